@@ -5,16 +5,25 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	tailscale "tailscale.com/client/tailscale/v2"
 
 	"github.com/R167/tailscale-mcp/internal"
+	"github.com/R167/tailscale-mcp/redact"
 )
 
+// minPollInterval keeps resource subscriptions from polling the Tailscale API too aggressively
+const minPollInterval = 5 * time.Second
+
 type Config struct {
-	Tailnet string
-	Port    string
-	Client  internal.TailscaleClient
+	Tailnet  string
+	Port     string
+	Client   internal.TailscaleClient
+	Redactor *redact.Redactor
+	// PollInterval is how often subscribed resources are checked for changes; zero uses the
+	// server default
+	PollInterval time.Duration
 }
 
 func Load() (*Config, error) {
@@ -38,15 +47,27 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("invalid tailnet configuration: %w", err)
 	}
 
+	redactor, err := createRedactor()
+	if err != nil {
+		return nil, fmt.Errorf("invalid redaction configuration: %w", err)
+	}
+
+	pollInterval, err := parsePollInterval(os.Getenv("RESOURCE_POLL_INTERVAL"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid poll interval configuration: %w", err)
+	}
+
 	client, err := createTailscaleClient(tailnet)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Tailscale client: %w", err)
 	}
 
 	cfg := &Config{
-		Tailnet: tailnet,
-		Port:    port,
-		Client:  &internal.TailscaleClientAdapter{Client: client},
+		Tailnet:      tailnet,
+		Port:         port,
+		Client:       &internal.TailscaleClientAdapter{Client: client},
+		Redactor:     redactor,
+		PollInterval: pollInterval,
 	}
 
 	// Validate complete configuration
@@ -87,6 +108,41 @@ func createTailscaleClient(tailnet string) (*tailscale.Client, error) {
 	return nil, fmt.Errorf("either TAILSCALE_API_KEY or TAILSCALE_CLIENT_ID/TAILSCALE_CLIENT_SECRET environment variables are required")
 }
 
+// createRedactor builds the tool output redactor from REDACT_PATHS, extra fields to mask, and
+// UNREDACT_PATHS, fields this deployment allows through. Both are comma-separated JSON paths.
+func createRedactor() (*redact.Redactor, error) {
+	paths, err := redact.ParsePaths(os.Getenv("REDACT_PATHS"))
+	if err != nil {
+		return nil, fmt.Errorf("REDACT_PATHS: %w", err)
+	}
+
+	allowed, err := redact.ParsePaths(os.Getenv("UNREDACT_PATHS"))
+	if err != nil {
+		return nil, fmt.Errorf("UNREDACT_PATHS: %w", err)
+	}
+
+	return redact.New(paths, allowed), nil
+}
+
+// parsePollInterval parses RESOURCE_POLL_INTERVAL, a Go duration such as "30s" or "2m". An
+// empty value leaves the interval at zero so the server default applies.
+func parsePollInterval(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	interval, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("RESOURCE_POLL_INTERVAL must be a duration such as 30s: %w", err)
+	}
+
+	if interval < minPollInterval {
+		return 0, fmt.Errorf("RESOURCE_POLL_INTERVAL must be at least %s, got %s", minPollInterval, interval)
+	}
+
+	return interval, nil
+}
+
 // validatePort checks if the port is valid
 func validatePort(port string) error {
 	if port == "" {
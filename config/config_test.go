@@ -4,6 +4,7 @@ import (
 	"context"
 	"strings"
 	"testing"
+	"time"
 
 	tailscale "tailscale.com/client/tailscale/v2"
 
@@ -114,6 +115,89 @@ func TestLoad_Error_MissingClientSecret(t *testing.T) {
 	}
 }
 
+func TestLoad_Success_Redaction(t *testing.T) {
+	t.Setenv("TAILSCALE_TAILNET", "test-tailnet")
+	t.Setenv("TAILSCALE_API_KEY", "test-api-key")
+	t.Setenv("REDACT_PATHS", "devices.hostname")
+	t.Setenv("UNREDACT_PATHS", "nodeKey")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	output := cfg.Redactor.JSON(`{"devices": [{"hostname": "laptop", "nodeKey": "nodekey:abc123", "machineKey": "mkey:def456"}]}`)
+	if strings.Contains(output, "laptop") || strings.Contains(output, "def456") {
+		t.Errorf("Expected the configured and default fields to be redacted: %s", output)
+	}
+	if !strings.Contains(output, "nodekey:abc123") {
+		t.Errorf("Expected the allowed field to be kept: %s", output)
+	}
+}
+
+func TestLoad_Error_InvalidRedactPaths(t *testing.T) {
+	t.Setenv("TAILSCALE_TAILNET", "test-tailnet")
+	t.Setenv("TAILSCALE_API_KEY", "test-api-key")
+	t.Setenv("UNREDACT_PATHS", "devices..nodeKey")
+
+	_, err := Load()
+	if err == nil {
+		t.Fatal("Expected error for invalid redaction path")
+	}
+
+	expected := "UNREDACT_PATHS"
+	if !strings.Contains(err.Error(), expected) {
+		t.Errorf("Expected error containing '%s', got '%s'", expected, err.Error())
+	}
+}
+
+func TestLoad_Success_PollInterval(t *testing.T) {
+	t.Setenv("TAILSCALE_TAILNET", "test-tailnet")
+	t.Setenv("TAILSCALE_API_KEY", "test-api-key")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if cfg.PollInterval != 0 {
+		t.Errorf("Expected the default poll interval when unset, got %s", cfg.PollInterval)
+	}
+
+	t.Setenv("RESOURCE_POLL_INTERVAL", "2m")
+
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if cfg.PollInterval != 2*time.Minute {
+		t.Errorf("Expected a poll interval of 2m, got %s", cfg.PollInterval)
+	}
+}
+
+func TestLoad_Error_InvalidPollInterval(t *testing.T) {
+	testCases := map[string]string{
+		"30":  "must be a duration",
+		"1s":  "at least 5s",
+		"-1m": "at least 5s",
+	}
+
+	for value, expected := range testCases {
+		t.Run(value, func(t *testing.T) {
+			t.Setenv("TAILSCALE_TAILNET", "test-tailnet")
+			t.Setenv("TAILSCALE_API_KEY", "test-api-key")
+			t.Setenv("RESOURCE_POLL_INTERVAL", value)
+
+			_, err := Load()
+			if err == nil {
+				t.Fatalf("Expected error for poll interval %q", value)
+			}
+			if !strings.Contains(err.Error(), expected) {
+				t.Errorf("Expected error containing '%s', got '%s'", expected, err.Error())
+			}
+		})
+	}
+}
+
 func TestCreateTailscaleClient_APIKey(t *testing.T) {
 	t.Setenv("TAILSCALE_API_KEY", "test-api-key")
 
@@ -395,10 +479,25 @@ func (m *mockClient) Keys() internal.KeysResource {
 	return &mockKeysResource{}
 }
 
+func (m *mockClient) Users() internal.UsersResource {
+	return &mockUsersResource{}
+}
+
+func (m *mockClient) DNS() internal.DNSResource {
+	return &mockDNSResource{}
+}
+
+func (m *mockClient) Webhooks() internal.WebhooksResource {
+	return &mockWebhooksResource{}
+}
+
 // Mock resource implementations
 type mockDevicesResource struct{}
 type mockPolicyFileResource struct{}
 type mockKeysResource struct{}
+type mockUsersResource struct{}
+type mockDNSResource struct{}
+type mockWebhooksResource struct{}
 
 func (m *mockDevicesResource) List(ctx context.Context) ([]tailscale.Device, error) {
 	return nil, nil
@@ -416,10 +515,146 @@ func (m *mockDevicesResource) SubnetRoutes(ctx context.Context, deviceID string)
 	return nil, nil
 }
 
+func (m *mockDevicesResource) SetSubnetRoutes(ctx context.Context, deviceID string, routes []string) error {
+	return nil
+}
+
+func (m *mockDevicesResource) SetAuthorized(ctx context.Context, deviceID string, authorized bool) error {
+	return nil
+}
+
+func (m *mockDevicesResource) SetTags(ctx context.Context, deviceID string, tags []string) error {
+	return nil
+}
+
+func (m *mockDevicesResource) SetName(ctx context.Context, deviceID, name string) error {
+	return nil
+}
+
+func (m *mockDevicesResource) SetKey(ctx context.Context, deviceID string, key tailscale.DeviceKey) error {
+	return nil
+}
+
+func (m *mockDevicesResource) Delete(ctx context.Context, deviceID string) error {
+	return nil
+}
+
 func (m *mockPolicyFileResource) Get(ctx context.Context) (*tailscale.ACL, error) {
 	return nil, nil
 }
 
+func (m *mockPolicyFileResource) Raw(ctx context.Context) (*tailscale.RawACL, error) {
+	return nil, nil
+}
+
+func (m *mockPolicyFileResource) Set(ctx context.Context, policy string, etag string) error {
+	return nil
+}
+
+func (m *mockPolicyFileResource) Validate(ctx context.Context, policy string) (*tailscale.APIError, error) {
+	return nil, nil
+}
+
 func (m *mockKeysResource) List(ctx context.Context, all bool) ([]tailscale.Key, error) {
 	return nil, nil
 }
+
+func (m *mockKeysResource) CreateAuthKey(ctx context.Context, request tailscale.CreateKeyRequest) (*tailscale.Key, error) {
+	return nil, nil
+}
+
+func (m *mockKeysResource) Get(ctx context.Context, id string) (*tailscale.Key, error) {
+	return nil, nil
+}
+
+func (m *mockKeysResource) Delete(ctx context.Context, id string) error {
+	return nil
+}
+
+func (m *mockUsersResource) List(ctx context.Context, userType *tailscale.UserType, role *tailscale.UserRole) ([]tailscale.User, error) {
+	return nil, nil
+}
+
+func (m *mockUsersResource) Get(ctx context.Context, userID string) (*tailscale.User, error) {
+	return nil, nil
+}
+
+func (m *mockUsersResource) SetRole(ctx context.Context, userID string, role tailscale.UserRole) error {
+	return nil
+}
+
+func (m *mockUsersResource) Suspend(ctx context.Context, userID string) error {
+	return nil
+}
+
+func (m *mockUsersResource) Restore(ctx context.Context, userID string) error {
+	return nil
+}
+
+func (m *mockUsersResource) Delete(ctx context.Context, userID string) error {
+	return nil
+}
+
+func (m *mockDNSResource) Nameservers(ctx context.Context) ([]string, error) {
+	return nil, nil
+}
+
+func (m *mockDNSResource) SetNameservers(ctx context.Context, nameservers []string) error {
+	return nil
+}
+
+func (m *mockDNSResource) SearchPaths(ctx context.Context) ([]string, error) {
+	return nil, nil
+}
+
+func (m *mockDNSResource) SetSearchPaths(ctx context.Context, searchPaths []string) error {
+	return nil
+}
+
+func (m *mockDNSResource) SplitDNS(ctx context.Context) (tailscale.SplitDNSResponse, error) {
+	return nil, nil
+}
+
+func (m *mockDNSResource) SetSplitDNS(ctx context.Context, request tailscale.SplitDNSRequest) error {
+	return nil
+}
+
+func (m *mockDNSResource) UpdateSplitDNS(ctx context.Context, request tailscale.SplitDNSRequest) (tailscale.SplitDNSResponse, error) {
+	return nil, nil
+}
+
+func (m *mockDNSResource) Preferences(ctx context.Context) (*tailscale.DNSPreferences, error) {
+	return nil, nil
+}
+
+func (m *mockDNSResource) SetPreferences(ctx context.Context, preferences tailscale.DNSPreferences) error {
+	return nil
+}
+
+func (m *mockWebhooksResource) List(ctx context.Context) ([]tailscale.Webhook, error) {
+	return nil, nil
+}
+
+func (m *mockWebhooksResource) Get(ctx context.Context, endpointID string) (*tailscale.Webhook, error) {
+	return nil, nil
+}
+
+func (m *mockWebhooksResource) Create(ctx context.Context, request tailscale.CreateWebhookRequest) (*tailscale.Webhook, error) {
+	return nil, nil
+}
+
+func (m *mockWebhooksResource) Update(ctx context.Context, endpointID string, subscriptions []tailscale.WebhookSubscriptionType) (*tailscale.Webhook, error) {
+	return nil, nil
+}
+
+func (m *mockWebhooksResource) Delete(ctx context.Context, endpointID string) error {
+	return nil
+}
+
+func (m *mockWebhooksResource) Test(ctx context.Context, endpointID string) error {
+	return nil
+}
+
+func (m *mockWebhooksResource) RotateSecret(ctx context.Context, endpointID string) (*tailscale.Webhook, error) {
+	return nil, nil
+}
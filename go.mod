@@ -2,13 +2,14 @@ module github.com/R167/tailscale-mcp
 
 go 1.25.0
 
-require github.com/modelcontextprotocol/go-sdk v0.2.0
+require github.com/modelcontextprotocol/go-sdk v0.3.0
 
 require github.com/joho/godotenv v1.5.1 // indirect
 
 require (
-	github.com/tailscale/hujson v0.0.0-20220506213045-af5ed07155e5 // indirect
-	github.com/yosida95/uritemplate/v3 v3.0.2 // indirect
-	golang.org/x/oauth2 v0.30.0 // indirect
-	tailscale.com/client/tailscale/v2 v2.0.0-20250809230149-9ce246ebbf4e
+	github.com/google/jsonschema-go v0.2.0
+	github.com/tailscale/hujson v0.0.0-20220506213045-af5ed07155e5
+	github.com/yosida95/uritemplate/v3 v3.0.2
+	golang.org/x/oauth2 v0.34.0 // indirect
+	tailscale.com/client/tailscale/v2 v2.9.0
 )
@@ -0,0 +1,83 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	tailscale "tailscale.com/client/tailscale/v2"
+)
+
+// apiRequest describes a call to a Tailscale API endpoint that the client library does not
+// cover, or whose response it does not expose in full
+type apiRequest struct {
+	method      string
+	path        []string // path below /api/v2/tailnet/<tailnet>/, or below /api/v2/ if global is set
+	global      bool
+	body        []byte
+	contentType string
+}
+
+// doAPIRequest sends req using the client's base URL, tailnet, and credentials. A 2xx response
+// body is decoded into out when out is non-nil and the body is not empty; error responses are
+// returned as a tailscale.APIError so that helpers such as tailscale.IsNotFound keep working.
+// The client must already be initialized, which happens when any of its resources is obtained.
+func doAPIRequest(ctx context.Context, client *tailscale.Client, req apiRequest, out any) error {
+	elements := []string{"/api/v2"}
+	if !req.global {
+		elements = append(elements, "tailnet", url.PathEscape(client.Tailnet))
+	}
+	for _, element := range req.path {
+		elements = append(elements, url.PathEscape(element))
+	}
+	uri := client.BaseURL.JoinPath(elements...)
+
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, uri.String(), bytes.NewReader(req.body))
+	if err != nil {
+		return err
+	}
+
+	if client.UserAgent != "" {
+		httpReq.Header.Set("User-Agent", client.UserAgent)
+	}
+	if req.body != nil {
+		contentType := req.contentType
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		httpReq.Header.Set("Content-Type", contentType)
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	if client.APIKey != "" {
+		httpReq.SetBasicAuth(client.APIKey, "")
+	}
+
+	resp, err := client.HTTP.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		var apiErr tailscale.APIError
+		if err := json.Unmarshal(body, &apiErr); err != nil {
+			return fmt.Errorf("unexpected response from Tailscale API (%d): %s", resp.StatusCode, bytes.TrimSpace(body))
+		}
+		apiErr.Status = resp.StatusCode
+		return apiErr
+	}
+
+	if out == nil || len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+	return json.Unmarshal(body, out)
+}
@@ -0,0 +1,161 @@
+package internal
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	tailscale "tailscale.com/client/tailscale/v2"
+)
+
+// newTestAdapter returns a client adapter that sends requests to handler
+func newTestAdapter(t *testing.T, handler http.HandlerFunc) *TailscaleClientAdapter {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	baseURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse test server URL: %v", err)
+	}
+
+	return &TailscaleClientAdapter{Client: &tailscale.Client{
+		BaseURL: baseURL,
+		APIKey:  "tskey-api-test",
+		Tailnet: "example.com",
+	}}
+}
+
+func TestPolicyFileValidate(t *testing.T) {
+	const policy = `{"acls": [], // comment
+}`
+
+	testCases := []struct {
+		name       string
+		status     int
+		response   string
+		wantStatus int
+		wantErr    bool
+	}{
+		{name: "Valid", status: http.StatusOK, response: `{}`},
+		{name: "ValidEmptyBody", status: http.StatusOK, response: ``},
+		{
+			name:       "TestsFailed",
+			status:     http.StatusOK,
+			response:   `{"message": "test(s) failed", "data": [{"user": "alice@example.com", "errors": ["alice@example.com cannot access tag:db:5432"]}]}`,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Rejected",
+			status:     http.StatusBadRequest,
+			response:   `{"message": "line 3: unknown group \"group:ops\""}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{name: "Unauthorized", status: http.StatusUnauthorized, response: `{"message": "invalid API key"}`, wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			adapter := newTestAdapter(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPost || r.URL.Path != "/api/v2/tailnet/example.com/acl/validate" {
+					t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+				}
+				if got := r.Header.Get("Content-Type"); got != "application/hujson" {
+					t.Errorf("Expected HuJSON content type, got %s", got)
+				}
+				if user, _, ok := r.BasicAuth(); !ok || user != "tskey-api-test" {
+					t.Error("Expected the API key as basic auth")
+				}
+				if body, _ := io.ReadAll(r.Body); string(body) != policy {
+					t.Errorf("Expected the policy to be sent unchanged, got %q", body)
+				}
+
+				w.WriteHeader(tc.status)
+				_, _ = io.WriteString(w, tc.response)
+			})
+
+			apiErr, err := adapter.PolicyFile().Validate(context.Background(), policy)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("Expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if tc.wantStatus == 0 {
+				if apiErr != nil {
+					t.Errorf("Expected a valid policy, got %+v", apiErr)
+				}
+				return
+			}
+			if apiErr == nil || apiErr.Status != tc.wantStatus || apiErr.Message == "" {
+				t.Errorf("Expected problems with status %d, got %+v", tc.wantStatus, apiErr)
+			}
+		})
+	}
+}
+
+func TestUserActions(t *testing.T) {
+	testCases := []struct {
+		name string
+		call func(ctx context.Context, users UsersResource) error
+		path string
+		body string
+	}{
+		{
+			name: "SetRole",
+			call: func(ctx context.Context, users UsersResource) error {
+				return users.SetRole(ctx, "u123", tailscale.UserRoleAdmin)
+			},
+			path: "/api/v2/users/u123/role",
+			body: `{"role":"admin"}`,
+		},
+		{
+			name: "Suspend",
+			call: func(ctx context.Context, users UsersResource) error { return users.Suspend(ctx, "u123") },
+			path: "/api/v2/users/u123/suspend",
+		},
+		{
+			name: "Restore",
+			call: func(ctx context.Context, users UsersResource) error { return users.Restore(ctx, "u123") },
+			path: "/api/v2/users/u123/restore",
+		},
+		{
+			name: "Delete",
+			call: func(ctx context.Context, users UsersResource) error { return users.Delete(ctx, "u123") },
+			path: "/api/v2/users/u123/delete",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			adapter := newTestAdapter(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPost || r.URL.Path != tc.path {
+					t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+				}
+				if body, _ := io.ReadAll(r.Body); string(body) != tc.body {
+					t.Errorf("Expected body %q, got %q", tc.body, body)
+				}
+			})
+
+			if err := tc.call(context.Background(), adapter.Users()); err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+
+	adapter := newTestAdapter(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = io.WriteString(w, `{"message": "user not found"}`)
+	})
+	if err := adapter.Users().Suspend(context.Background(), "missing"); !tailscale.IsNotFound(err) {
+		t.Errorf("Expected a not found error, got %v", err)
+	}
+}
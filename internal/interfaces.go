@@ -2,6 +2,9 @@ package internal
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
 
 	tailscale "tailscale.com/client/tailscale/v2"
 )
@@ -11,6 +14,9 @@ type TailscaleClient interface {
 	Devices() DevicesResource
 	PolicyFile() PolicyFileResource
 	Keys() KeysResource
+	Users() UsersResource
+	DNS() DNSResource
+	Webhooks() WebhooksResource
 }
 
 // DevicesResource defines the interface for device operations
@@ -19,16 +25,71 @@ type DevicesResource interface {
 	ListWithAllFields(ctx context.Context) ([]tailscale.Device, error)
 	GetWithAllFields(ctx context.Context, deviceID string) (*tailscale.Device, error)
 	SubnetRoutes(ctx context.Context, deviceID string) (*tailscale.DeviceRoutes, error)
+	SetSubnetRoutes(ctx context.Context, deviceID string, routes []string) error
+	SetAuthorized(ctx context.Context, deviceID string, authorized bool) error
+	SetTags(ctx context.Context, deviceID string, tags []string) error
+	SetName(ctx context.Context, deviceID, name string) error
+	SetKey(ctx context.Context, deviceID string, key tailscale.DeviceKey) error
+	Delete(ctx context.Context, deviceID string) error
 }
 
 // PolicyFileResource defines the interface for ACL operations
 type PolicyFileResource interface {
 	Get(ctx context.Context) (*tailscale.ACL, error)
+	Raw(ctx context.Context) (*tailscale.RawACL, error)
+	// Validate checks a HuJSON policy file, including its embedded tests, without applying it.
+	// It returns the problems the API reported, or nil if the policy is valid. The returned
+	// Status is 400 when the policy was rejected and 200 when it parsed but its tests failed.
+	// The error is only set when the policy could not be checked at all.
+	Validate(ctx context.Context, policy string) (*tailscale.APIError, error)
+	// Set replaces the policy file with HuJSON text. A non-empty etag is sent as If-Match so
+	// that the update fails if the policy changed since that version was read.
+	Set(ctx context.Context, policy string, etag string) error
 }
 
 // KeysResource defines the interface for API key operations
 type KeysResource interface {
 	List(ctx context.Context, all bool) ([]tailscale.Key, error)
+	CreateAuthKey(ctx context.Context, request tailscale.CreateKeyRequest) (*tailscale.Key, error)
+	Get(ctx context.Context, id string) (*tailscale.Key, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// UsersResource defines the interface for user operations
+type UsersResource interface {
+	List(ctx context.Context, userType *tailscale.UserType, role *tailscale.UserRole) ([]tailscale.User, error)
+	Get(ctx context.Context, userID string) (*tailscale.User, error)
+	SetRole(ctx context.Context, userID string, role tailscale.UserRole) error
+	Suspend(ctx context.Context, userID string) error
+	Restore(ctx context.Context, userID string) error
+	Delete(ctx context.Context, userID string) error
+}
+
+// DNSResource defines the interface for DNS operations
+type DNSResource interface {
+	Nameservers(ctx context.Context) ([]string, error)
+	SetNameservers(ctx context.Context, nameservers []string) error
+	SearchPaths(ctx context.Context) ([]string, error)
+	SetSearchPaths(ctx context.Context, searchPaths []string) error
+	SplitDNS(ctx context.Context) (tailscale.SplitDNSResponse, error)
+	// SetSplitDNS replaces the whole split DNS configuration
+	SetSplitDNS(ctx context.Context, request tailscale.SplitDNSRequest) error
+	// UpdateSplitDNS changes only the domains in request; a domain with no nameservers is removed
+	UpdateSplitDNS(ctx context.Context, request tailscale.SplitDNSRequest) (tailscale.SplitDNSResponse, error)
+	Preferences(ctx context.Context) (*tailscale.DNSPreferences, error)
+	SetPreferences(ctx context.Context, preferences tailscale.DNSPreferences) error
+}
+
+// WebhooksResource defines the interface for webhook endpoint operations
+type WebhooksResource interface {
+	List(ctx context.Context) ([]tailscale.Webhook, error)
+	Get(ctx context.Context, endpointID string) (*tailscale.Webhook, error)
+	Create(ctx context.Context, request tailscale.CreateWebhookRequest) (*tailscale.Webhook, error)
+	Update(ctx context.Context, endpointID string, subscriptions []tailscale.WebhookSubscriptionType) (*tailscale.Webhook, error)
+	Delete(ctx context.Context, endpointID string) error
+	// Test queues a test event; delivery happens asynchronously a few seconds later
+	Test(ctx context.Context, endpointID string) error
+	RotateSecret(ctx context.Context, endpointID string) (*tailscale.Webhook, error)
 }
 
 // TailscaleClientAdapter wraps the real Tailscale client to implement our interface
@@ -48,6 +109,18 @@ func (t *TailscaleClientAdapter) Keys() KeysResource {
 	return &KeysResourceAdapter{t.Client.Keys()}
 }
 
+func (t *TailscaleClientAdapter) Users() UsersResource {
+	return &UsersResourceAdapter{t.Client.Users()}
+}
+
+func (t *TailscaleClientAdapter) DNS() DNSResource {
+	return &DNSResourceAdapter{t.Client.DNS()}
+}
+
+func (t *TailscaleClientAdapter) Webhooks() WebhooksResource {
+	return &WebhooksResourceAdapter{t.Client.Webhooks()}
+}
+
 // DevicesResourceAdapter adapts the real DevicesResource
 type DevicesResourceAdapter struct {
 	*tailscale.DevicesResource
@@ -69,6 +142,30 @@ func (d *DevicesResourceAdapter) SubnetRoutes(ctx context.Context, deviceID stri
 	return d.DevicesResource.SubnetRoutes(ctx, deviceID)
 }
 
+func (d *DevicesResourceAdapter) SetSubnetRoutes(ctx context.Context, deviceID string, routes []string) error {
+	return d.DevicesResource.SetSubnetRoutes(ctx, deviceID, routes)
+}
+
+func (d *DevicesResourceAdapter) SetAuthorized(ctx context.Context, deviceID string, authorized bool) error {
+	return d.DevicesResource.SetAuthorized(ctx, deviceID, authorized)
+}
+
+func (d *DevicesResourceAdapter) SetTags(ctx context.Context, deviceID string, tags []string) error {
+	return d.DevicesResource.SetTags(ctx, deviceID, tags)
+}
+
+func (d *DevicesResourceAdapter) SetName(ctx context.Context, deviceID, name string) error {
+	return d.DevicesResource.SetName(ctx, deviceID, name)
+}
+
+func (d *DevicesResourceAdapter) SetKey(ctx context.Context, deviceID string, key tailscale.DeviceKey) error {
+	return d.DevicesResource.SetKey(ctx, deviceID, key)
+}
+
+func (d *DevicesResourceAdapter) Delete(ctx context.Context, deviceID string) error {
+	return d.DevicesResource.Delete(ctx, deviceID)
+}
+
 // PolicyFileResourceAdapter adapts the real PolicyFileResource
 type PolicyFileResourceAdapter struct {
 	*tailscale.PolicyFileResource
@@ -78,6 +175,40 @@ func (p *PolicyFileResourceAdapter) Get(ctx context.Context) (*tailscale.ACL, er
 	return p.PolicyFileResource.Get(ctx)
 }
 
+func (p *PolicyFileResourceAdapter) Raw(ctx context.Context) (*tailscale.RawACL, error) {
+	return p.PolicyFileResource.Raw(ctx)
+}
+
+func (p *PolicyFileResourceAdapter) Set(ctx context.Context, policy string, etag string) error {
+	return p.PolicyFileResource.Set(ctx, policy, etag)
+}
+
+// Validate calls the validate endpoint directly because the client library flattens the
+// per-user test failures it returns into an error string
+func (p *PolicyFileResourceAdapter) Validate(ctx context.Context, policy string) (*tailscale.APIError, error) {
+	var result tailscale.APIError
+	err := doAPIRequest(ctx, p.Client, apiRequest{
+		method:      http.MethodPost,
+		path:        []string{"acl", "validate"},
+		body:        []byte(policy),
+		contentType: "application/hujson",
+	}, &result)
+
+	var apiErr tailscale.APIError
+	if errors.As(err, &apiErr) && apiErr.Status == http.StatusBadRequest {
+		return &apiErr, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Message == "" && len(result.Data) == 0 {
+		return nil, nil
+	}
+	result.Status = http.StatusOK
+	return &result, nil
+}
+
 // KeysResourceAdapter adapts the real KeysResource
 type KeysResourceAdapter struct {
 	*tailscale.KeysResource
@@ -86,3 +217,138 @@ type KeysResourceAdapter struct {
 func (k *KeysResourceAdapter) List(ctx context.Context, all bool) ([]tailscale.Key, error) {
 	return k.KeysResource.List(ctx, all)
 }
+
+func (k *KeysResourceAdapter) CreateAuthKey(ctx context.Context, request tailscale.CreateKeyRequest) (*tailscale.Key, error) {
+	return k.KeysResource.CreateAuthKey(ctx, request)
+}
+
+func (k *KeysResourceAdapter) Get(ctx context.Context, id string) (*tailscale.Key, error) {
+	return k.KeysResource.Get(ctx, id)
+}
+
+func (k *KeysResourceAdapter) Delete(ctx context.Context, id string) error {
+	return k.KeysResource.Delete(ctx, id)
+}
+
+// UsersResourceAdapter adapts the real UsersResource
+type UsersResourceAdapter struct {
+	*tailscale.UsersResource
+}
+
+func (u *UsersResourceAdapter) List(ctx context.Context, userType *tailscale.UserType, role *tailscale.UserRole) ([]tailscale.User, error) {
+	return u.UsersResource.List(ctx, userType, role)
+}
+
+func (u *UsersResourceAdapter) Get(ctx context.Context, userID string) (*tailscale.User, error) {
+	return u.UsersResource.Get(ctx, userID)
+}
+
+// SetRole, Suspend, Restore, and Delete call the API directly because the client library
+// does not cover user management actions
+
+func (u *UsersResourceAdapter) SetRole(ctx context.Context, userID string, role tailscale.UserRole) error {
+	body, err := json.Marshal(map[string]tailscale.UserRole{"role": role})
+	if err != nil {
+		return err
+	}
+	return doAPIRequest(ctx, u.Client, apiRequest{
+		method: http.MethodPost,
+		path:   []string{"users", userID, "role"},
+		global: true,
+		body:   body,
+	}, nil)
+}
+
+func (u *UsersResourceAdapter) Suspend(ctx context.Context, userID string) error {
+	return u.userAction(ctx, userID, "suspend")
+}
+
+func (u *UsersResourceAdapter) Restore(ctx context.Context, userID string) error {
+	return u.userAction(ctx, userID, "restore")
+}
+
+func (u *UsersResourceAdapter) Delete(ctx context.Context, userID string) error {
+	return u.userAction(ctx, userID, "delete")
+}
+
+func (u *UsersResourceAdapter) userAction(ctx context.Context, userID, action string) error {
+	return doAPIRequest(ctx, u.Client, apiRequest{
+		method: http.MethodPost,
+		path:   []string{"users", userID, action},
+		global: true,
+	}, nil)
+}
+
+// DNSResourceAdapter adapts the real DNSResource
+type DNSResourceAdapter struct {
+	*tailscale.DNSResource
+}
+
+func (d *DNSResourceAdapter) Nameservers(ctx context.Context) ([]string, error) {
+	return d.DNSResource.Nameservers(ctx)
+}
+
+func (d *DNSResourceAdapter) SetNameservers(ctx context.Context, nameservers []string) error {
+	return d.DNSResource.SetNameservers(ctx, nameservers)
+}
+
+func (d *DNSResourceAdapter) SearchPaths(ctx context.Context) ([]string, error) {
+	return d.DNSResource.SearchPaths(ctx)
+}
+
+func (d *DNSResourceAdapter) SetSearchPaths(ctx context.Context, searchPaths []string) error {
+	return d.DNSResource.SetSearchPaths(ctx, searchPaths)
+}
+
+func (d *DNSResourceAdapter) SplitDNS(ctx context.Context) (tailscale.SplitDNSResponse, error) {
+	return d.DNSResource.SplitDNS(ctx)
+}
+
+func (d *DNSResourceAdapter) SetSplitDNS(ctx context.Context, request tailscale.SplitDNSRequest) error {
+	return d.DNSResource.SetSplitDNS(ctx, request)
+}
+
+func (d *DNSResourceAdapter) UpdateSplitDNS(ctx context.Context, request tailscale.SplitDNSRequest) (tailscale.SplitDNSResponse, error) {
+	return d.DNSResource.UpdateSplitDNS(ctx, request)
+}
+
+func (d *DNSResourceAdapter) Preferences(ctx context.Context) (*tailscale.DNSPreferences, error) {
+	return d.DNSResource.Preferences(ctx)
+}
+
+func (d *DNSResourceAdapter) SetPreferences(ctx context.Context, preferences tailscale.DNSPreferences) error {
+	return d.DNSResource.SetPreferences(ctx, preferences)
+}
+
+// WebhooksResourceAdapter adapts the real WebhooksResource
+type WebhooksResourceAdapter struct {
+	*tailscale.WebhooksResource
+}
+
+func (w *WebhooksResourceAdapter) List(ctx context.Context) ([]tailscale.Webhook, error) {
+	return w.WebhooksResource.List(ctx)
+}
+
+func (w *WebhooksResourceAdapter) Get(ctx context.Context, endpointID string) (*tailscale.Webhook, error) {
+	return w.WebhooksResource.Get(ctx, endpointID)
+}
+
+func (w *WebhooksResourceAdapter) Create(ctx context.Context, request tailscale.CreateWebhookRequest) (*tailscale.Webhook, error) {
+	return w.WebhooksResource.Create(ctx, request)
+}
+
+func (w *WebhooksResourceAdapter) Update(ctx context.Context, endpointID string, subscriptions []tailscale.WebhookSubscriptionType) (*tailscale.Webhook, error) {
+	return w.WebhooksResource.Update(ctx, endpointID, subscriptions)
+}
+
+func (w *WebhooksResourceAdapter) Delete(ctx context.Context, endpointID string) error {
+	return w.WebhooksResource.Delete(ctx, endpointID)
+}
+
+func (w *WebhooksResourceAdapter) Test(ctx context.Context, endpointID string) error {
+	return w.WebhooksResource.Test(ctx, endpointID)
+}
+
+func (w *WebhooksResourceAdapter) RotateSecret(ctx context.Context, endpointID string) (*tailscale.Webhook, error) {
+	return w.WebhooksResource.RotateSecret(ctx, endpointID)
+}
@@ -21,15 +21,22 @@ func main() {
 		showVersion = flag.Bool("version", false, "Show version information")
 		showHelp    = flag.Bool("help", false, "Show help information")
 		loadEnv     = flag.Bool("env", true, "Load .env file if present (default: true)")
+		transport   = flag.String("transport", server.TransportHTTP, "Transport to serve MCP over: stdio or http")
 	)
 	flag.Parse()
 
+	if err := server.ValidateTransport(*transport); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+
 	// Load .env file if requested
 	if *loadEnv {
 		if err := godotenv.Load(); err != nil {
 			// Only log in development - production deployments typically use env vars directly
 			if os.Getenv("ENVIRONMENT") == "development" {
-				fmt.Printf("Note: .env file not found (%v)\n", err)
+				// stderr, since stdout carries the protocol stream in stdio mode
+				fmt.Fprintf(os.Stderr, "Note: .env file not found (%v)\n", err)
 			}
 		}
 	}
@@ -75,11 +82,16 @@ func main() {
 		fmt.Println("  TAILSCALE_API_KEY          Your Tailscale API key")
 		fmt.Println("  TAILSCALE_CLIENT_ID        OAuth client ID (alternative to API key)")
 		fmt.Println("  TAILSCALE_CLIENT_SECRET    OAuth client secret (required with CLIENT_ID)")
-		fmt.Println("  PORT                       HTTP server port (default: 8080)")
+		fmt.Println("  PORT                       HTTP server port (default: 8080, http transport only)")
+		fmt.Println("  REDACT_PATHS               Extra comma-separated JSON fields to mask in tool output")
+		fmt.Println("  UNREDACT_PATHS             Comma-separated JSON fields to leave unmasked (e.g. nodeKey)")
+		fmt.Println("  RESOURCE_POLL_INTERVAL     How often subscribed resources are checked for changes (default: 30s)")
 		fmt.Println("\nConfiguration:")
 		fmt.Println("  Environment variables can be set via .env file or system environment.")
 		fmt.Println("\nUsage:")
-		fmt.Println("  tailscale-mcp              Start the server")
+		fmt.Println("  tailscale-mcp              Start the server over streamable HTTP")
+		fmt.Println("  tailscale-mcp --transport=stdio")
+		fmt.Println("                             Serve one client over stdin/stdout (logs go to stderr)")
 		fmt.Println("  tailscale-mcp --version    Show version and configuration")
 		fmt.Println("  tailscale-mcp --help       Show this help")
 		fmt.Println("  tailscale-mcp --env=false  Disable .env file loading")
@@ -87,5 +99,5 @@ func main() {
 		os.Exit(0)
 	}
 
-	server.Start()
+	server.Start(*transport)
 }
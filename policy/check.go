@@ -0,0 +1,101 @@
+package policy
+
+import "fmt"
+
+// Match is a rule that allows a connection, with the selectors that matched each end
+type Match struct {
+	Rule        string `json:"rule"`
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+	Entry       any    `json:"entry"`
+}
+
+// Decision is the result of checking whether a connection is allowed
+type Decision struct {
+	Allowed bool `json:"allowed"`
+	// Matches lists every rule that allows the connection, in policy order
+	Matches []Match `json:"matches,omitempty"`
+	// Warnings lists rules that might allow the connection but depend on selectors that could
+	// not be evaluated locally; those selectors are treated as not matching
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// Check reports whether src may open a connection to dst using proto (tcp, udp, icmp, or an
+// IANA protocol number; tcp if empty) on port. Tailscale denies everything that no rule
+// allows, so the connection is allowed exactly when at least one rule matches. The port is
+// ignored for protocols without ports, such as ICMP.
+func (p *Policy) Check(src, dst Node, proto string, port int) Decision {
+	proto = normalizeProtocol(proto)
+	if proto == "" {
+		proto = "tcp"
+	}
+	var decision Decision
+	for _, rule := range p.rules {
+		m := &matcher{policy: p}
+
+		source, sourceOK := m.firstSource(rule, src)
+		if !sourceOK && len(m.warnings) == 0 {
+			continue
+		}
+
+		// uncertain is set when the rule might allow the connection but depends on a
+		// selector that could not be evaluated
+		matched, uncertain := false, false
+		for _, dest := range rule.Destinations {
+			if !allowsProtocol(dest.Protocols, proto) {
+				continue
+			}
+			if hasPorts(proto) && !allowsPort(dest.Ports, port) {
+				continue
+			}
+
+			warnings := len(m.warnings)
+			if !m.matchesDestination(dest.Selector, dst, src) {
+				uncertain = uncertain || len(m.warnings) > warnings
+				continue
+			}
+			if !sourceOK {
+				uncertain = true
+				continue
+			}
+
+			decision.Matches = append(decision.Matches, Match{
+				Rule:        rule.Path,
+				Source:      source,
+				Destination: dest.Raw,
+				Entry:       rule.Entry,
+			})
+			matched = true
+			break
+		}
+
+		if !matched && uncertain {
+			for _, warning := range m.warnings {
+				decision.Warnings = append(decision.Warnings, fmt.Sprintf("%s: %s", rule.Path, warning))
+			}
+		}
+	}
+
+	decision.Allowed = len(decision.Matches) > 0
+	return decision
+}
+
+// firstSource returns the first of a rule's sources that covers node
+func (m *matcher) firstSource(rule Rule, node Node) (string, bool) {
+	for _, source := range rule.Sources {
+		if m.matchesSource(source, node) {
+			return source, true
+		}
+	}
+	return "", false
+}
+
+// hasPorts reports whether connections using proto are identified by a port
+func hasPorts(proto string) bool {
+	switch proto {
+	case "tcp", "udp", "sctp":
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,158 @@
+package policy
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// OutboundRule is the access one rule gives a node to destinations in the tailnet
+type OutboundRule struct {
+	Rule string `json:"rule"`
+	// Source is the rule's source selector that covers the node
+	Source       string                `json:"source"`
+	Destinations []OutboundDestination `json:"destinations"`
+}
+
+// OutboundDestination is one destination of a rule with the devices it covers
+type OutboundDestination struct {
+	Destination string   `json:"destination"`
+	Ports       string   `json:"ports"`
+	Protocols   []string `json:"protocols,omitempty"`
+	// Devices lists the names of the devices the destination covers. It is empty for
+	// destinations outside the device list, such as subnets behind a subnet router.
+	Devices []string `json:"devices"`
+}
+
+// InboundRule is the access one rule destination gives others to a node
+type InboundRule struct {
+	Rule string `json:"rule"`
+	// Destination is the rule's destination that covers the node
+	Destination string   `json:"destination"`
+	Ports       string   `json:"ports"`
+	Protocols   []string `json:"protocols,omitempty"`
+	Sources     []string `json:"sources"`
+	// Users lists the users the sources name directly or through groups
+	Users []string `json:"users,omitempty"`
+	// Devices lists the names of the devices the sources cover
+	Devices []string `json:"devices"`
+}
+
+// Outbound lists, rule by rule, every destination src may connect to. The device src itself
+// is left out of the destination devices. Warnings name selectors that could not be
+// evaluated locally; they are treated as not matching.
+func (p *Policy) Outbound(src Node) ([]OutboundRule, []string) {
+	var rules []OutboundRule
+	var warnings []string
+	for _, rule := range p.rules {
+		m := &matcher{policy: p}
+
+		source, ok := m.firstSource(rule, src)
+		if ok {
+			outbound := OutboundRule{Rule: rule.Path, Source: source}
+			for _, dest := range rule.Destinations {
+				devices := []string{}
+				for _, device := range p.devices {
+					node := NodeFromDevice(device)
+					if node.Name != "" && node.Name == src.Name {
+						continue
+					}
+					if m.matchesDestination(dest.Selector, node, src) {
+						devices = append(devices, device.Name)
+					}
+				}
+
+				outbound.Destinations = append(outbound.Destinations, OutboundDestination{
+					Destination: dest.Raw,
+					Ports:       FormatPorts(dest.Ports),
+					Protocols:   dest.Protocols,
+					Devices:     devices,
+				})
+			}
+			rules = append(rules, outbound)
+		}
+
+		warnings = appendRuleWarnings(warnings, rule, m.warnings)
+	}
+
+	return rules, warnings
+}
+
+// Inbound lists, rule by rule, who may connect to dst. A rule destination is listed when a
+// device or user in its sources may reach dst through it, or when its sources include
+// addresses outside the device list, such as a subnet. The device dst itself is left out of
+// the source devices.
+func (p *Policy) Inbound(dst Node) ([]InboundRule, []string) {
+	var rules []InboundRule
+	var warnings []string
+	for _, rule := range p.rules {
+		m := &matcher{policy: p}
+
+		for _, dest := range rule.Destinations {
+			inbound := InboundRule{
+				Rule:        rule.Path,
+				Destination: dest.Raw,
+				Ports:       FormatPorts(dest.Ports),
+				Protocols:   dest.Protocols,
+				Sources:     rule.Sources,
+				Devices:     []string{},
+			}
+
+			for _, device := range p.devices {
+				src := NodeFromDevice(device)
+				if src.Name != "" && src.Name == dst.Name {
+					continue
+				}
+				if _, ok := m.firstSource(rule, src); ok && m.matchesDestination(dest.Selector, dst, src) {
+					inbound.Devices = append(inbound.Devices, device.Name)
+				}
+			}
+
+			for _, user := range m.sourceUsers(rule.Sources) {
+				if m.matchesDestination(dest.Selector, dst, UserNode(user)) {
+					inbound.Users = append(inbound.Users, user)
+				}
+			}
+
+			addressSources := slices.ContainsFunc(rule.Sources, m.isAddressSelector) &&
+				m.matchesDestination(dest.Selector, dst, Node{})
+			if len(inbound.Devices) > 0 || len(inbound.Users) > 0 || addressSources {
+				rules = append(rules, inbound)
+			}
+		}
+
+		warnings = appendRuleWarnings(warnings, rule, m.warnings)
+	}
+
+	return rules, warnings
+}
+
+// sourceUsers returns the users that source selectors name directly or through groups
+func (m *matcher) sourceUsers(sources []string) []string {
+	var users []string
+	for _, source := range sources {
+		var named []string
+		switch {
+		case strings.HasPrefix(source, "group:"):
+			named = m.policy.acl.Groups[source]
+		case strings.Contains(source, "@") && !strings.HasPrefix(source, "autogroup:"):
+			named = []string{source}
+		}
+
+		for _, user := range named {
+			if !slices.ContainsFunc(users, func(existing string) bool { return strings.EqualFold(existing, user) }) {
+				users = append(users, user)
+			}
+		}
+	}
+
+	slices.Sort(users)
+	return users
+}
+
+func appendRuleWarnings(warnings []string, rule Rule, ruleWarnings []string) []string {
+	for _, warning := range ruleWarnings {
+		warnings = append(warnings, fmt.Sprintf("%s: %s", rule.Path, warning))
+	}
+	return warnings
+}
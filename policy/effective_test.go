@@ -0,0 +1,104 @@
+package policy
+
+import (
+	"maps"
+	"slices"
+	"testing"
+
+	tailscale "tailscale.com/client/tailscale/v2"
+)
+
+func TestOutbound(t *testing.T) {
+	p := New(testACL(), testDevices())
+
+	rules, warnings := p.Outbound(deviceNode(t, "bob-laptop"))
+
+	reached := make(map[string][]string)
+	for _, rule := range rules {
+		for _, dest := range rule.Destinations {
+			reached[rule.Rule+" "+dest.Destination+" "+dest.Ports] = dest.Devices
+		}
+	}
+
+	expected := map[string][]string{
+		"acls[1] autogroup:self:* *": {},
+		"acls[3] ipset:databases:443 443": {
+			"alice-laptop.example.ts.net", "db-1.example.ts.net", "ci-runner.example.ts.net", "alice-desktop.example.ts.net",
+		},
+		"acls[4] office:* *":                {},
+		"grants[0] tag:db (tcp:6379) 6379":  {"db-1.example.ts.net", "db-2.example.ts.net"},
+		"grants[1] fd7a:115c:a1e0::1 (*) *": {"alice-laptop.example.ts.net"},
+		"grants[2] tag:prod (icmp:*) *":     {"db-1.example.ts.net"},
+	}
+	if !maps.EqualFunc(reached, expected, slices.Equal) {
+		t.Errorf("Expected %v, got %v", expected, reached)
+	}
+
+	if rules[0].Rule != "acls[1]" || rules[0].Source != "autogroup:member" {
+		t.Errorf("Expected rules in policy order with the matching source, got %+v", rules[0])
+	}
+	if !slices.Equal(warnings, []string{"acls[6]: autogroup:admin depends on user roles or sharing that are not evaluated locally"}) {
+		t.Errorf("Unexpected warnings %v", warnings)
+	}
+}
+
+func TestOutboundAutogroupSelf(t *testing.T) {
+	p := New(testACL(), testDevices())
+
+	rules, _ := p.Outbound(deviceNode(t, "alice-laptop"))
+	for _, rule := range rules {
+		if rule.Rule != "acls[1]" {
+			continue
+		}
+		if devices := rule.Destinations[0].Devices; !slices.Equal(devices, []string{"alice-desktop.example.ts.net"}) {
+			t.Errorf("Expected only alice's other device, got %v", devices)
+		}
+		return
+	}
+	t.Error("Expected autogroup:self rule to apply to alice")
+}
+
+func TestInbound(t *testing.T) {
+	p := New(testACL(), testDevices())
+
+	rules, _ := p.Inbound(deviceNode(t, "db-1"))
+
+	type access struct {
+		devices []string
+		users   []string
+	}
+	reaching := make(map[string]access)
+	for _, rule := range rules {
+		reaching[rule.Rule] = access{devices: rule.Devices, users: rule.Users}
+	}
+
+	expected := map[string]access{
+		"acls[0]":   {devices: []string{"alice-laptop.example.ts.net", "alice-desktop.example.ts.net"}, users: []string{"alice@example.com"}},
+		"acls[2]":   {devices: []string{"ci-runner.example.ts.net"}},
+		"acls[3]":   {devices: []string{"bob-laptop.example.ts.net"}, users: []string{"bob@example.com"}},
+		"acls[5]":   {devices: []string{"db-2.example.ts.net", "ci-runner.example.ts.net"}},
+		"grants[0]": {devices: []string{"bob-laptop.example.ts.net"}, users: []string{"bob@example.com"}},
+		"grants[2]": {devices: []string{
+			"alice-laptop.example.ts.net", "bob-laptop.example.ts.net", "db-2.example.ts.net",
+			"ci-runner.example.ts.net", "alice-desktop.example.ts.net",
+		}},
+	}
+	equal := func(a, b access) bool { return slices.Equal(a.devices, b.devices) && slices.Equal(a.users, b.users) }
+	if !maps.EqualFunc(reaching, expected, equal) {
+		t.Errorf("Expected %v, got %v", expected, reaching)
+	}
+}
+
+func TestInboundAddressSources(t *testing.T) {
+	acl := &tailscale.ACL{
+		ACLs: []tailscale.ACLEntry{
+			{Action: "accept", Source: []string{"192.168.1.0/24"}, Destination: []string{"tag:db:5432"}},
+			{Action: "accept", Source: []string{"192.168.1.0/24"}, Destination: []string{"tag:ci:*"}},
+		},
+	}
+
+	rules, _ := New(acl, testDevices()).Inbound(deviceNode(t, "db-2"))
+	if len(rules) != 1 || rules[0].Rule != "acls[0]" || len(rules[0].Devices) != 0 {
+		t.Errorf("Expected the subnet rule covering db-2 only, got %+v", rules)
+	}
+}
@@ -0,0 +1,407 @@
+package policy
+
+import (
+	"fmt"
+	"maps"
+	"net/netip"
+	"slices"
+	"strings"
+
+	tailscale "tailscale.com/client/tailscale/v2"
+)
+
+// Severity ranks how urgently a lint finding should be addressed
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+)
+
+// Finding is a problem the linter found in a policy file
+type Finding struct {
+	Severity Severity `json:"severity"`
+	// Check names the lint check that produced the finding, e.g. empty-group
+	Check string `json:"check"`
+	// Path locates the offending part of the policy, e.g. acls[3] or groups["group:eng"]
+	Path    string `json:"path"`
+	Message string `json:"message"`
+	Fix     string `json:"fix"`
+}
+
+// tailscaleRanges are the address ranges Tailscale assigns device addresses from
+var tailscaleRanges = []netip.Prefix{
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("fd7a:115c:a1e0::/48"),
+}
+
+// Lint checks a policy file against the tailnet's devices and users for empty groups, tags
+// no device carries, unused tagOwners entries, rules shadowed by earlier rules, *:*
+// wildcards, and hosts that point at addresses no device or subnet route has. Devices should
+// include their routes so hosts behind subnet routers are recognized.
+func Lint(acl *tailscale.ACL, devices []tailscale.Device, users []tailscale.User) []Finding {
+	p := New(acl, devices)
+
+	var findings []Finding
+	findings = append(findings, p.lintGroups(users)...)
+	findings = append(findings, p.lintTags()...)
+	findings = append(findings, p.lintWildcards()...)
+	findings = append(findings, p.lintShadowedRules()...)
+	findings = append(findings, p.lintHosts()...)
+	return findings
+}
+
+func (p *Policy) lintGroups(users []tailscale.User) []Finding {
+	logins := make(map[string]bool, len(users))
+	for _, user := range users {
+		logins[strings.ToLower(user.LoginName)] = true
+	}
+
+	var findings []Finding
+	for _, group := range slices.Sorted(maps.Keys(p.acl.Groups)) {
+		path := fmt.Sprintf("groups[%q]", group)
+		members := p.acl.Groups[group]
+		if len(members) == 0 {
+			findings = append(findings, Finding{
+				Severity: SeverityWarning,
+				Check:    "empty-group",
+				Path:     path,
+				Message:  fmt.Sprintf("%s has no members, so rules that use it allow nothing", group),
+				Fix:      fmt.Sprintf("Add members to %s, or remove it and the rules that reference it", group),
+			})
+			continue
+		}
+		if len(users) == 0 {
+			continue
+		}
+
+		var unknown []string
+		for _, member := range members {
+			if !logins[strings.ToLower(member)] {
+				unknown = append(unknown, member)
+			}
+		}
+		switch {
+		case len(unknown) == len(members):
+			findings = append(findings, Finding{
+				Severity: SeverityWarning,
+				Check:    "empty-group",
+				Path:     path,
+				Message:  fmt.Sprintf("none of the members of %s are users in the tailnet", group),
+				Fix:      fmt.Sprintf("Replace the members of %s with current users, or remove the group", group),
+			})
+		case len(unknown) > 0:
+			findings = append(findings, Finding{
+				Severity: SeverityInfo,
+				Check:    "unknown-group-member",
+				Path:     path,
+				Message:  fmt.Sprintf("%s lists users that are not in the tailnet: %s", group, strings.Join(unknown, ", ")),
+				Fix:      fmt.Sprintf("Remove %s from %s", strings.Join(unknown, ", "), group),
+			})
+		}
+	}
+	return findings
+}
+
+func (p *Policy) lintTags() []Finding {
+	references := p.tagReferences()
+
+	carried := make(map[string]bool)
+	for _, device := range p.devices {
+		for _, tag := range device.Tags {
+			carried[tag] = true
+		}
+	}
+
+	tags := slices.Collect(maps.Keys(references))
+	for tag := range p.acl.TagOwners {
+		if _, ok := references[tag]; !ok {
+			tags = append(tags, tag)
+		}
+	}
+	slices.Sort(tags)
+
+	var findings []Finding
+	for _, tag := range tags {
+		_, declared := p.acl.TagOwners[tag]
+		path := fmt.Sprintf("tagOwners[%q]", tag)
+		if !declared {
+			path = references[tag]
+		}
+
+		if !carried[tag] {
+			findings = append(findings, Finding{
+				Severity: SeverityInfo,
+				Check:    "unused-tag",
+				Path:     path,
+				Message:  fmt.Sprintf("no device carries %s", tag),
+				Fix:      fmt.Sprintf("Tag a device with %s, or remove it from the policy if it is no longer needed", tag),
+			})
+		}
+		if _, referenced := references[tag]; declared && !referenced {
+			findings = append(findings, Finding{
+				Severity: SeverityInfo,
+				Check:    "unused-tag-owner",
+				Path:     path,
+				Message:  fmt.Sprintf("%s is declared in tagOwners but no rule, SSH rule, node attribute, or auto approver uses it", tag),
+				Fix:      fmt.Sprintf("Remove the tagOwners entry for %s, or add the rules it was meant for", tag),
+			})
+		}
+	}
+	return findings
+}
+
+// tagReferences maps each tag the policy uses outside its own tagOwners entry to the path of
+// the first place it is used
+func (p *Policy) tagReferences() map[string]string {
+	references := make(map[string]string)
+	add := func(path string, selectors ...string) {
+		for _, selector := range selectors {
+			if strings.HasPrefix(selector, "tag:") {
+				if _, ok := references[selector]; !ok {
+					references[selector] = path
+				}
+			}
+		}
+	}
+
+	for i, entry := range p.acl.ACLs {
+		path := fmt.Sprintf("acls[%d]", i)
+		add(path, entry.Source...)
+		add(path, entry.Users...)
+		for _, raw := range entry.Destination {
+			if selector, _, err := parseACLDestination(raw); err == nil {
+				add(path, selector)
+			}
+		}
+	}
+	for i, grant := range p.acl.Grants {
+		path := fmt.Sprintf("grants[%d]", i)
+		add(path, grant.Source...)
+		add(path, grant.Destination...)
+	}
+	for i, rule := range p.acl.SSH {
+		path := fmt.Sprintf("ssh[%d]", i)
+		add(path, rule.Source...)
+		add(path, rule.Destination...)
+	}
+	for i, attr := range p.acl.NodeAttrs {
+		add(fmt.Sprintf("nodeAttrs[%d]", i), attr.Target...)
+	}
+	if approvers := p.acl.AutoApprovers; approvers != nil {
+		for _, route := range slices.Sorted(maps.Keys(approvers.Routes)) {
+			add(fmt.Sprintf("autoApprovers.routes[%q]", route), approvers.Routes[route]...)
+		}
+		add("autoApprovers.exitNode", approvers.ExitNode...)
+	}
+	for _, tag := range slices.Sorted(maps.Keys(p.acl.TagOwners)) {
+		for _, owner := range p.acl.TagOwners[tag] {
+			if owner != tag {
+				add(fmt.Sprintf("tagOwners[%q]", tag), owner)
+			}
+		}
+	}
+	return references
+}
+
+func (p *Policy) lintWildcards() []Finding {
+	var findings []Finding
+	for _, rule := range p.rules {
+		for _, dest := range rule.Destinations {
+			if dest.Selector != "*" || dest.Ports != nil || dest.Protocols != nil {
+				continue
+			}
+
+			finding := Finding{
+				Severity: SeverityWarning,
+				Check:    "wildcard-destination",
+				Path:     rule.Path,
+				Message:  fmt.Sprintf("%s allows %s to reach every port on every device", rule.Path, strings.Join(rule.Sources, ", ")),
+				Fix:      fmt.Sprintf("Replace %s with the tags, groups, or hosts and the ports this rule needs", dest.Raw),
+			}
+			if slices.Contains(rule.Sources, "*") {
+				finding.Severity = SeverityError
+				finding.Message = fmt.Sprintf("%s allows all traffic between every source and every device, which disables access control", rule.Path)
+			}
+			findings = append(findings, finding)
+			break
+		}
+	}
+	return findings
+}
+
+func (p *Policy) lintShadowedRules() []Finding {
+	var findings []Finding
+	for j, rule := range p.rules {
+		if len(rule.Sources) == 0 || len(rule.Destinations) == 0 {
+			continue
+		}
+		for _, earlier := range p.rules[:j] {
+			if !p.ruleCovers(earlier, rule) {
+				continue
+			}
+			findings = append(findings, Finding{
+				Severity: SeverityWarning,
+				Check:    "shadowed-rule",
+				Path:     rule.Path,
+				Message:  fmt.Sprintf("%s allows nothing that %s does not already allow", rule.Path, earlier.Path),
+				Fix:      fmt.Sprintf("Remove %s, or narrow %s if it is broader than intended", rule.Path, earlier.Path),
+			})
+			break
+		}
+	}
+	return findings
+}
+
+// ruleCovers reports whether broad allows every source, destination, port, and protocol that
+// narrow allows. It compares selectors as written, so it only finds shadowing that holds for
+// any device inventory.
+func (p *Policy) ruleCovers(broad, narrow Rule) bool {
+	for _, source := range narrow.Sources {
+		if !slices.ContainsFunc(broad.Sources, func(b string) bool { return p.selectorCovers(b, source) }) {
+			return false
+		}
+	}
+	for _, dest := range narrow.Destinations {
+		covered := slices.ContainsFunc(broad.Destinations, func(b Destination) bool {
+			return p.selectorCovers(b.Selector, dest.Selector) &&
+				portsCover(b.Ports, dest.Ports) && protocolsCover(b.Protocols, dest.Protocols)
+		})
+		if !covered {
+			return false
+		}
+	}
+	return true
+}
+
+// selectorCovers reports whether every node the narrow selector matches is also matched by broad
+func (p *Policy) selectorCovers(broad, narrow string) bool {
+	if broad == "*" || strings.EqualFold(broad, narrow) {
+		return true
+	}
+
+	switch {
+	case broad == "autogroup:member":
+		return strings.HasPrefix(narrow, "group:") || (strings.Contains(narrow, "@") && !strings.HasPrefix(narrow, "autogroup:"))
+	case broad == "autogroup:tagged":
+		return strings.HasPrefix(narrow, "tag:")
+	case strings.HasPrefix(broad, "group:"):
+		members := p.acl.Groups[broad]
+		inGroup := func(user string) bool {
+			return slices.ContainsFunc(members, func(member string) bool { return strings.EqualFold(member, user) })
+		}
+		if strings.HasPrefix(narrow, "group:") {
+			narrowMembers := p.acl.Groups[narrow]
+			return len(narrowMembers) > 0 && !slices.ContainsFunc(narrowMembers, func(user string) bool { return !inGroup(user) })
+		}
+		return strings.Contains(narrow, "@") && inGroup(narrow)
+	}
+
+	broadPrefix, ok := p.literalPrefix(broad)
+	if !ok {
+		return false
+	}
+	narrowPrefix, ok := p.literalPrefix(narrow)
+	return ok && broadPrefix.Bits() <= narrowPrefix.Bits() && broadPrefix.Contains(narrowPrefix.Addr())
+}
+
+// literalPrefix resolves a host alias, IP address, or CIDR to a prefix
+func (p *Policy) literalPrefix(selector string) (netip.Prefix, bool) {
+	if host, ok := p.acl.Hosts[selector]; ok {
+		selector = host
+	}
+	prefix, err := parsePrefix(selector)
+	return prefix, err == nil
+}
+
+func portsCover(broad, narrow []PortRange) bool {
+	if broad == nil {
+		return true
+	}
+	if narrow == nil {
+		return false
+	}
+	for _, n := range narrow {
+		if !slices.ContainsFunc(broad, func(b PortRange) bool { return b.First <= n.First && n.Last <= b.Last }) {
+			return false
+		}
+	}
+	return true
+}
+
+func protocolsCover(broad, narrow []string) bool {
+	if len(broad) == 0 {
+		return true
+	}
+	if len(narrow) == 0 {
+		return false
+	}
+	for _, proto := range narrow {
+		if !slices.Contains(broad, proto) {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *Policy) lintHosts() []Finding {
+	var addresses []netip.Addr
+	var routes []netip.Prefix
+	for _, device := range p.devices {
+		node := NodeFromDevice(device)
+		addresses = append(addresses, node.Addresses...)
+		for _, route := range slices.Concat(device.AdvertisedRoutes, device.EnabledRoutes) {
+			if prefix, err := netip.ParsePrefix(route); err == nil {
+				routes = append(routes, prefix.Masked())
+			}
+		}
+	}
+
+	var findings []Finding
+	for _, name := range slices.Sorted(maps.Keys(p.acl.Hosts)) {
+		address := p.acl.Hosts[name]
+		path := fmt.Sprintf("hosts[%q]", name)
+
+		prefix, err := parsePrefix(address)
+		if err != nil {
+			findings = append(findings, Finding{
+				Severity: SeverityError,
+				Check:    "invalid-host",
+				Path:     path,
+				Message:  fmt.Sprintf("host %s has %q, which is not an IP address or CIDR", name, address),
+				Fix:      fmt.Sprintf("Set %s to the IP address or CIDR it should name", name),
+			})
+			continue
+		}
+
+		if isTailscaleAddress(prefix.Addr()) {
+			if !slices.ContainsFunc(addresses, prefix.Contains) {
+				findings = append(findings, Finding{
+					Severity: SeverityWarning,
+					Check:    "unknown-host-address",
+					Path:     path,
+					Message:  fmt.Sprintf("host %s points at %s, but no device has that Tailscale address", name, address),
+					Fix:      fmt.Sprintf("Update %s to the current address of the device, or remove it and the rules that use it", name),
+				})
+			}
+			continue
+		}
+
+		if !slices.ContainsFunc(routes, prefix.Overlaps) {
+			findings = append(findings, Finding{
+				Severity: SeverityWarning,
+				Check:    "unknown-host-address",
+				Path:     path,
+				Message:  fmt.Sprintf("host %s points at %s, which no device address or subnet route covers", name, address),
+				Fix:      fmt.Sprintf("Advertise and enable a subnet route covering %s, or remove %s and the rules that use it", address, name),
+			})
+		}
+	}
+	return findings
+}
+
+func isTailscaleAddress(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return slices.ContainsFunc(tailscaleRanges, func(r netip.Prefix) bool { return r.Contains(addr) })
+}
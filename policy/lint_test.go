@@ -0,0 +1,113 @@
+package policy
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	tailscale "tailscale.com/client/tailscale/v2"
+)
+
+func testUsers() []tailscale.User {
+	return []tailscale.User{
+		{ID: "u1", LoginName: "alice@example.com"},
+		{ID: "u2", LoginName: "bob@example.com"},
+	}
+}
+
+func TestLint(t *testing.T) {
+	devices := testDevices()
+	devices[1].AdvertisedRoutes = []string{"192.168.1.0/24"}
+
+	acl := &tailscale.ACL{
+		Groups: map[string][]string{
+			"group:eng":     {"alice@example.com", "carol@example.com"},
+			"group:empty":   {},
+			"group:former":  {"dave@example.com"},
+			"group:sre":     {"ALICE@example.com", "bob@example.com"},
+			"group:oncall":  {"bob@example.com"},
+			"group:unknown": nil,
+		},
+		TagOwners: map[string][]string{
+			"tag:db":     {"group:eng"},
+			"tag:ci":     {"tag:db"},
+			"tag:legacy": {"group:eng"},
+		},
+		Hosts: map[string]string{
+			"db-primary": "100.64.0.3",
+			"gone":       "100.64.0.99",
+			"office":     "192.168.1.10",
+			"lab":        "10.0.0.0/8",
+			"typo":       "100.64.0",
+		},
+		ACLs: []tailscale.ACLEntry{
+			{Action: "accept", Source: []string{"group:sre"}, Destination: []string{"tag:db:*"}},
+			{Action: "accept", Source: []string{"group:oncall"}, Destination: []string{"tag:db:5432"}},
+			{Action: "accept", Source: []string{"alice@example.com"}, Destination: []string{"tag:web:443"}},
+			{Action: "accept", Source: []string{"tag:ci"}, Destination: []string{"*:*"}},
+			{Action: "accept", Source: []string{"100.64.0.0/24"}, Destination: []string{"office:22"}},
+			{Action: "accept", Source: []string{"100.64.0.5"}, Destination: []string{"192.168.1.10:22"}, Protocol: "tcp"},
+			{Action: "accept", Source: []string{"*"}, Destination: []string{"*:*"}},
+		},
+	}
+
+	findings := Lint(acl, devices, testUsers())
+
+	var got []string
+	for _, finding := range findings {
+		got = append(got, string(finding.Severity)+" "+finding.Check+" "+finding.Path)
+		if finding.Message == "" || finding.Fix == "" {
+			t.Errorf("Expected a message and fix, got %+v", finding)
+		}
+	}
+
+	expected := []string{
+		`warning empty-group groups["group:empty"]`,
+		`info unknown-group-member groups["group:eng"]`,
+		`warning empty-group groups["group:former"]`,
+		`warning empty-group groups["group:unknown"]`,
+		`info unused-tag-owner tagOwners["tag:legacy"]`,
+		`info unused-tag tagOwners["tag:legacy"]`,
+		`info unused-tag acls[2]`,
+		`warning wildcard-destination acls[3]`,
+		`error wildcard-destination acls[6]`,
+		`warning shadowed-rule acls[1]`,
+		`warning shadowed-rule acls[5]`,
+		`warning unknown-host-address hosts["gone"]`,
+		`warning unknown-host-address hosts["lab"]`,
+		`error invalid-host hosts["typo"]`,
+	}
+	slices.Sort(got)
+	slices.Sort(expected)
+	if !slices.Equal(got, expected) {
+		t.Errorf("Expected findings\n%s\ngot\n%s", strings.Join(expected, "\n"), strings.Join(got, "\n"))
+	}
+}
+
+func TestLintShadowedBy(t *testing.T) {
+	acl := &tailscale.ACL{
+		Groups: map[string][]string{"group:eng": {"alice@example.com"}},
+		ACLs: []tailscale.ACLEntry{
+			{Action: "accept", Source: []string{"autogroup:member"}, Destination: []string{"tag:db:5432"}},
+			{Action: "accept", Source: []string{"group:eng"}, Destination: []string{"tag:db:5432"}, Protocol: "tcp"},
+			{Action: "accept", Source: []string{"group:eng"}, Destination: []string{"tag:db:5432,22"}},
+		},
+		Grants: []tailscale.Grant{
+			{Source: []string{"alice@example.com"}, Destination: []string{"tag:db"}, IP: []string{"tcp:5432"}},
+		},
+	}
+
+	var shadowed []string
+	for _, finding := range Lint(acl, testDevices(), testUsers()) {
+		if finding.Check == "shadowed-rule" {
+			shadowed = append(shadowed, finding.Path)
+			if !strings.Contains(finding.Message, "acls[0]") {
+				t.Errorf("Expected %s to be shadowed by acls[0], got %q", finding.Path, finding.Message)
+			}
+		}
+	}
+
+	if !slices.Equal(shadowed, []string{"acls[1]", "grants[0]"}) {
+		t.Errorf("Expected acls[1] and grants[0] to be shadowed, got %v", shadowed)
+	}
+}
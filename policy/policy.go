@@ -0,0 +1,234 @@
+// Package policy evaluates a tailnet policy file locally against a device inventory. It
+// answers questions such as "can this laptop reach db-2 on port 5432?" without calling
+// the Tailscale API, by expanding users, groups, tags, autogroups, hosts, and IP sets in
+// the policy's acls and grants.
+package policy
+
+import (
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+
+	tailscale "tailscale.com/client/tailscale/v2"
+)
+
+// Node is one end of a connection: a device, or a user or tag that is not tied to a device
+type Node struct {
+	Name string `json:"name,omitempty"`
+	// User is the owner of an untagged device, or the user principal itself. Tagged devices
+	// take their identity from their tags, so User is empty for them.
+	User      string       `json:"user,omitempty"`
+	Tags      []string     `json:"tags,omitempty"`
+	Addresses []netip.Addr `json:"addresses,omitempty"`
+}
+
+// NodeFromDevice returns the node a device acts as in the policy
+func NodeFromDevice(device tailscale.Device) Node {
+	node := Node{Name: device.Name, Tags: device.Tags}
+	if len(device.Tags) == 0 {
+		node.User = device.User
+	}
+	for _, address := range device.Addresses {
+		if addr, err := netip.ParseAddr(address); err == nil {
+			node.Addresses = append(node.Addresses, addr)
+		}
+	}
+	return node
+}
+
+// UserNode returns the node for a user principal, independent of any device
+func UserNode(login string) Node {
+	return Node{Name: login, User: login}
+}
+
+// TagNode returns the node for a tag principal, independent of any device
+func TagNode(tag string) Node {
+	return Node{Name: tag, Tags: []string{tag}}
+}
+
+// AddressNode returns the node for an address that does not belong to a known device, such
+// as a host behind a subnet router
+func AddressNode(addr netip.Addr) Node {
+	return Node{Name: addr.String(), Addresses: []netip.Addr{addr}}
+}
+
+// Rule is an ACL entry or grant reduced to the parts that decide network access
+type Rule struct {
+	// Path locates the rule in the policy file, e.g. acls[2] or grants[0]
+	Path         string        `json:"path"`
+	Sources      []string      `json:"sources"`
+	Destinations []Destination `json:"destinations"`
+	// Entry is the tailscale.ACLEntry or tailscale.Grant the rule came from
+	Entry any `json:"entry"`
+}
+
+// Destination is a destination selector together with the ports and protocols it allows
+type Destination struct {
+	// Raw is the destination as written in the policy
+	Raw      string `json:"raw"`
+	Selector string `json:"selector"`
+	// Ports is empty when the destination allows every port
+	Ports []PortRange `json:"ports,omitempty"`
+	// Protocols is empty when the destination allows every protocol
+	Protocols []string `json:"protocols,omitempty"`
+}
+
+// Policy is a parsed policy file and device inventory that access questions are answered against
+type Policy struct {
+	acl     *tailscale.ACL
+	devices []tailscale.Device
+	rules   []Rule
+	// problems lists parts of the policy that could not be parsed and were skipped
+	problems []string
+}
+
+// New prepares a policy file and the tailnet's devices for evaluation
+func New(acl *tailscale.ACL, devices []tailscale.Device) *Policy {
+	p := &Policy{acl: acl, devices: devices}
+
+	for i, entry := range acl.ACLs {
+		path := fmt.Sprintf("acls[%d]", i)
+		if entry.Action != "" && entry.Action != "accept" {
+			p.problems = append(p.problems, fmt.Sprintf("%s: unsupported action %q", path, entry.Action))
+			continue
+		}
+
+		sources := entry.Source
+		if len(sources) == 0 {
+			// Legacy policies list sources under users
+			sources = entry.Users
+		}
+
+		var protocols []string
+		if entry.Protocol != "" {
+			protocols = []string{normalizeProtocol(entry.Protocol)}
+		}
+
+		rule := Rule{Path: path, Sources: sources, Entry: entry}
+		for _, raw := range entry.Destination {
+			selector, ports, err := parseACLDestination(raw)
+			if err != nil {
+				p.problems = append(p.problems, fmt.Sprintf("%s: %v", path, err))
+				continue
+			}
+			rule.Destinations = append(rule.Destinations, Destination{
+				Raw:       raw,
+				Selector:  selector,
+				Ports:     ports,
+				Protocols: protocols,
+			})
+		}
+		p.rules = append(p.rules, rule)
+	}
+
+	for i, grant := range acl.Grants {
+		path := fmt.Sprintf("grants[%d]", i)
+		if len(grant.IP) == 0 {
+			// Grants with only application capabilities do not allow network traffic
+			continue
+		}
+
+		rule := Rule{Path: path, Sources: grant.Source, Entry: grant}
+		for _, ip := range grant.IP {
+			protocols, ports, err := parseGrantIP(ip)
+			if err != nil {
+				p.problems = append(p.problems, fmt.Sprintf("%s: %v", path, err))
+				continue
+			}
+			for _, selector := range grant.Destination {
+				rule.Destinations = append(rule.Destinations, Destination{
+					Raw:       fmt.Sprintf("%s (%s)", selector, ip),
+					Selector:  selector,
+					Ports:     ports,
+					Protocols: protocols,
+				})
+			}
+		}
+		p.rules = append(p.rules, rule)
+	}
+
+	return p
+}
+
+// Rules returns the policy's network rules in policy order, acls first and then grants
+func (p *Policy) Rules() []Rule {
+	return p.rules
+}
+
+// Problems returns the parts of the policy that could not be parsed and were skipped
+func (p *Policy) Problems() []string {
+	return p.problems
+}
+
+// Devices returns the device inventory the policy is evaluated against
+func (p *Policy) Devices() []tailscale.Device {
+	return p.devices
+}
+
+// parseACLDestination splits an ACL destination such as tag:db:5432, 100.64.0.1:22, or
+// [fd7a:115c:a1e0::1]:443 into its selector and port ranges
+func parseACLDestination(raw string) (string, []PortRange, error) {
+	var selector, ports string
+	if strings.HasPrefix(raw, "[") {
+		end := strings.Index(raw, "]:")
+		if end < 0 {
+			return "", nil, fmt.Errorf("invalid destination %q: expected [address]:ports", raw)
+		}
+		selector, ports = raw[1:end], raw[end+2:]
+	} else {
+		i := strings.LastIndex(raw, ":")
+		if i <= 0 {
+			return "", nil, fmt.Errorf("invalid destination %q: expected selector:ports", raw)
+		}
+		selector, ports = raw[:i], raw[i+1:]
+	}
+
+	ranges, err := ParsePorts(ports)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid destination %q: %w", raw, err)
+	}
+	return selector, ranges, nil
+}
+
+// parseGrantIP parses a grant ip entry such as *, 443, tcp:443, or udp:1000-2000
+func parseGrantIP(ip string) ([]string, []PortRange, error) {
+	if ip == "*" {
+		return nil, nil, nil
+	}
+
+	var protocols []string
+	ports := ip
+	if proto, rest, ok := strings.Cut(ip, ":"); ok {
+		protocols = []string{normalizeProtocol(proto)}
+		ports = rest
+	}
+
+	ranges, err := ParsePorts(ports)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid ip %q: %w", ip, err)
+	}
+	return protocols, ranges, nil
+}
+
+// protocolNames maps IANA protocol numbers used in policies to their names
+var protocolNames = map[string]string{
+	"1":   "icmp",
+	"6":   "tcp",
+	"17":  "udp",
+	"58":  "ipv6-icmp",
+	"132": "sctp",
+}
+
+// normalizeProtocol returns the lower-case protocol name for a name or IANA number
+func normalizeProtocol(proto string) string {
+	proto = strings.ToLower(strings.TrimSpace(proto))
+	if name, ok := protocolNames[proto]; ok {
+		return name
+	}
+	return proto
+}
+
+func allowsProtocol(protocols []string, proto string) bool {
+	return len(protocols) == 0 || slices.Contains(protocols, proto)
+}
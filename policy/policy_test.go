@@ -0,0 +1,387 @@
+package policy
+
+import (
+	"net/netip"
+	"slices"
+	"testing"
+
+	tailscale "tailscale.com/client/tailscale/v2"
+)
+
+func testDevices() []tailscale.Device {
+	return []tailscale.Device{
+		{
+			ID:        "1",
+			Name:      "alice-laptop.example.ts.net",
+			User:      "alice@example.com",
+			Addresses: []string{"100.64.0.1", "fd7a:115c:a1e0::1"},
+		},
+		{
+			ID:        "2",
+			Name:      "bob-laptop.example.ts.net",
+			User:      "bob@example.com",
+			Addresses: []string{"100.64.0.2"},
+		},
+		{
+			ID:        "3",
+			Name:      "db-1.example.ts.net",
+			User:      "alice@example.com",
+			Tags:      []string{"tag:db", "tag:prod"},
+			Addresses: []string{"100.64.0.3"},
+		},
+		{
+			ID:        "4",
+			Name:      "db-2.example.ts.net",
+			User:      "alice@example.com",
+			Tags:      []string{"tag:db"},
+			Addresses: []string{"100.64.0.4", "fd7a:115c:a1e0::4"},
+		},
+		{
+			ID:        "5",
+			Name:      "ci-runner.example.ts.net",
+			User:      "bob@example.com",
+			Tags:      []string{"tag:ci"},
+			Addresses: []string{"100.64.0.5"},
+		},
+		{
+			ID:        "6",
+			Name:      "alice-desktop.example.ts.net",
+			User:      "alice@example.com",
+			Addresses: []string{"100.64.0.6"},
+		},
+	}
+}
+
+func testACL() *tailscale.ACL {
+	return &tailscale.ACL{
+		Groups: map[string][]string{
+			"group:eng":   {"alice@example.com"},
+			"group:empty": {},
+		},
+		Hosts: map[string]string{
+			"db-primary": "100.64.0.3",
+			"office":     "192.168.1.0/24",
+		},
+		IPSets: map[string][]string{
+			"ipset:databases": {"100.64.0.0/29", "remove 100.64.0.4"},
+			"ipset:all-dbs":   {"ipset:databases", "add 100.64.0.4"},
+		},
+		ACLs: []tailscale.ACLEntry{
+			{Action: "accept", Source: []string{"group:eng"}, Destination: []string{"tag:db:5432"}},
+			{Action: "accept", Source: []string{"autogroup:member"}, Destination: []string{"autogroup:self:*"}},
+			{Action: "accept", Source: []string{"tag:ci"}, Destination: []string{"db-primary:22,80-90"}},
+			{Action: "accept", Source: []string{"bob@example.com"}, Destination: []string{"ipset:databases:443"}},
+			{Action: "accept", Source: []string{"100.64.0.2"}, Destination: []string{"office:*"}},
+			{Action: "accept", Source: []string{"autogroup:tagged"}, Destination: []string{"tag:db:53"}, Protocol: "udp"},
+			{Action: "accept", Source: []string{"autogroup:admin"}, Destination: []string{"*:8080"}},
+		},
+		Grants: []tailscale.Grant{
+			{Source: []string{"bob@example.com"}, Destination: []string{"tag:db"}, IP: []string{"tcp:6379"}},
+			{Source: []string{"ipset:all-dbs"}, Destination: []string{"fd7a:115c:a1e0::1"}, IP: []string{"*"}},
+			{Source: []string{"*"}, Destination: []string{"tag:prod"}, IP: []string{"icmp:*"}},
+			{Source: []string{"*"}, Destination: []string{"*"}, App: map[string][]map[string]any{"example.com/cap/web": {{}}}},
+		},
+	}
+}
+
+func deviceNode(t *testing.T, name string) Node {
+	t.Helper()
+	for _, device := range testDevices() {
+		if device.Name == name+".example.ts.net" {
+			return NodeFromDevice(device)
+		}
+	}
+	t.Fatalf("Unknown test device %s", name)
+	return Node{}
+}
+
+func TestCheck(t *testing.T) {
+	p := New(testACL(), testDevices())
+
+	testCases := []struct {
+		name    string
+		src     Node
+		dst     Node
+		proto   string
+		port    int
+		rules   []string
+		warning bool
+	}{
+		{
+			name:  "GroupToTag",
+			src:   deviceNode(t, "alice-laptop"),
+			dst:   deviceNode(t, "db-2"),
+			port:  5432,
+			rules: []string{"acls[0]"},
+		},
+		{
+			name: "GroupToTagWrongPort",
+			src:  deviceNode(t, "alice-laptop"),
+			dst:  deviceNode(t, "db-2"),
+			port: 5433,
+		},
+		{
+			name: "UserNotInGroup",
+			src:  deviceNode(t, "bob-laptop"),
+			dst:  deviceNode(t, "db-2"),
+			port: 5432,
+		},
+		{
+			name:  "UserPrincipalInGroup",
+			src:   UserNode("ALICE@example.com"),
+			dst:   deviceNode(t, "db-1"),
+			port:  5432,
+			rules: []string{"acls[0]"},
+		},
+		{
+			name:  "AutogroupSelf",
+			src:   deviceNode(t, "alice-laptop"),
+			dst:   deviceNode(t, "alice-desktop"),
+			port:  3389,
+			rules: []string{"acls[1]"},
+		},
+		{
+			name: "AutogroupSelfOtherUser",
+			src:  deviceNode(t, "bob-laptop"),
+			dst:  deviceNode(t, "alice-desktop"),
+			port: 3389,
+		},
+		{
+			name: "TaggedDeviceIsNotItsOwner",
+			src:  deviceNode(t, "alice-laptop"),
+			dst:  deviceNode(t, "db-1"),
+			port: 3389,
+		},
+		{
+			name:  "HostAliasPortRange",
+			src:   deviceNode(t, "ci-runner"),
+			dst:   deviceNode(t, "db-1"),
+			port:  85,
+			rules: []string{"acls[2]"},
+		},
+		{
+			name: "HostAliasOtherDevice",
+			src:  deviceNode(t, "ci-runner"),
+			dst:  deviceNode(t, "db-2"),
+			port: 22,
+		},
+		{
+			name:  "IPSetIncludesPrefix",
+			src:   deviceNode(t, "bob-laptop"),
+			dst:   deviceNode(t, "db-1"),
+			port:  443,
+			rules: []string{"acls[3]"},
+		},
+		{
+			name: "IPSetRemovesAddress",
+			src:  deviceNode(t, "bob-laptop"),
+			dst:  deviceNode(t, "db-2"),
+			port: 443,
+		},
+		{
+			name:  "SourceIPToSubnetHost",
+			src:   deviceNode(t, "bob-laptop"),
+			dst:   AddressNode(netip.MustParseAddr("192.168.1.20")),
+			port:  9100,
+			rules: []string{"acls[4]"},
+		},
+		{
+			name:  "ProtocolRestrictedRuleUDP",
+			src:   deviceNode(t, "ci-runner"),
+			dst:   deviceNode(t, "db-2"),
+			proto: "udp",
+			port:  53,
+			rules: []string{"acls[5]"},
+		},
+		{
+			name:  "ProtocolRestrictedRuleByNumber",
+			src:   deviceNode(t, "ci-runner"),
+			dst:   deviceNode(t, "db-2"),
+			proto: "17",
+			port:  53,
+			rules: []string{"acls[5]"},
+		},
+		{
+			name: "ProtocolRestrictedRuleTCP",
+			src:  deviceNode(t, "ci-runner"),
+			dst:  deviceNode(t, "db-2"),
+			port: 53,
+		},
+		{
+			name:    "RoleAutogroupIsWarned",
+			src:     deviceNode(t, "alice-laptop"),
+			dst:     deviceNode(t, "bob-laptop"),
+			port:    8080,
+			warning: true,
+		},
+		{
+			name: "RoleAutogroupOnOtherPortIsNotWarned",
+			src:  deviceNode(t, "alice-laptop"),
+			dst:  deviceNode(t, "bob-laptop"),
+			port: 8081,
+		},
+		{
+			name:  "GrantWithProtocolAndPort",
+			src:   deviceNode(t, "bob-laptop"),
+			dst:   deviceNode(t, "db-2"),
+			port:  6379,
+			rules: []string{"grants[0]"},
+		},
+		{
+			name:  "GrantNestedIPSetToIPv6",
+			src:   deviceNode(t, "db-2"),
+			dst:   deviceNode(t, "alice-laptop"),
+			proto: "udp",
+			port:  9999,
+			rules: []string{"grants[1]"},
+		},
+		{
+			name:  "GrantICMPIgnoresPort",
+			src:   deviceNode(t, "bob-laptop"),
+			dst:   deviceNode(t, "db-1"),
+			proto: "icmp",
+			rules: []string{"acls[3]", "grants[2]"},
+			// autogroup:admin might also allow ICMP to every device
+			warning: true,
+		},
+		{
+			name:    "MultipleMatchingRules",
+			src:     deviceNode(t, "alice-laptop"),
+			dst:     deviceNode(t, "db-1"),
+			proto:   "icmp",
+			rules:   []string{"acls[0]", "grants[2]"},
+			warning: true,
+		},
+		{
+			name:  "TagPrincipal",
+			src:   TagNode("tag:ci"),
+			dst:   deviceNode(t, "db-1"),
+			port:  22,
+			rules: []string{"acls[2]"},
+		},
+		{
+			name: "AppOnlyGrantAllowsNoTraffic",
+			src:  deviceNode(t, "bob-laptop"),
+			dst:  deviceNode(t, "alice-desktop"),
+			port: 80,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			decision := p.Check(tc.src, tc.dst, tc.proto, tc.port)
+
+			var rules []string
+			for _, match := range decision.Matches {
+				rules = append(rules, match.Rule)
+			}
+			if !slices.Equal(rules, tc.rules) {
+				t.Errorf("Expected matching rules %v, got %v", tc.rules, rules)
+			}
+			if decision.Allowed != (len(tc.rules) > 0) {
+				t.Errorf("Expected allowed=%v, got %v", len(tc.rules) > 0, decision.Allowed)
+			}
+			if hasWarnings := len(decision.Warnings) > 0; hasWarnings != tc.warning {
+				t.Errorf("Expected warnings=%v, got %v", tc.warning, decision.Warnings)
+			}
+		})
+	}
+}
+
+func TestCheckMatchDetails(t *testing.T) {
+	p := New(testACL(), testDevices())
+
+	decision := p.Check(deviceNode(t, "ci-runner"), deviceNode(t, "db-1"), "tcp", 22)
+	if len(decision.Matches) != 1 {
+		t.Fatalf("Expected one match, got %+v", decision.Matches)
+	}
+
+	match := decision.Matches[0]
+	if match.Source != "tag:ci" || match.Destination != "db-primary:22,80-90" {
+		t.Errorf("Unexpected match %+v", match)
+	}
+	if entry, ok := match.Entry.(tailscale.ACLEntry); !ok || entry.Destination[0] != "db-primary:22,80-90" {
+		t.Errorf("Expected the matching ACL entry, got %#v", match.Entry)
+	}
+}
+
+func TestNewRules(t *testing.T) {
+	acl := testACL()
+	acl.ACLs = append(acl.ACLs,
+		tailscale.ACLEntry{Action: "accept", Source: []string{"*"}, Destination: []string{"tag:db"}},
+		tailscale.ACLEntry{Action: "accept", Source: []string{"*"}, Destination: []string{"[fd7a:115c:a1e0::4]:22"}},
+		tailscale.ACLEntry{Action: "deny", Source: []string{"*"}, Destination: []string{"*:*"}},
+		tailscale.ACLEntry{Users: []string{"bob@example.com"}, Destination: []string{"tag:ci:*"}},
+	)
+	acl.Grants = append(acl.Grants, tailscale.Grant{Source: []string{"*"}, Destination: []string{"tag:db"}, IP: []string{"tcp:http"}})
+
+	p := New(acl, testDevices())
+
+	if len(p.Problems()) != 3 {
+		t.Errorf("Expected 3 problems, got %v", p.Problems())
+	}
+
+	rules := p.Rules()
+	byPath := make(map[string]Rule, len(rules))
+	for _, rule := range rules {
+		byPath[rule.Path] = rule
+	}
+
+	ipv6 := byPath["acls[8]"]
+	if len(ipv6.Destinations) != 1 || ipv6.Destinations[0].Selector != "fd7a:115c:a1e0::4" {
+		t.Errorf("Expected a bracketed IPv6 destination, got %+v", ipv6.Destinations)
+	}
+	if legacy := byPath["acls[10]"]; !slices.Equal(legacy.Sources, []string{"bob@example.com"}) {
+		t.Errorf("Expected legacy users to be used as sources, got %+v", legacy)
+	}
+	if _, ok := byPath["grants[3]"]; ok {
+		t.Error("Expected app-only grants to be skipped")
+	}
+
+	decision := p.Check(deviceNode(t, "bob-laptop"), deviceNode(t, "ci-runner"), "tcp", 1234)
+	if !decision.Allowed || decision.Matches[0].Rule != "acls[10]" {
+		t.Errorf("Expected legacy rule to allow bob, got %+v", decision)
+	}
+
+	decision = p.Check(deviceNode(t, "ci-runner"), deviceNode(t, "db-2"), "tcp", 22)
+	if !decision.Allowed || decision.Matches[0].Rule != "acls[8]" {
+		t.Errorf("Expected IPv6 rule to allow ci-runner, got %+v", decision)
+	}
+}
+
+func TestUnknownSelectors(t *testing.T) {
+	acl := &tailscale.ACL{
+		ACLs: []tailscale.ACLEntry{
+			{Action: "accept", Source: []string{"group:missing", "laptop"}, Destination: []string{"ipset:missing:*"}},
+			{Action: "accept", Source: []string{"autogroup:self"}, Destination: []string{"*:*"}},
+		},
+	}
+
+	decision := New(acl, testDevices()).Check(deviceNode(t, "alice-laptop"), deviceNode(t, "bob-laptop"), "tcp", 22)
+	if decision.Allowed {
+		t.Error("Expected unknown selectors not to match")
+	}
+	expected := []string{
+		`acls[0]: group:missing is not defined in groups`,
+		`acls[0]: unknown selector "laptop"`,
+		`acls[0]: ipset:missing is not defined in ipsets`,
+		`acls[1]: autogroup:self is only meaningful as a destination`,
+	}
+	if !slices.Equal(decision.Warnings, expected) {
+		t.Errorf("Expected warnings %v, got %v", expected, decision.Warnings)
+	}
+}
+
+func TestNodeFromDevice(t *testing.T) {
+	devices := testDevices()
+
+	laptop := NodeFromDevice(devices[0])
+	if laptop.User != "alice@example.com" || len(laptop.Addresses) != 2 {
+		t.Errorf("Unexpected node %+v", laptop)
+	}
+
+	db := NodeFromDevice(devices[2])
+	if db.User != "" || !slices.Equal(db.Tags, []string{"tag:db", "tag:prod"}) {
+		t.Errorf("Expected tagged device to act as its tags, got %+v", db)
+	}
+}
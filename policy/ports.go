@@ -0,0 +1,88 @@
+package policy
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PortRange is an inclusive range of ports
+type PortRange struct {
+	First int `json:"first"`
+	Last  int `json:"last"`
+}
+
+func (r PortRange) String() string {
+	if r.First == r.Last {
+		return strconv.Itoa(r.First)
+	}
+	return fmt.Sprintf("%d-%d", r.First, r.Last)
+}
+
+// ParsePorts parses a policy port list such as *, 22, 80,443, or 8000-8999. It returns nil
+// for *, which allows every port.
+func ParsePorts(ports string) ([]PortRange, error) {
+	if ports == "*" {
+		return nil, nil
+	}
+	if ports == "" {
+		return nil, fmt.Errorf("missing ports")
+	}
+
+	var ranges []PortRange
+	for _, part := range strings.Split(ports, ",") {
+		first, last, isRange := strings.Cut(part, "-")
+		if !isRange {
+			last = first
+		}
+
+		start, err := parsePort(first)
+		if err != nil {
+			return nil, err
+		}
+		end, err := parsePort(last)
+		if err != nil {
+			return nil, err
+		}
+		if start > end {
+			return nil, fmt.Errorf("invalid port range %q", part)
+		}
+
+		ranges = append(ranges, PortRange{First: start, Last: end})
+	}
+
+	return ranges, nil
+}
+
+func parsePort(s string) (int, error) {
+	port, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || port < 0 || port > 65535 {
+		return 0, fmt.Errorf("invalid port %q", s)
+	}
+	return port, nil
+}
+
+// allowsPort reports whether port falls in any of the ranges; nil ranges allow every port
+func allowsPort(ranges []PortRange, port int) bool {
+	if ranges == nil {
+		return true
+	}
+	for _, r := range ranges {
+		if port >= r.First && port <= r.Last {
+			return true
+		}
+	}
+	return false
+}
+
+// FormatPorts renders port ranges the way a policy would write them
+func FormatPorts(ranges []PortRange) string {
+	if ranges == nil {
+		return "*"
+	}
+	parts := make([]string, 0, len(ranges))
+	for _, r := range ranges {
+		parts = append(parts, r.String())
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,59 @@
+package policy
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePorts(t *testing.T) {
+	testCases := []struct {
+		ports    string
+		expected []PortRange
+		wantErr  bool
+	}{
+		{ports: "*", expected: nil},
+		{ports: "22", expected: []PortRange{{22, 22}}},
+		{ports: "80,443", expected: []PortRange{{80, 80}, {443, 443}}},
+		{ports: "8000-8999", expected: []PortRange{{8000, 8999}}},
+		{ports: "22,6000-6010", expected: []PortRange{{22, 22}, {6000, 6010}}},
+		{ports: "", wantErr: true},
+		{ports: "ssh", wantErr: true},
+		{ports: "70000", wantErr: true},
+		{ports: "90-80", wantErr: true},
+		{ports: "80,", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.ports, func(t *testing.T) {
+			ranges, err := ParsePorts(tc.ports)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("Expected error, got %v", ranges)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(ranges, tc.expected) {
+				t.Errorf("Expected %v, got %v", tc.expected, ranges)
+			}
+			if got := FormatPorts(ranges); got != tc.ports {
+				t.Errorf("Expected %s to format back unchanged, got %s", tc.ports, got)
+			}
+		})
+	}
+}
+
+func TestAllowsPort(t *testing.T) {
+	ranges := []PortRange{{22, 22}, {8000, 8999}}
+
+	for port, expected := range map[int]bool{22: true, 23: false, 8000: true, 8500: true, 8999: true, 9000: false} {
+		if got := allowsPort(ranges, port); got != expected {
+			t.Errorf("allowsPort(%d) = %v, expected %v", port, got, expected)
+		}
+	}
+	if !allowsPort(nil, 12345) {
+		t.Error("Expected nil ranges to allow every port")
+	}
+}
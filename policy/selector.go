@@ -0,0 +1,174 @@
+package policy
+
+import (
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+)
+
+// maxIPSetDepth bounds how deeply IP sets may reference other IP sets
+const maxIPSetDepth = 8
+
+// matcher decides whether policy selectors cover a node. Selectors that cannot be decided
+// locally, such as role-based autogroups, never match and are reported as warnings.
+type matcher struct {
+	policy   *Policy
+	warnings []string
+}
+
+func (m *matcher) warn(format string, args ...any) {
+	warning := fmt.Sprintf(format, args...)
+	if !slices.Contains(m.warnings, warning) {
+		m.warnings = append(m.warnings, warning)
+	}
+}
+
+// matchesSource reports whether a src selector covers node
+func (m *matcher) matchesSource(selector string, node Node) bool {
+	if selector == "autogroup:self" {
+		m.warn("autogroup:self is only meaningful as a destination")
+		return false
+	}
+	return m.matches(selector, node, nil)
+}
+
+// matchesDestination reports whether a dst selector covers node for traffic from src
+func (m *matcher) matchesDestination(selector string, node, src Node) bool {
+	return m.matches(selector, node, &src)
+}
+
+func (m *matcher) matches(selector string, node Node, src *Node) bool {
+	switch {
+	case selector == "*":
+		return true
+
+	case strings.HasPrefix(selector, "autogroup:"):
+		return m.matchesAutogroup(selector, node, src)
+
+	case strings.HasPrefix(selector, "tag:"):
+		return slices.Contains(node.Tags, selector)
+
+	case strings.HasPrefix(selector, "group:"):
+		members, ok := m.policy.acl.Groups[selector]
+		if !ok {
+			m.warn("%s is not defined in groups", selector)
+			return false
+		}
+		return isUser(node) && slices.ContainsFunc(members, func(member string) bool {
+			return strings.EqualFold(member, node.User)
+		})
+
+	case strings.Contains(selector, "@"):
+		return isUser(node) && strings.EqualFold(selector, node.User)
+	}
+
+	if !m.isAddressSelector(selector) {
+		m.warn("unknown selector %q", selector)
+		return false
+	}
+	for _, addr := range node.Addresses {
+		if m.containsAddr(selector, addr, 0) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *matcher) matchesAutogroup(selector string, node Node, src *Node) bool {
+	switch selector {
+	case "autogroup:member":
+		return isUser(node)
+	case "autogroup:tagged":
+		return len(node.Tags) > 0
+	case "autogroup:self":
+		return src != nil && isUser(node) && isUser(*src) && strings.EqualFold(node.User, src.User)
+	case "autogroup:internet":
+		// Internet access through exit nodes never targets a device in the tailnet
+		return false
+	default:
+		m.warn("%s depends on user roles or sharing that are not evaluated locally", selector)
+		return false
+	}
+}
+
+// isAddressSelector reports whether selector is an IP set, host alias, IP address, or CIDR
+func (m *matcher) isAddressSelector(selector string) bool {
+	if strings.HasPrefix(selector, "ipset:") {
+		return true
+	}
+	if _, ok := m.policy.acl.Hosts[selector]; ok {
+		return true
+	}
+	_, err := parsePrefix(selector)
+	return err == nil
+}
+
+// containsAddr reports whether an address selector covers addr. IP set entries are applied
+// in order, so a later "remove" entry excludes addresses an earlier entry added and a later
+// "add" entry puts them back.
+func (m *matcher) containsAddr(selector string, addr netip.Addr, depth int) bool {
+	addr = addr.Unmap()
+
+	if strings.HasPrefix(selector, "ipset:") {
+		entries, defined := m.policy.acl.IPSets[selector]
+		if !defined {
+			m.warn("%s is not defined in ipsets", selector)
+			return false
+		}
+		if depth >= maxIPSetDepth {
+			m.warn("%s nests IP sets too deeply", selector)
+			return false
+		}
+
+		member := false
+		for _, entry := range entries {
+			remove := false
+			if rest, found := strings.CutPrefix(entry, "remove "); found {
+				entry, remove = rest, true
+			} else if rest, found := strings.CutPrefix(entry, "add "); found {
+				entry = rest
+			}
+			entry = strings.TrimSpace(entry)
+
+			if !m.isAddressSelector(entry) {
+				m.warn("%s contains unknown entry %q", selector, entry)
+				continue
+			}
+			if m.containsAddr(entry, addr, depth+1) {
+				member = !remove
+			}
+		}
+		return member
+	}
+
+	if host, isHost := m.policy.acl.Hosts[selector]; isHost {
+		prefix, err := parsePrefix(host)
+		if err != nil {
+			m.warn("host %s has invalid address %q", selector, host)
+			return false
+		}
+		return prefix.Contains(addr)
+	}
+
+	prefix, err := parsePrefix(selector)
+	return err == nil && prefix.Contains(addr)
+}
+
+// parsePrefix parses an IP address or CIDR as a prefix
+func parsePrefix(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		prefix, err := netip.ParsePrefix(s)
+		return prefix.Masked(), err
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// isUser reports whether a node acts as a user, i.e. it is an untagged device or a user principal
+func isUser(node Node) bool {
+	return node.User != "" && len(node.Tags) == 0
+}
@@ -0,0 +1,265 @@
+// Package redact masks secrets and sensitive fields in tool output before it is returned to
+// an MCP client. Fields are selected by JSON path, and values that look like Tailscale key
+// material are masked wherever they appear, so a secret is caught even in a field or error
+// message nobody thought to list.
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Mask replaces redacted values
+const Mask = "[REDACTED]"
+
+// DefaultPaths are the fields redacted unless a deployment allows them
+var DefaultPaths = []string{
+	"key",
+	"nodeKey",
+	"machineKey",
+	"tailnetLockKey",
+	"secret",
+	"clientSecret",
+}
+
+// secretPattern matches Tailscale key secrets (tskey-auth-..., tskey-api-..., tskey-client-...)
+// and node, machine, disco, and tailnet lock keys
+var secretPattern = regexp.MustCompile(`tskey-[A-Za-z0-9_-]+|\b(?:nodekey|mkey|discokey|nlpub|privkey):[0-9A-Za-z]+`)
+
+// Path selects a JSON field by the names of the objects leading to it, separated by dots.
+// Array elements are transparent, and a path matches the end of a field's location, so
+// "nodeKey" matches the field anywhere and "devices.nodeKey" only within devices. A "*"
+// segment matches any single field name.
+type Path []string
+
+// ParsePath parses a dotted path such as "devices.*.nodeKey"
+func ParsePath(path string) (Path, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return nil, fmt.Errorf("path cannot be empty")
+	}
+	segments := strings.Split(path, ".")
+	for _, segment := range segments {
+		if segment == "" || strings.ContainsAny(segment, " \t\n\r") {
+			return nil, fmt.Errorf("invalid path %q", path)
+		}
+	}
+	return segments, nil
+}
+
+// ParsePaths parses a comma-separated list of paths, ignoring empty entries
+func ParsePaths(list string) ([]Path, error) {
+	var paths []Path
+	for _, entry := range strings.Split(list, ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		path, err := ParsePath(entry)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+func (p Path) String() string {
+	return strings.Join(p, ".")
+}
+
+// matches reports whether the path selects a field at location
+func (p Path) matches(location []string) bool {
+	if len(p) > len(location) {
+		return false
+	}
+	tail := location[len(location)-len(p):]
+	for i, segment := range p {
+		if segment != "*" && segment != tail[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Redactor masks sensitive fields and secret-looking values. Fields matching an allowed
+// path are left untouched, along with everything beneath them.
+type Redactor struct {
+	paths   []Path
+	allowed []Path
+}
+
+// New creates a Redactor masking DefaultPaths and the given paths, except where allowed
+func New(paths, allowed []Path) *Redactor {
+	r := &Redactor{allowed: allowed}
+	for _, path := range DefaultPaths {
+		r.paths = append(r.paths, Path(strings.Split(path, ".")))
+	}
+	r.paths = append(r.paths, paths...)
+	return r
+}
+
+// Default masks DefaultPaths and secret-looking values with nothing allowed
+var Default = New(nil, nil)
+
+// Allow returns a copy of the Redactor that also leaves the given paths untouched
+func (r *Redactor) Allow(paths ...Path) *Redactor {
+	return &Redactor{paths: r.paths, allowed: append(slices.Clip(r.allowed), paths...)}
+}
+
+// Text masks secret-looking values in free-form text such as error messages
+func (r *Redactor) Text(text string) string {
+	return secretPattern.ReplaceAllString(text, Mask)
+}
+
+// JSON masks sensitive fields and secret-looking values in a JSON document, keeping its field
+// order and two-space indentation. Documents that need no redaction are returned unchanged,
+// and text that is not JSON is redacted with Text.
+func (r *Redactor) JSON(data string) string {
+	decoder := json.NewDecoder(strings.NewReader(data))
+	decoder.UseNumber()
+	value, err := decodeOrdered(decoder)
+	if err != nil || decoder.More() {
+		return r.Text(data)
+	}
+
+	value, changed := r.walk(value, nil)
+	if !changed {
+		return data
+	}
+
+	output, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		// Redacted values are plain strings, so this is unreachable; fail closed regardless
+		return Mask
+	}
+	return string(output)
+}
+
+// walk redacts value found at location, reporting whether anything was masked
+func (r *Redactor) walk(value any, location []string) (any, bool) {
+	if len(location) > 0 {
+		if slices.ContainsFunc(r.allowed, func(p Path) bool { return p.matches(location) }) {
+			return value, false
+		}
+		if slices.ContainsFunc(r.paths, func(p Path) bool { return p.matches(location) }) && !isEmpty(value) {
+			return Mask, true
+		}
+	}
+
+	switch v := value.(type) {
+	case string:
+		if masked := r.Text(v); masked != v {
+			return masked, true
+		}
+	case []any:
+		changed := false
+		for i, element := range v {
+			var elementChanged bool
+			v[i], elementChanged = r.walk(element, location)
+			changed = changed || elementChanged
+		}
+		return v, changed
+	case object:
+		changed := false
+		for i, field := range v {
+			var fieldChanged bool
+			v[i].value, fieldChanged = r.walk(field.value, append(slices.Clip(location), field.key))
+			changed = changed || fieldChanged
+		}
+		return v, changed
+	}
+	return value, false
+}
+
+// isEmpty reports whether a value carries nothing worth masking, such as the empty key
+// field of a listed key
+func isEmpty(value any) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	}
+	return false
+}
+
+// object is a JSON object that keeps the order of its fields
+type object []field
+
+type field struct {
+	key   string
+	value any
+}
+
+func (o object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, field := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(field.key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(field.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// decodeOrdered decodes the next JSON value, representing objects as object
+func decodeOrdered(decoder *json.Decoder) (any, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch token {
+	case json.Delim('{'):
+		o := object{}
+		for decoder.More() {
+			keyToken, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			key, ok := keyToken.(string)
+			if !ok {
+				return nil, fmt.Errorf("object key is not a string")
+			}
+			value, err := decodeOrdered(decoder)
+			if err != nil {
+				return nil, err
+			}
+			o = append(o, field{key: key, value: value})
+		}
+		if _, err := decoder.Token(); err != nil {
+			return nil, err
+		}
+		return o, nil
+	case json.Delim('['):
+		a := []any{}
+		for decoder.More() {
+			value, err := decodeOrdered(decoder)
+			if err != nil {
+				return nil, err
+			}
+			a = append(a, value)
+		}
+		if _, err := decoder.Token(); err != nil {
+			return nil, err
+		}
+		return a, nil
+	}
+	return token, nil
+}
@@ -2,6 +2,9 @@ package server
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
 	"os"
@@ -15,21 +18,110 @@ import (
 	"github.com/R167/tailscale-mcp/tools"
 )
 
-func Start() {
-	cfg, err := config.Load()
-	if err != nil {
-		slog.Error("Failed to load configuration", "error", err)
-		os.Exit(1)
+// Transports the server can be reached over
+const (
+	// TransportHTTP serves streamable HTTP on the configured port
+	TransportHTTP = "http"
+	// TransportStdio serves a single client over stdin and stdout, for clients that launch the binary
+	TransportStdio = "stdio"
+)
+
+// ValidateTransport checks that transport is one Start can serve
+func ValidateTransport(transport string) error {
+	if transport != TransportHTTP && transport != TransportStdio {
+		return fmt.Errorf("transport must be %q or %q, got %q", TransportStdio, TransportHTTP, transport)
 	}
+	return nil
+}
+
+// NewServer creates the MCP server with every tool, resource, and prompt registered against the configured client
+func NewServer(cfg *config.Config) *mcp.Server {
+	if cfg.Redactor != nil {
+		tools.SetRedactor(cfg.Redactor)
+	}
+
+	// Device tools, resources, and completions share one device cache, so renames and deletes
+	// are seen by all of them
+	resolver := tools.NewDeviceResolver(cfg.Client)
+
+	// Resource subscriptions are served by polling the API for changes
+	poller := tools.NewResourcePoller(cfg.Client, cfg.PollInterval)
+	completer := tools.NewCompleter(cfg.Client, resolver)
 
 	impl := &mcp.Implementation{}
-	server := mcp.NewServer(impl, &mcp.ServerOptions{})
+	server := mcp.NewServer(impl, &mcp.ServerOptions{
+		SubscribeHandler:   poller.Subscribe,
+		UnsubscribeHandler: poller.Unsubscribe,
+		CompletionHandler:  completer.Complete,
+	})
+	poller.Attach(server)
 
 	// Register all tools
-	tools.RegisterDeviceTools(server, cfg.Client)
+	tools.RegisterDeviceTools(server, cfg.Client, resolver)
 	tools.RegisterACLTools(server, cfg.Client)
 	tools.RegisterKeyTools(server, cfg.Client)
+	tools.RegisterAccessTools(server, cfg.Client)
+	tools.RegisterDNSTools(server, cfg.Client)
+	tools.RegisterUserTools(server, cfg.Client)
+	tools.RegisterWebhookTools(server, cfg.Client)
+
+	// Register resources
+	tools.RegisterResources(server, cfg.Client, resolver)
+
+	// Register prompts
+	tools.RegisterPrompts(server)
+
+	return server
+}
 
+// Start serves the MCP server over transport, which must have passed ValidateTransport, until
+// it is interrupted
+func Start(transport string) {
+	if transport == TransportStdio {
+		// stdout carries the protocol stream, so logs must never be written there
+		slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, nil)))
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		slog.Error("Failed to load configuration", "error", err)
+		os.Exit(1)
+	}
+
+	server := NewServer(cfg)
+
+	// Stop on interrupt signal
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if transport == TransportStdio {
+		err = serveStdio(ctx, server, &mcp.StdioTransport{})
+	} else {
+		err = serveHTTP(ctx, server, cfg.Port)
+	}
+	if err != nil {
+		slog.Error("Server failed", "transport", transport, "error", err)
+		os.Exit(1)
+	}
+
+	slog.Info("Server stopped")
+}
+
+// serveStdio serves a single client over transport, which is stdin and stdout outside of tests,
+// until the client disconnects or ctx is cancelled
+func serveStdio(ctx context.Context, server *mcp.Server, transport mcp.Transport) error {
+	slog.Info("Starting MCP server", "transport", TransportStdio)
+
+	err := server.Run(ctx, transport)
+	if errors.Is(err, context.Canceled) || errors.Is(err, io.EOF) {
+		// The client closed stdin or the server was interrupted; both are a normal shutdown
+		return nil
+	}
+	return err
+}
+
+// serveHTTP serves streamable HTTP on port until ctx is cancelled, then shuts down gracefully
+func serveHTTP(ctx context.Context, server *mcp.Server, port string) error {
 	// Create HTTP handler
 	mcpHandler := mcp.NewStreamableHTTPHandler(
 		func(req *http.Request) *mcp.Server {
@@ -43,23 +135,24 @@ func Start() {
 
 	// Setup HTTP server
 	httpServer := &http.Server{
-		Addr:    ":" + cfg.Port,
+		Addr:    ":" + port,
 		Handler: handler,
 	}
 
 	// Start server in goroutine
+	serveErr := make(chan error, 1)
 	go func() {
-		slog.Info("Starting MCP server", "port", cfg.Port)
+		slog.Info("Starting MCP server", "transport", TransportHTTP, "port", port)
 		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			slog.Error("HTTP server failed", "error", err)
-			os.Exit(1)
+			serveErr <- fmt.Errorf("HTTP server failed: %w", err)
 		}
 	}()
 
-	// Wait for interrupt signal
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
-	<-sigChan
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
 
 	slog.Info("Shutting down server...")
 
@@ -71,5 +164,5 @@ func Start() {
 		slog.Error("Server shutdown error", "error", err)
 	}
 
-	slog.Info("Server stopped")
+	return nil
 }
@@ -0,0 +1,87 @@
+package server
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/R167/tailscale-mcp/config"
+	"github.com/R167/tailscale-mcp/internal"
+	"github.com/R167/tailscale-mcp/redact"
+)
+
+func TestValidateTransport(t *testing.T) {
+	for _, transport := range []string{TransportHTTP, TransportStdio} {
+		if err := ValidateTransport(transport); err != nil {
+			t.Errorf("Expected %q to be valid, got %v", transport, err)
+		}
+	}
+	if err := ValidateTransport("sse"); err == nil {
+		t.Error("Expected an unknown transport to be rejected")
+	}
+}
+
+func TestServeStdio(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config.Config{Client: &internal.MockTailscaleClient{}, Redactor: redact.Default}
+	server := NewServer(cfg)
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- serveStdio(context.Background(), server, serverTransport)
+	}()
+
+	ctx := context.Background()
+	client := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "1.0.0"}, nil)
+	session, err := client.Connect(ctx, clientTransport, nil)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+
+	capabilities := session.InitializeResult().Capabilities
+	if capabilities.Tools == nil || capabilities.Prompts == nil || capabilities.Completions == nil {
+		t.Errorf("Expected the server to advertise tools, prompts, and completions: %+v", capabilities)
+	}
+	if capabilities.Resources == nil || !capabilities.Resources.Subscribe {
+		t.Errorf("Expected the server to advertise resource subscriptions: %+v", capabilities.Resources)
+	}
+
+	listed, err := session.ListTools(ctx, nil)
+	if err != nil {
+		t.Fatalf("Failed to list tools: %v", err)
+	}
+	var names []string
+	for _, tool := range listed.Tools {
+		names = append(names, tool.Name)
+	}
+	for _, name := range []string{"list_devices", "list_keys", "list_webhooks"} {
+		if !slices.Contains(names, name) {
+			t.Errorf("Expected %s to be listed, got %v", name, names)
+		}
+	}
+
+	called, err := session.CallTool(ctx, &mcp.CallToolParams{Name: "list_keys", Arguments: map[string]any{}})
+	if err != nil || called.IsError {
+		t.Fatalf("Expected the tool call to succeed: %v %+v", err, called)
+	}
+	if text := called.Content[0].(*mcp.TextContent).Text; !strings.Contains(text, "Test API Key 1") {
+		t.Errorf("Expected the keys in the tool result: %s", text)
+	}
+
+	// Closing the client's end of the stream ends the session cleanly
+	session.Close()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Expected a clean shutdown when the client disconnects, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the server to stop when the client disconnects")
+	}
+}
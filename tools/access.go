@@ -0,0 +1,364 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"strings"
+
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	tailscale "tailscale.com/client/tailscale/v2"
+
+	"github.com/R167/tailscale-mcp/internal"
+	"github.com/R167/tailscale-mcp/policy"
+)
+
+// accessProtocols are the protocols check_access accepts
+var accessProtocols = []any{"tcp", "udp", "icmp", "sctp"}
+
+func RegisterAccessTools(server *mcp.Server, client internal.TailscaleClient) {
+	// Check access tool
+	mcp.AddTool(
+		server,
+		&mcp.Tool{
+			Name: "check_access",
+			Description: "Check whether a source can reach a destination on a port according to the current policy file. " +
+				"The policy is evaluated locally against the device list, expanding users, groups, tags, autogroups, hosts, " +
+				"and IP sets, and the result names the ACL entries or grants that allow the connection.",
+			InputSchema: &jsonschema.Schema{
+				Type: "object",
+				Properties: map[string]*jsonschema.Schema{
+					"source": {
+						Type: "string",
+						Description: "Where the connection comes from: " + deviceRefDescription +
+							"; a user login (e.g. alice@example.com); or a tag (e.g. tag:ci)",
+					},
+					"destination": {
+						Type: "string",
+						Description: "Where the connection goes: " + deviceRefDescription +
+							"; or an IP address behind a subnet router",
+					},
+					"port": {
+						Type:        "integer",
+						Description: "Destination port; not needed for icmp",
+						Minimum:     jsonschema.Ptr(0.0),
+						Maximum:     jsonschema.Ptr(65535.0),
+					},
+					"proto": {
+						Type:        "string",
+						Description: "Protocol (default: tcp)",
+						Enum:        accessProtocols,
+					},
+				},
+				Required:             []string{"source", "destination"},
+				AdditionalProperties: &jsonschema.Schema{Not: &jsonschema.Schema{}},
+			},
+		},
+		func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
+			sourceRef, err := getStringParam(args, "source")
+			if err != nil {
+				return toolError("Invalid source parameter", err), nil, nil
+			}
+
+			destinationRef, err := getStringParam(args, "destination")
+			if err != nil {
+				return toolError("Invalid destination parameter", err), nil, nil
+			}
+
+			proto, err := getOptionalStringParam(args, "proto")
+			if err != nil {
+				return toolError("Invalid proto parameter", err), nil, nil
+			}
+			if proto == "" {
+				proto = "tcp"
+			}
+
+			port, err := getOptionalIntParam(args, "port", -1)
+			if err != nil {
+				return toolError("Invalid port parameter", err), nil, nil
+			}
+			if proto != "icmp" && (port < 0 || port > 65535) {
+				return toolError("Invalid port parameter", fmt.Errorf("a port between 0 and 65535 is required for %s", proto)), nil, nil
+			}
+			if proto == "icmp" {
+				port = 0
+			}
+
+			acl, err := client.PolicyFile().Get(ctx)
+			if err != nil {
+				return toolError("Failed to get ACL policy", err), nil, nil
+			}
+
+			devices, err := client.Devices().List(ctx)
+			if err != nil {
+				return toolError("Failed to list devices", err), nil, nil
+			}
+
+			source, err := resolvePolicyNode(devices, sourceRef)
+			if err != nil {
+				return toolError("Failed to resolve source", err), nil, nil
+			}
+
+			destination, err := resolvePolicyNode(devices, destinationRef)
+			if err != nil {
+				return toolError("Failed to resolve destination", err), nil, nil
+			}
+
+			evaluated := policy.New(acl, devices)
+			decision := evaluated.Check(source, destination, proto, port)
+
+			result := struct {
+				Allowed        bool           `json:"allowed"`
+				Summary        string         `json:"summary"`
+				Source         policy.Node    `json:"source"`
+				Destination    policy.Node    `json:"destination"`
+				Protocol       string         `json:"protocol"`
+				Port           int            `json:"port,omitempty"`
+				Matches        []policy.Match `json:"matches,omitempty"`
+				Warnings       []string       `json:"warnings,omitempty"`
+				PolicyProblems []string       `json:"policyProblems,omitempty"`
+			}{
+				Allowed:        decision.Allowed,
+				Summary:        summarizeDecision(source, destination, proto, port, decision),
+				Source:         source,
+				Destination:    destination,
+				Protocol:       proto,
+				Port:           port,
+				Matches:        decision.Matches,
+				Warnings:       decision.Warnings,
+				PolicyProblems: evaluated.Problems(),
+			}
+
+			output, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				return toolError("Failed to serialize access check", err), nil, nil
+			}
+
+			return toolSuccess(string(output)), nil, nil
+		},
+	)
+
+	// Effective access from a node tool
+	mcp.AddTool(
+		server,
+		&mcp.Tool{
+			Name: "effective_access",
+			Description: "List every device and port a user, tag, or device can reach according to the current policy file, " +
+				"grouped by the ACL entry or grant that allows it, with summary counts.",
+			InputSchema: &jsonschema.Schema{
+				Type: "object",
+				Properties: map[string]*jsonschema.Schema{
+					"source": {
+						Type: "string",
+						Description: "Whose access to report: " + deviceRefDescription +
+							"; a user login (e.g. alice@example.com); or a tag (e.g. tag:ci)",
+					},
+				},
+				Required:             []string{"source"},
+				AdditionalProperties: &jsonschema.Schema{Not: &jsonschema.Schema{}},
+			},
+		},
+		func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
+			sourceRef, err := getStringParam(args, "source")
+			if err != nil {
+				return toolError("Invalid source parameter", err), nil, nil
+			}
+
+			acl, err := client.PolicyFile().Get(ctx)
+			if err != nil {
+				return toolError("Failed to get ACL policy", err), nil, nil
+			}
+
+			devices, err := client.Devices().List(ctx)
+			if err != nil {
+				return toolError("Failed to list devices", err), nil, nil
+			}
+
+			source, err := resolvePolicyNode(devices, sourceRef)
+			if err != nil {
+				return toolError("Failed to resolve source", err), nil, nil
+			}
+
+			evaluated := policy.New(acl, devices)
+			rules, warnings := evaluated.Outbound(source)
+
+			type summary struct {
+				Rules        int `json:"rules"`
+				Destinations int `json:"destinations"`
+				Devices      int `json:"devices"`
+			}
+			result := struct {
+				Source         policy.Node           `json:"source"`
+				Summary        summary               `json:"summary"`
+				Rules          []policy.OutboundRule `json:"rules"`
+				Warnings       []string              `json:"warnings,omitempty"`
+				PolicyProblems []string              `json:"policyProblems,omitempty"`
+			}{
+				Source:         source,
+				Rules:          rules,
+				Warnings:       warnings,
+				PolicyProblems: evaluated.Problems(),
+			}
+			if result.Rules == nil {
+				result.Rules = []policy.OutboundRule{}
+			}
+
+			reachable := make(map[string]bool)
+			for _, rule := range rules {
+				result.Summary.Destinations += len(rule.Destinations)
+				for _, dest := range rule.Destinations {
+					for _, device := range dest.Devices {
+						reachable[device] = true
+					}
+				}
+			}
+			result.Summary.Rules = len(rules)
+			result.Summary.Devices = len(reachable)
+
+			output, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				return toolError("Failed to serialize effective access", err), nil, nil
+			}
+
+			return toolSuccess(string(output)), nil, nil
+		},
+	)
+
+	// Effective access to a node tool
+	mcp.AddTool(
+		server,
+		&mcp.Tool{
+			Name: "effective_access_to",
+			Description: "List who can reach a device according to the current policy file: the users and devices allowed " +
+				"in, and on which ports, grouped by the ACL entry or grant that allows it, with summary counts.",
+			InputSchema: &jsonschema.Schema{
+				Type: "object",
+				Properties: map[string]*jsonschema.Schema{
+					"destination": {
+						Type: "string",
+						Description: "What to report access to: " + deviceRefDescription +
+							"; a tag (e.g. tag:db); or an IP address behind a subnet router",
+					},
+				},
+				Required:             []string{"destination"},
+				AdditionalProperties: &jsonschema.Schema{Not: &jsonschema.Schema{}},
+			},
+		},
+		func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
+			destinationRef, err := getStringParam(args, "destination")
+			if err != nil {
+				return toolError("Invalid destination parameter", err), nil, nil
+			}
+
+			acl, err := client.PolicyFile().Get(ctx)
+			if err != nil {
+				return toolError("Failed to get ACL policy", err), nil, nil
+			}
+
+			devices, err := client.Devices().List(ctx)
+			if err != nil {
+				return toolError("Failed to list devices", err), nil, nil
+			}
+
+			destination, err := resolvePolicyNode(devices, destinationRef)
+			if err != nil {
+				return toolError("Failed to resolve destination", err), nil, nil
+			}
+
+			evaluated := policy.New(acl, devices)
+			rules, warnings := evaluated.Inbound(destination)
+
+			type summary struct {
+				Rules   int `json:"rules"`
+				Devices int `json:"devices"`
+				Users   int `json:"users"`
+			}
+			result := struct {
+				Destination    policy.Node          `json:"destination"`
+				Summary        summary              `json:"summary"`
+				Rules          []policy.InboundRule `json:"rules"`
+				Warnings       []string             `json:"warnings,omitempty"`
+				PolicyProblems []string             `json:"policyProblems,omitempty"`
+			}{
+				Destination:    destination,
+				Rules:          rules,
+				Warnings:       warnings,
+				PolicyProblems: evaluated.Problems(),
+			}
+			if result.Rules == nil {
+				result.Rules = []policy.InboundRule{}
+			}
+
+			sourceDevices := make(map[string]bool)
+			users := make(map[string]bool)
+			for _, rule := range rules {
+				for _, device := range rule.Devices {
+					sourceDevices[device] = true
+				}
+				for _, user := range rule.Users {
+					users[strings.ToLower(user)] = true
+				}
+			}
+			result.Summary.Rules = len(rules)
+			result.Summary.Devices = len(sourceDevices)
+			result.Summary.Users = len(users)
+
+			output, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				return toolError("Failed to serialize effective access", err), nil, nil
+			}
+
+			return toolSuccess(string(output)), nil, nil
+		},
+	)
+}
+
+// resolvePolicyNode turns a tag, user login, device reference, or IP address into the node it
+// acts as in the policy
+func resolvePolicyNode(devices []tailscale.Device, ref string) (policy.Node, error) {
+	ref = strings.TrimSpace(ref)
+
+	switch {
+	case strings.HasPrefix(ref, "tag:"):
+		return policy.TagNode(ref), nil
+	case strings.Contains(ref, "@"):
+		return policy.UserNode(ref), nil
+	}
+
+	device, err := findDevice(devices, ref)
+	if err == nil {
+		return policy.NodeFromDevice(*device), nil
+	}
+
+	if addr, parseErr := netip.ParseAddr(ref); parseErr == nil {
+		return policy.AddressNode(addr), nil
+	}
+
+	return policy.Node{}, err
+}
+
+// summarizeDecision describes an access decision in one sentence
+func summarizeDecision(source, destination policy.Node, proto string, port int, decision policy.Decision) string {
+	target := proto
+	if proto != "icmp" {
+		target = fmt.Sprintf("%s/%d", proto, port)
+	}
+
+	if decision.Allowed {
+		rules := make([]string, 0, len(decision.Matches))
+		for _, match := range decision.Matches {
+			rules = append(rules, match.Rule)
+		}
+		return fmt.Sprintf("%s can reach %s on %s, allowed by %s",
+			source.Name, destination.Name, target, strings.Join(rules, ", "))
+	}
+
+	summary := fmt.Sprintf("%s cannot reach %s on %s: no rule allows it and Tailscale denies traffic by default",
+		source.Name, destination.Name, target)
+	if len(decision.Warnings) > 0 {
+		summary += "; some rules could not be fully evaluated, see warnings"
+	}
+	return summary
+}
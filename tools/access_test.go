@@ -0,0 +1,224 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	tailscale "tailscale.com/client/tailscale/v2"
+
+	"github.com/R167/tailscale-mcp/internal"
+	"github.com/R167/tailscale-mcp/policy"
+)
+
+// accessTestServer returns a server with the access tools registered against testDevices and acl,
+// counting the API calls made
+func accessTestServer(acl *tailscale.ACL, calls *int) *mcp.Server {
+	client := &internal.MockTailscaleClient{
+		DevicesFunc: func() internal.DevicesResource {
+			return &internal.MockDevicesResource{
+				ListFunc: func(ctx context.Context) ([]tailscale.Device, error) {
+					*calls++
+					return testDevices(), nil
+				},
+			}
+		},
+		PolicyFileFunc: func() internal.PolicyFileResource {
+			return &internal.MockPolicyFileResource{
+				GetFunc: func(ctx context.Context) (*tailscale.ACL, error) {
+					*calls++
+					return acl, nil
+				},
+			}
+		},
+	}
+
+	server := mcp.NewServer(&mcp.Implementation{}, &mcp.ServerOptions{})
+	RegisterAccessTools(server, client)
+	return server
+}
+
+func accessTestACL() *tailscale.ACL {
+	return &tailscale.ACL{
+		Groups: map[string][]string{"group:dba": {"alice@example.com"}},
+		ACLs: []tailscale.ACLEntry{
+			{Action: "accept", Source: []string{"group:dba"}, Destination: []string{"tag:db:5432"}, Protocol: "tcp"},
+			{Action: "accept", Source: []string{"tag:prod"}, Destination: []string{"tag:db:5432,9100"}},
+		},
+	}
+}
+
+type accessCheckOutput struct {
+	Allowed bool           `json:"allowed"`
+	Summary string         `json:"summary"`
+	Matches []policy.Match `json:"matches"`
+}
+
+func TestCheckAccess(t *testing.T) {
+	testCases := []struct {
+		name    string
+		args    map[string]any
+		allowed bool
+		rule    string
+	}{
+		{
+			name:    "DeviceByHostnameToDeviceByIP",
+			args:    map[string]any{"source": "alice-laptop", "destination": "100.101.2.4", "port": 5432},
+			allowed: true,
+			rule:    "acls[0]",
+		},
+		{
+			name:    "TaggedDevice",
+			args:    map[string]any{"source": "db-1.example.ts.net", "destination": "db-2", "port": 9100},
+			allowed: true,
+			rule:    "acls[1]",
+		},
+		{
+			name: "UserNotInGroup",
+			args: map[string]any{"source": "bob@example.com", "destination": "db-2", "port": 5432},
+		},
+		{
+			name: "WrongProtocol",
+			args: map[string]any{"source": "alice@example.com", "destination": "db-2", "port": 5432, "proto": "udp"},
+		},
+		{
+			name:    "TagPrincipal",
+			args:    map[string]any{"source": "tag:prod", "destination": "db-2", "port": 5432},
+			allowed: true,
+			rule:    "acls[1]",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls int
+			server := accessTestServer(accessTestACL(), &calls)
+
+			result, text := callTool(t, server, "check_access", tc.args)
+			if result.IsError {
+				t.Fatalf("Expected success, got error: %s", text)
+			}
+
+			var output accessCheckOutput
+			if err := json.Unmarshal([]byte(text), &output); err != nil {
+				t.Fatalf("Failed to unmarshal output: %v", err)
+			}
+			if output.Allowed != tc.allowed {
+				t.Errorf("Expected allowed=%v: %s", tc.allowed, text)
+			}
+			if tc.allowed && (len(output.Matches) == 0 || output.Matches[0].Rule != tc.rule) {
+				t.Errorf("Expected %s to match: %s", tc.rule, text)
+			}
+			if !tc.allowed && !strings.Contains(output.Summary, "denies traffic by default") {
+				t.Errorf("Expected a default-deny summary, got %q", output.Summary)
+			}
+			if calls != 2 {
+				t.Errorf("Expected only the policy and device list to be fetched, got %d calls", calls)
+			}
+		})
+	}
+}
+
+func TestCheckAccessErrors(t *testing.T) {
+	var calls int
+	server := accessTestServer(accessTestACL(), &calls)
+
+	result, text := callTool(t, server, "check_access", map[string]any{"source": "alice-laptop", "destination": "db-2"})
+	if !result.IsError || !strings.Contains(text, "port") {
+		t.Errorf("Expected a missing port error, got %s", text)
+	}
+
+	result, text = callTool(t, server, "check_access", map[string]any{"source": "web-9", "destination": "db-2", "port": 80})
+	if !result.IsError || !strings.Contains(text, "no device matches") {
+		t.Errorf("Expected an unknown source error, got %s", text)
+	}
+
+	result, text = callTool(t, server, "check_access", map[string]any{"source": "alice-laptop", "destination": "db-2", "proto": "icmp"})
+	if result.IsError {
+		t.Errorf("Expected icmp checks not to need a port, got %s", text)
+	}
+}
+
+func TestEffectiveAccess(t *testing.T) {
+	var calls int
+	server := accessTestServer(accessTestACL(), &calls)
+
+	result, text := callTool(t, server, "effective_access", map[string]any{"source": "db-1"})
+	if result.IsError {
+		t.Fatalf("Expected success, got error: %s", text)
+	}
+
+	var output struct {
+		Summary struct {
+			Rules        int `json:"rules"`
+			Destinations int `json:"destinations"`
+			Devices      int `json:"devices"`
+		} `json:"summary"`
+		Rules []policy.OutboundRule `json:"rules"`
+	}
+	if err := json.Unmarshal([]byte(text), &output); err != nil {
+		t.Fatalf("Failed to unmarshal output: %v", err)
+	}
+
+	if output.Summary.Rules != 1 || output.Summary.Destinations != 1 || output.Summary.Devices != 1 {
+		t.Errorf("Unexpected summary %+v", output.Summary)
+	}
+	if len(output.Rules) != 1 || output.Rules[0].Rule != "acls[1]" || output.Rules[0].Source != "tag:prod" {
+		t.Fatalf("Expected access through acls[1], got %+v", output.Rules)
+	}
+	dest := output.Rules[0].Destinations[0]
+	if dest.Ports != "5432,9100" || len(dest.Devices) != 1 || dest.Devices[0] != "db-2.example.ts.net" {
+		t.Errorf("Expected db-2 on 5432,9100, got %+v", dest)
+	}
+	if calls != 2 {
+		t.Errorf("Expected only the policy and device list to be fetched, got %d calls", calls)
+	}
+
+	result, text = callTool(t, server, "effective_access", map[string]any{"source": "bob@example.com"})
+	if result.IsError || !strings.Contains(text, `"rules": []`) {
+		t.Errorf("Expected no access for bob, got %s", text)
+	}
+}
+
+func TestEffectiveAccessTo(t *testing.T) {
+	var calls int
+	server := accessTestServer(accessTestACL(), &calls)
+
+	result, text := callTool(t, server, "effective_access_to", map[string]any{"destination": "100.101.2.4"})
+	if result.IsError {
+		t.Fatalf("Expected success, got error: %s", text)
+	}
+
+	var output struct {
+		Summary struct {
+			Rules   int `json:"rules"`
+			Devices int `json:"devices"`
+			Users   int `json:"users"`
+		} `json:"summary"`
+		Rules []policy.InboundRule `json:"rules"`
+	}
+	if err := json.Unmarshal([]byte(text), &output); err != nil {
+		t.Fatalf("Failed to unmarshal output: %v", err)
+	}
+
+	if output.Summary.Rules != 2 || output.Summary.Devices != 2 || output.Summary.Users != 1 {
+		t.Errorf("Unexpected summary %+v", output.Summary)
+	}
+	if len(output.Rules) != 2 {
+		t.Fatalf("Expected two rules, got %+v", output.Rules)
+	}
+	if first := output.Rules[0]; first.Rule != "acls[0]" || first.Ports != "5432" ||
+		len(first.Devices) != 1 || first.Devices[0] != "alice-laptop.example.ts.net" {
+		t.Errorf("Unexpected first rule %+v", first)
+	}
+	if second := output.Rules[1]; second.Rule != "acls[1]" || len(second.Devices) != 1 || second.Devices[0] != "db-1.example.ts.net" {
+		t.Errorf("Unexpected second rule %+v", second)
+	}
+
+	result, text = callTool(t, server, "effective_access_to", map[string]any{"destination": "web-9"})
+	if !result.IsError || !strings.Contains(text, "no device matches") {
+		t.Errorf("Expected an unknown destination error, got %s", text)
+	}
+}
@@ -3,11 +3,18 @@ package tools
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
 
-	"github.com/modelcontextprotocol/go-sdk/jsonschema"
+	"github.com/google/jsonschema-go/jsonschema"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
+	tailscale "tailscale.com/client/tailscale/v2"
 
 	"github.com/R167/tailscale-mcp/internal"
+	"github.com/R167/tailscale-mcp/policy"
 )
 
 func RegisterACLTools(server *mcp.Server, client internal.TailscaleClient) {
@@ -22,18 +29,313 @@ func RegisterACLTools(server *mcp.Server, client internal.TailscaleClient) {
 				AdditionalProperties: &jsonschema.Schema{Not: &jsonschema.Schema{}},
 			},
 		},
-		func(ctx context.Context, session *mcp.ServerSession, params *mcp.CallToolParamsFor[map[string]any]) (*mcp.CallToolResultFor[any], error) {
+		func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
 			acl, err := client.PolicyFile().Get(ctx)
 			if err != nil {
-				return toolError("Failed to get ACL policy", err), nil
+				return toolError("Failed to get ACL policy", err), nil, nil
 			}
 
 			output, err := json.MarshalIndent(acl, "", "  ")
 			if err != nil {
-				return toolError("Failed to serialize ACL policy", err), nil
+				return toolError("Failed to serialize ACL policy", err), nil, nil
 			}
 
-			return toolSuccess(string(output)), nil
+			return toolSuccess(string(output)), nil, nil
 		},
 	)
+
+	// Get raw ACL tool
+	mcp.AddTool(
+		server,
+		&mcp.Tool{
+			Name: "get_acl_raw",
+			Description: "Get the tailnet policy file as HuJSON text exactly as stored, including comments, ordering, and " +
+				"trailing commas, together with its ETag. Use this to propose precise edits to the policy.",
+			InputSchema: &jsonschema.Schema{
+				Type:                 "object",
+				AdditionalProperties: &jsonschema.Schema{Not: &jsonschema.Schema{}},
+			},
+		},
+		func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
+			raw, err := client.PolicyFile().Raw(ctx)
+			if err != nil {
+				return toolError("Failed to get raw ACL policy", err), nil, nil
+			}
+
+			result := struct {
+				ETag   string `json:"etag"`
+				Policy string `json:"policy"`
+			}{
+				ETag:   raw.ETag,
+				Policy: raw.HuJSON,
+			}
+
+			output, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				return toolError("Failed to serialize raw ACL policy", err), nil, nil
+			}
+
+			return toolSuccess(string(output)), nil, nil
+		},
+	)
+
+	// Validate ACL tool
+	mcp.AddTool(
+		server,
+		&mcp.Tool{
+			Name: "validate_acl",
+			Description: "Validate a candidate HuJSON policy file without applying it. Reports syntax errors with their " +
+				"position, semantic errors from the Tailscale API, and failing tests/sshTests assertions, so the policy " +
+				"can be fixed and validated again.",
+			InputSchema: &jsonschema.Schema{
+				Type: "object",
+				Properties: map[string]*jsonschema.Schema{
+					"policy": {
+						Type:        "string",
+						Description: "The complete policy file as HuJSON text",
+					},
+				},
+				Required:             []string{"policy"},
+				AdditionalProperties: &jsonschema.Schema{Not: &jsonschema.Schema{}},
+			},
+		},
+		func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
+			policy, err := getStringParam(args, "policy")
+			if err != nil {
+				return toolError("Invalid policy parameter", err), nil, nil
+			}
+
+			validation, err := validatePolicy(ctx, client, policy)
+			if err != nil {
+				return toolError("Failed to validate ACL policy", err), nil, nil
+			}
+
+			output, err := json.MarshalIndent(validation, "", "  ")
+			if err != nil {
+				return toolError("Failed to serialize ACL validation", err), nil, nil
+			}
+
+			return toolSuccess(string(output)), nil, nil
+		},
+	)
+
+	// Update ACL tool
+	mcp.AddTool(
+		server,
+		&mcp.Tool{
+			Name: "update_acl",
+			Description: "Replace the tailnet policy file with new HuJSON text. Shows a unified diff against the current " +
+				"policy and validates the new policy first. The update is only applied when etag matches the current " +
+				"policy's ETag (from get_acl_raw or a dry run), so a concurrent edit is never overwritten. " +
+				"Use dry_run to preview the diff and validation results without applying anything.",
+			InputSchema: &jsonschema.Schema{
+				Type: "object",
+				Properties: map[string]*jsonschema.Schema{
+					"policy": {
+						Type:        "string",
+						Description: "The complete new policy file as HuJSON text",
+					},
+					"etag": {
+						Type:        "string",
+						Description: "ETag of the policy version the change is based on; required unless dry_run is set",
+					},
+					"dry_run": {
+						Type:        "boolean",
+						Description: "Only return the diff and validation results without applying the policy",
+					},
+				},
+				Required:             []string{"policy"},
+				AdditionalProperties: &jsonschema.Schema{Not: &jsonschema.Schema{}},
+			},
+		},
+		func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
+			policy, err := getStringParam(args, "policy")
+			if err != nil {
+				return toolError("Invalid policy parameter", err), nil, nil
+			}
+
+			etag, err := getOptionalStringParam(args, "etag")
+			if err != nil {
+				return toolError("Invalid etag parameter", err), nil, nil
+			}
+
+			dryRun, err := getOptionalBoolParam(args, "dry_run")
+			if err != nil {
+				return toolError("Invalid dry_run parameter", err), nil, nil
+			}
+			isDryRun := dryRun != nil && *dryRun
+
+			if !isDryRun && etag == "" {
+				return toolError("Missing etag parameter",
+					fmt.Errorf("pass the etag returned by get_acl_raw or a dry run so concurrent edits are detected")), nil, nil
+			}
+
+			current, err := client.PolicyFile().Raw(ctx)
+			if err != nil {
+				return toolError("Failed to get current ACL policy", err), nil, nil
+			}
+
+			if !isDryRun && !sameETag(etag, current.ETag) {
+				return toolError("ACL policy changed since it was read",
+					fmt.Errorf("current ETag is %s but the update is based on %s; review the current policy and try again",
+						current.ETag, etag)), nil, nil
+			}
+
+			validation, err := validatePolicy(ctx, client, policy)
+			if err != nil {
+				return toolError("Failed to validate ACL policy", err), nil, nil
+			}
+
+			result := struct {
+				Applied    bool           `json:"applied"`
+				DryRun     bool           `json:"dryRun"`
+				Message    string         `json:"message"`
+				ETag       string         `json:"etag"`
+				Diff       string         `json:"diff"`
+				Validation *ACLValidation `json:"validation"`
+			}{
+				DryRun:     isDryRun,
+				ETag:       current.ETag,
+				Diff:       unifiedDiff("current", "proposed", current.HuJSON, policy),
+				Validation: validation,
+			}
+
+			switch {
+			case isDryRun:
+				result.Message = "Dry run: the policy was not applied"
+			case !validation.Valid:
+				result.Message = fmt.Sprintf("The policy failed %s validation and was not applied", validation.Stage)
+			case result.Diff == "":
+				result.Message = "The policy is unchanged; nothing was applied"
+			default:
+				if err := client.PolicyFile().Set(ctx, policy, current.ETag); err != nil {
+					var apiErr tailscale.APIError
+					if errors.As(err, &apiErr) && apiErr.Status == http.StatusPreconditionFailed {
+						return toolError("ACL policy changed since it was read",
+							fmt.Errorf("the policy was modified concurrently; review the current policy and try again")), nil, nil
+					}
+					return toolError("Failed to update ACL policy", err), nil, nil
+				}
+
+				result.Applied = true
+				result.Message = "The policy was applied"
+
+				if updated, err := client.PolicyFile().Raw(ctx); err == nil {
+					result.ETag = updated.ETag
+				} else {
+					result.ETag = ""
+					result.Message += "; fetch it again with get_acl_raw to get its new ETag"
+				}
+			}
+
+			output, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				return toolError("Failed to serialize ACL update", err), nil, nil
+			}
+
+			return toolSuccess(string(output)), nil, nil
+		},
+	)
+
+	// Lint ACL tool
+	mcp.AddTool(
+		server,
+		&mcp.Tool{
+			Name: "lint_acl",
+			Description: "Review the current policy file against the tailnet's devices and users. Flags groups with no " +
+				"members, tags no device carries, unused tagOwners entries, rules shadowed by earlier broader rules, " +
+				"*:* wildcards, and hosts pointing at addresses no device or subnet route has. Each finding has a " +
+				"severity, the path of the offending policy entry, and a suggested fix.",
+			InputSchema: &jsonschema.Schema{
+				Type:                 "object",
+				Properties:           map[string]*jsonschema.Schema{},
+				AdditionalProperties: &jsonschema.Schema{Not: &jsonschema.Schema{}},
+			},
+		},
+		func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
+			acl, err := client.PolicyFile().Get(ctx)
+			if err != nil {
+				return toolError("Failed to get ACL policy", err), nil, nil
+			}
+
+			// Routes are only included with all fields, and are needed to recognize hosts behind subnet routers
+			devices, err := client.Devices().ListWithAllFields(ctx)
+			if err != nil {
+				return toolError("Failed to list devices", err), nil, nil
+			}
+
+			users, err := client.Users().List(ctx, nil, nil)
+			if err != nil {
+				return toolError("Failed to list users", err), nil, nil
+			}
+
+			findings := policy.Lint(acl, devices, users)
+			slices.SortStableFunc(findings, func(a, b policy.Finding) int {
+				return severityRank[a.Severity] - severityRank[b.Severity]
+			})
+
+			type summary struct {
+				Errors   int `json:"errors"`
+				Warnings int `json:"warnings"`
+				Info     int `json:"info"`
+			}
+			result := struct {
+				Summary  summary          `json:"summary"`
+				Findings []policy.Finding `json:"findings"`
+			}{
+				Findings: findings,
+			}
+			if result.Findings == nil {
+				result.Findings = []policy.Finding{}
+			}
+			for _, finding := range findings {
+				switch finding.Severity {
+				case policy.SeverityError:
+					result.Summary.Errors++
+				case policy.SeverityWarning:
+					result.Summary.Warnings++
+				default:
+					result.Summary.Info++
+				}
+			}
+
+			output, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				return toolError("Failed to serialize lint findings", err), nil, nil
+			}
+
+			return toolSuccess(string(output)), nil, nil
+		},
+	)
+}
+
+// severityRank orders lint findings with the most severe first
+var severityRank = map[policy.Severity]int{
+	policy.SeverityError:   0,
+	policy.SeverityWarning: 1,
+	policy.SeverityInfo:    2,
+}
+
+// sameETag reports whether two ETags refer to the same version, ignoring surrounding quotes
+func sameETag(a, b string) bool {
+	return strings.Trim(a, `"`) == strings.Trim(b, `"`)
+}
+
+// checkTagsDeclared verifies that every tag is well formed and declared in the policy's tagOwners
+func checkTagsDeclared(acl *tailscale.ACL, tags []string) error {
+	var undeclared []string
+	for _, tag := range tags {
+		if !strings.HasPrefix(tag, "tag:") || len(tag) == len("tag:") {
+			return fmt.Errorf("invalid tag %q: tags must have the form tag:<name>", tag)
+		}
+		if _, ok := acl.TagOwners[tag]; !ok {
+			undeclared = append(undeclared, tag)
+		}
+	}
+
+	if len(undeclared) > 0 {
+		return fmt.Errorf("tags not declared in the policy's tagOwners: %s", strings.Join(undeclared, ", "))
+	}
+
+	return nil
 }
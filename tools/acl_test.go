@@ -4,9 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/tailscale/hujson"
 	tailscale "tailscale.com/client/tailscale/v2"
 
 	"github.com/R167/tailscale-mcp/internal"
@@ -65,6 +69,100 @@ func TestGetACLError(t *testing.T) {
 	RegisterACLTools(server, mockClient)
 }
 
+func TestGetACLRawSuccess(t *testing.T) {
+	policy := `{
+	// Admins can reach every server
+	"acls": [
+		{"action": "accept", "src": ["group:admin"], "dst": ["tag:server:*"]},
+	],
+	"tagOwners": {"tag:server": ["group:admin"]},
+}
+`
+	mockClient := &internal.MockTailscaleClient{
+		PolicyFileFunc: func() internal.PolicyFileResource {
+			return &internal.MockPolicyFileResource{
+				RawFunc: func(ctx context.Context) (*tailscale.RawACL, error) {
+					return &tailscale.RawACL{HuJSON: policy, ETag: `"abc123"`}, nil
+				},
+			}
+		},
+	}
+
+	server := mcp.NewServer(&mcp.Implementation{}, &mcp.ServerOptions{})
+	RegisterACLTools(server, mockClient)
+
+	result, text := callTool(t, server, "get_acl_raw", nil)
+	if result.IsError {
+		t.Fatalf("Expected success, got error: %s", text)
+	}
+
+	var output struct {
+		ETag   string `json:"etag"`
+		Policy string `json:"policy"`
+	}
+	if err := json.Unmarshal([]byte(text), &output); err != nil {
+		t.Fatalf("Failed to unmarshal output: %v", err)
+	}
+	if output.Policy != policy {
+		t.Errorf("Expected policy to be returned exactly as stored, got %q", output.Policy)
+	}
+	if output.ETag != `"abc123"` {
+		t.Errorf("Expected ETag \"abc123\", got %s", output.ETag)
+	}
+}
+
+func TestGetACLRawError(t *testing.T) {
+	mockClient := &internal.MockTailscaleClient{
+		PolicyFileFunc: func() internal.PolicyFileResource {
+			return &internal.MockPolicyFileResource{
+				RawFunc: func(ctx context.Context) (*tailscale.RawACL, error) {
+					return nil, fmt.Errorf("API error: unauthorized")
+				},
+			}
+		},
+	}
+
+	server := mcp.NewServer(&mcp.Implementation{}, &mcp.ServerOptions{})
+	RegisterACLTools(server, mockClient)
+
+	result, text := callTool(t, server, "get_acl_raw", nil)
+	if !result.IsError || !strings.Contains(text, "unauthorized") {
+		t.Errorf("Expected error result, got %s", text)
+	}
+}
+
+func TestMockPolicyRepresentationsAgree(t *testing.T) {
+	mock := &internal.MockPolicyFileResource{}
+
+	parsed, err := mock.Get(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	raw, err := mock.Raw(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	standard, err := hujson.Standardize([]byte(raw.HuJSON))
+	if err != nil {
+		t.Fatalf("Mock HuJSON is invalid: %v", err)
+	}
+	var fromRaw tailscale.ACL
+	if err := json.Unmarshal(standard, &fromRaw); err != nil {
+		t.Fatalf("Failed to unmarshal mock HuJSON: %v", err)
+	}
+
+	if !reflect.DeepEqual(fromRaw.ACLs, parsed.ACLs) {
+		t.Errorf("Expected raw and parsed mock policies to match: %+v vs %+v", fromRaw.ACLs, parsed.ACLs)
+	}
+	if raw.ETag != parsed.ETag {
+		t.Errorf("Expected matching ETags, got %s and %s", raw.ETag, parsed.ETag)
+	}
+	if !strings.Contains(raw.HuJSON, "//") {
+		t.Error("Expected mock HuJSON to contain comments")
+	}
+}
+
 func TestACLJSONSerialization(t *testing.T) {
 	acl := tailscale.ACL{
 		ACLs: []tailscale.ACLEntry{
@@ -135,3 +233,308 @@ func TestACLEmptyResponse(t *testing.T) {
 		t.Error("Expected non-empty JSON for empty ACL")
 	}
 }
+
+func TestCheckTagsDeclared(t *testing.T) {
+	acl := &tailscale.ACL{
+		TagOwners: map[string][]string{
+			"tag:prod": {"group:ops"},
+			"tag:db":   {"group:ops"},
+		},
+	}
+
+	testCases := []struct {
+		name      string
+		tags      []string
+		expectErr string
+	}{
+		{name: "AllDeclared", tags: []string{"tag:prod", "tag:db"}},
+		{name: "Empty", tags: nil},
+		{name: "Undeclared", tags: []string{"tag:prod", "tag:web", "tag:cache"}, expectErr: "tag:web, tag:cache"},
+		{name: "MissingPrefix", tags: []string{"prod"}, expectErr: "must have the form tag:<name>"},
+		{name: "EmptyName", tags: []string{"tag:"}, expectErr: "must have the form tag:<name>"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := checkTagsDeclared(acl, tc.tags)
+			if tc.expectErr == "" {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.expectErr) {
+				t.Errorf("Expected error containing %q, got %v", tc.expectErr, err)
+			}
+		})
+	}
+}
+
+// policyStore is an in-memory policy file backing a mock client for update_acl tests
+type policyStore struct {
+	policy   string
+	etag     string
+	sets     int
+	validate func(policy string) (*tailscale.APIError, error)
+	setErr   error
+}
+
+func (s *policyStore) client() *internal.MockTailscaleClient {
+	return &internal.MockTailscaleClient{
+		PolicyFileFunc: func() internal.PolicyFileResource {
+			return &internal.MockPolicyFileResource{
+				RawFunc: func(ctx context.Context) (*tailscale.RawACL, error) {
+					return &tailscale.RawACL{HuJSON: s.policy, ETag: s.etag}, nil
+				},
+				ValidateFunc: func(ctx context.Context, policy string) (*tailscale.APIError, error) {
+					if s.validate != nil {
+						return s.validate(policy)
+					}
+					return nil, nil
+				},
+				SetFunc: func(ctx context.Context, policy string, etag string) error {
+					if s.setErr != nil {
+						return s.setErr
+					}
+					s.sets++
+					s.policy = policy
+					s.etag = fmt.Sprintf(`"v%d"`, s.sets+1)
+					return nil
+				},
+			}
+		},
+	}
+}
+
+type aclUpdateOutput struct {
+	Applied    bool          `json:"applied"`
+	DryRun     bool          `json:"dryRun"`
+	Message    string        `json:"message"`
+	ETag       string        `json:"etag"`
+	Diff       string        `json:"diff"`
+	Validation ACLValidation `json:"validation"`
+}
+
+func callUpdateACL(t *testing.T, store *policyStore, args map[string]any) (*mcp.CallToolResult, string, aclUpdateOutput) {
+	t.Helper()
+
+	server := mcp.NewServer(&mcp.Implementation{}, &mcp.ServerOptions{})
+	RegisterACLTools(server, store.client())
+
+	result, text := callTool(t, server, "update_acl", args)
+
+	var output aclUpdateOutput
+	if !result.IsError {
+		if err := json.Unmarshal([]byte(text), &output); err != nil {
+			t.Fatalf("Failed to unmarshal output: %v", err)
+		}
+	}
+	return result, text, output
+}
+
+const (
+	currentTestPolicy  = "{\n\t// Everyone can reach everything\n\t\"acls\": [\n\t\t{\"action\": \"accept\", \"src\": [\"*\"], \"dst\": [\"*:*\"]},\n\t],\n}\n"
+	proposedTestPolicy = "{\n\t// Only engineers can reach databases\n\t\"acls\": [\n\t\t{\"action\": \"accept\", \"src\": [\"group:eng\"], \"dst\": [\"tag:db:5432\"]},\n\t],\n}\n"
+)
+
+func TestUpdateACLDryRun(t *testing.T) {
+	store := &policyStore{policy: currentTestPolicy, etag: `"v1"`}
+
+	result, text, output := callUpdateACL(t, store, map[string]any{"policy": proposedTestPolicy, "dry_run": true})
+	if result.IsError {
+		t.Fatalf("Expected success, got error: %s", text)
+	}
+
+	if output.Applied || !output.DryRun || store.sets != 0 {
+		t.Errorf("Expected nothing to be applied in a dry run: %s", text)
+	}
+	if output.ETag != `"v1"` || !output.Validation.Valid {
+		t.Errorf("Unexpected dry run result: %s", text)
+	}
+	for _, line := range []string{"--- current", "+++ proposed", "-\t// Everyone can reach everything", "+\t// Only engineers can reach databases"} {
+		if !strings.Contains(output.Diff, line+"\n") {
+			t.Errorf("Expected diff to contain %q, got:\n%s", line, output.Diff)
+		}
+	}
+}
+
+func TestUpdateACLApplies(t *testing.T) {
+	store := &policyStore{policy: currentTestPolicy, etag: `"v1"`}
+
+	result, text, output := callUpdateACL(t, store, map[string]any{"policy": proposedTestPolicy, "etag": "v1"})
+	if result.IsError {
+		t.Fatalf("Expected success, got error: %s", text)
+	}
+
+	if !output.Applied || store.sets != 1 || store.policy != proposedTestPolicy {
+		t.Errorf("Expected the policy to be applied: %s", text)
+	}
+	if output.ETag != `"v2"` {
+		t.Errorf("Expected the new ETag, got %s", output.ETag)
+	}
+}
+
+func TestUpdateACLRefusesStaleETag(t *testing.T) {
+	store := &policyStore{policy: currentTestPolicy, etag: `"v2"`}
+
+	result, text, _ := callUpdateACL(t, store, map[string]any{"policy": proposedTestPolicy, "etag": `"v1"`})
+	if !result.IsError || !strings.Contains(text, `"v2"`) {
+		t.Errorf("Expected a conflict error naming the current ETag, got %s", text)
+	}
+	if store.sets != 0 {
+		t.Error("Expected no update with a stale ETag")
+	}
+
+	result, text, _ = callUpdateACL(t, store, map[string]any{"policy": proposedTestPolicy})
+	if !result.IsError || !strings.Contains(text, "etag") {
+		t.Errorf("Expected an error without an ETag, got %s", text)
+	}
+}
+
+func TestUpdateACLConcurrentEdit(t *testing.T) {
+	store := &policyStore{
+		policy: currentTestPolicy,
+		etag:   `"v1"`,
+		setErr: tailscale.APIError{Message: "precondition failed, invalid old hash", Status: http.StatusPreconditionFailed},
+	}
+
+	result, text, _ := callUpdateACL(t, store, map[string]any{"policy": proposedTestPolicy, "etag": `"v1"`})
+	if !result.IsError || !strings.Contains(text, "changed since it was read") {
+		t.Errorf("Expected a conflict error, got %s", text)
+	}
+}
+
+func TestUpdateACLValidationFailure(t *testing.T) {
+	store := &policyStore{
+		policy: currentTestPolicy,
+		etag:   `"v1"`,
+		validate: func(policy string) (*tailscale.APIError, error) {
+			return &tailscale.APIError{Status: http.StatusBadRequest, Message: `unknown group "group:eng"`}, nil
+		},
+	}
+
+	result, text, output := callUpdateACL(t, store, map[string]any{"policy": proposedTestPolicy, "etag": `"v1"`})
+	if result.IsError {
+		t.Fatalf("Expected a result, got error: %s", text)
+	}
+	if output.Applied || store.sets != 0 {
+		t.Error("Expected an invalid policy not to be applied")
+	}
+	if output.Validation.Stage != "semantic" || output.Diff == "" {
+		t.Errorf("Expected validation errors and a diff, got %s", text)
+	}
+
+	result, text, output = callUpdateACL(t, store, map[string]any{"policy": "{\"acls\": [", "etag": `"v1"`})
+	if result.IsError || output.Applied || output.Validation.Stage != "syntax" {
+		t.Errorf("Expected a syntax error result, got %s", text)
+	}
+}
+
+func TestUpdateACLUnchanged(t *testing.T) {
+	store := &policyStore{policy: currentTestPolicy, etag: `"v1"`}
+
+	result, text, output := callUpdateACL(t, store, map[string]any{"policy": currentTestPolicy, "etag": `"v1"`})
+	if result.IsError {
+		t.Fatalf("Expected success, got error: %s", text)
+	}
+	if output.Applied || output.Diff != "" || store.sets != 0 {
+		t.Errorf("Expected an unchanged policy not to be applied: %s", text)
+	}
+}
+
+func TestLintACL(t *testing.T) {
+	client := &internal.MockTailscaleClient{
+		PolicyFileFunc: func() internal.PolicyFileResource {
+			return &internal.MockPolicyFileResource{
+				GetFunc: func(ctx context.Context) (*tailscale.ACL, error) {
+					return &tailscale.ACL{
+						Groups:    map[string][]string{"group:dba": {}},
+						TagOwners: map[string][]string{"tag:db": {"autogroup:admin"}},
+						Hosts:     map[string]string{"db-primary": "100.101.2.3", "old-db": "100.101.9.9"},
+						ACLs: []tailscale.ACLEntry{
+							{Action: "accept", Source: []string{"tag:prod"}, Destination: []string{"tag:db:5432"}},
+							{Action: "accept", Source: []string{"*"}, Destination: []string{"*:*"}},
+						},
+					}, nil
+				},
+			}
+		},
+		DevicesFunc: func() internal.DevicesResource {
+			return &internal.MockDevicesResource{
+				ListWithAllFieldsFunc: func(ctx context.Context) ([]tailscale.Device, error) {
+					return testDevices(), nil
+				},
+			}
+		},
+		UsersFunc: func() internal.UsersResource {
+			return &internal.MockUsersResource{
+				ListFunc: func(ctx context.Context, userType *tailscale.UserType, role *tailscale.UserRole) ([]tailscale.User, error) {
+					return []tailscale.User{{LoginName: "alice@example.com"}, {LoginName: "bob@example.com"}}, nil
+				},
+			}
+		},
+	}
+
+	server := mcp.NewServer(&mcp.Implementation{}, &mcp.ServerOptions{})
+	RegisterACLTools(server, client)
+
+	result, text := callTool(t, server, "lint_acl", nil)
+	if result.IsError {
+		t.Fatalf("Expected success, got error: %s", text)
+	}
+
+	var output struct {
+		Summary struct {
+			Errors   int `json:"errors"`
+			Warnings int `json:"warnings"`
+			Info     int `json:"info"`
+		} `json:"summary"`
+		Findings []struct {
+			Severity string `json:"severity"`
+			Check    string `json:"check"`
+			Path     string `json:"path"`
+			Fix      string `json:"fix"`
+		} `json:"findings"`
+	}
+	if err := json.Unmarshal([]byte(text), &output); err != nil {
+		t.Fatalf("Failed to unmarshal output: %v", err)
+	}
+
+	if output.Summary.Errors != 1 || output.Summary.Warnings != 2 || output.Summary.Info != 0 {
+		t.Errorf("Unexpected summary %+v: %s", output.Summary, text)
+	}
+	if len(output.Findings) != 3 {
+		t.Fatalf("Expected 3 findings, got %s", text)
+	}
+	if first := output.Findings[0]; first.Severity != "error" || first.Check != "wildcard-destination" || first.Path != "acls[1]" {
+		t.Errorf("Expected the *:* rule first, got %+v", first)
+	}
+	for _, finding := range output.Findings[1:] {
+		if finding.Severity != "warning" || finding.Fix == "" {
+			t.Errorf("Unexpected finding %+v", finding)
+		}
+	}
+	if !strings.Contains(text, `groups[\"group:dba\"]`) || !strings.Contains(text, `hosts[\"old-db\"]`) {
+		t.Errorf("Expected the empty group and unknown host to be flagged: %s", text)
+	}
+}
+
+func TestLintACLUsersError(t *testing.T) {
+	client := &internal.MockTailscaleClient{
+		UsersFunc: func() internal.UsersResource {
+			return &internal.MockUsersResource{
+				ListFunc: func(ctx context.Context, userType *tailscale.UserType, role *tailscale.UserRole) ([]tailscale.User, error) {
+					return nil, fmt.Errorf("API error: forbidden")
+				},
+			}
+		},
+	}
+
+	server := mcp.NewServer(&mcp.Implementation{}, &mcp.ServerOptions{})
+	RegisterACLTools(server, client)
+
+	result, text := callTool(t, server, "lint_acl", nil)
+	if !result.IsError || !strings.Contains(text, "Failed to list users") {
+		t.Errorf("Expected a users error, got %s", text)
+	}
+}
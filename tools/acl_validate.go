@@ -0,0 +1,130 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+
+	"github.com/tailscale/hujson"
+
+	"github.com/R167/tailscale-mcp/internal"
+)
+
+// Validation stages reported by ACLValidation.Stage
+const (
+	aclStageSyntax   = "syntax"
+	aclStageSemantic = "semantic"
+	aclStageTests    = "tests"
+)
+
+// ACLValidation is the outcome of validating a candidate policy file
+type ACLValidation struct {
+	Valid bool `json:"valid"`
+	// Stage is the check that failed: syntax, semantic, or tests
+	Stage          string           `json:"stage,omitempty"`
+	SyntaxErrors   []ACLSyntaxError `json:"syntaxErrors,omitempty"`
+	SemanticErrors []string         `json:"semanticErrors,omitempty"`
+	TestFailures   []ACLTestFailure `json:"testFailures,omitempty"`
+	// Tests and SSHTests count the assertions embedded in the policy
+	Tests    int `json:"tests"`
+	SSHTests int `json:"sshTests"`
+}
+
+// ACLSyntaxError is a HuJSON parse error at a position in the policy
+type ACLSyntaxError struct {
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+// ACLTestFailure lists the failing assertions for one test source
+type ACLTestFailure struct {
+	User   string   `json:"user,omitempty"`
+	Errors []string `json:"errors"`
+}
+
+var hujsonErrorPattern = regexp.MustCompile(`^hujson: line (\d+), column (\d+): (.*)$`)
+
+// validatePolicy checks a HuJSON policy in stages. Syntax is checked locally so that parse
+// errors come back with a position and without an API call; the policy is then sent to the
+// Tailscale API, which rejects semantic errors and runs the policy's tests and sshTests.
+func validatePolicy(ctx context.Context, client internal.TailscaleClient, policy string) (*ACLValidation, error) {
+	validation := &ACLValidation{}
+
+	if _, err := hujson.Parse([]byte(policy)); err != nil {
+		validation.Stage = aclStageSyntax
+		validation.SyntaxErrors = []ACLSyntaxError{parseSyntaxError(err)}
+		return validation, nil
+	}
+
+	validation.Tests, validation.SSHTests = countPolicyTests(policy)
+
+	apiErr, err := client.PolicyFile().Validate(ctx, policy)
+	if err != nil {
+		return nil, err
+	}
+
+	if apiErr == nil {
+		validation.Valid = true
+		return validation, nil
+	}
+
+	if apiErr.Status == http.StatusOK {
+		validation.Stage = aclStageTests
+		for _, data := range apiErr.Data {
+			validation.TestFailures = append(validation.TestFailures, ACLTestFailure{User: data.User, Errors: data.Errors})
+		}
+		if len(validation.TestFailures) == 0 {
+			validation.TestFailures = []ACLTestFailure{{Errors: []string{apiErr.Message}}}
+		}
+		return validation, nil
+	}
+
+	validation.Stage = aclStageSemantic
+	if apiErr.Message != "" {
+		validation.SemanticErrors = append(validation.SemanticErrors, apiErr.Message)
+	}
+	for _, data := range apiErr.Data {
+		for _, message := range data.Errors {
+			if data.User != "" {
+				message = fmt.Sprintf("%s: %s", data.User, message)
+			}
+			validation.SemanticErrors = append(validation.SemanticErrors, message)
+		}
+	}
+
+	return validation, nil
+}
+
+// parseSyntaxError extracts the position from a hujson parse error
+func parseSyntaxError(err error) ACLSyntaxError {
+	match := hujsonErrorPattern.FindStringSubmatch(err.Error())
+	if match == nil {
+		return ACLSyntaxError{Message: err.Error()}
+	}
+
+	line, _ := strconv.Atoi(match[1])
+	column, _ := strconv.Atoi(match[2])
+	return ACLSyntaxError{Line: line, Column: column, Message: match[3]}
+}
+
+// countPolicyTests returns the number of tests and sshTests in a syntactically valid policy
+func countPolicyTests(policy string) (tests, sshTests int) {
+	standard, err := hujson.Standardize([]byte(policy))
+	if err != nil {
+		return 0, 0
+	}
+
+	var embedded struct {
+		Tests    []json.RawMessage `json:"tests"`
+		SSHTests []json.RawMessage `json:"sshTests"`
+	}
+	if err := json.Unmarshal(standard, &embedded); err != nil {
+		return 0, 0
+	}
+
+	return len(embedded.Tests), len(embedded.SSHTests)
+}
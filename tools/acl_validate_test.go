@@ -0,0 +1,156 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	tailscale "tailscale.com/client/tailscale/v2"
+
+	"github.com/R167/tailscale-mcp/internal"
+)
+
+const validTestPolicy = `{
+	"acls": [
+		{"action": "accept", "src": ["group:eng"], "dst": ["tag:db:5432"]},
+	],
+	"tests": [
+		{"src": "alice@example.com", "accept": ["tag:db:5432"]},
+		{"src": "bob@example.com", "deny": ["tag:db:5432"]},
+	],
+	"sshTests": [
+		{"src": "alice@example.com", "dst": ["tag:db"], "accept": ["root"]},
+	],
+}`
+
+// validatingClient returns a mock client whose policy validation is handled by validate
+func validatingClient(validate func(policy string) (*tailscale.APIError, error), calls *int) *internal.MockTailscaleClient {
+	return &internal.MockTailscaleClient{
+		PolicyFileFunc: func() internal.PolicyFileResource {
+			return &internal.MockPolicyFileResource{
+				ValidateFunc: func(ctx context.Context, policy string) (*tailscale.APIError, error) {
+					*calls++
+					return validate(policy)
+				},
+			}
+		},
+	}
+}
+
+func TestValidatePolicy(t *testing.T) {
+	testCases := []struct {
+		name     string
+		policy   string
+		apiErr   *tailscale.APIError
+		check    func(t *testing.T, v *ACLValidation)
+		apiCalls int
+	}{
+		{
+			name:     "Valid",
+			policy:   validTestPolicy,
+			apiCalls: 1,
+			check: func(t *testing.T, v *ACLValidation) {
+				if !v.Valid || v.Stage != "" || v.Tests != 2 || v.SSHTests != 1 {
+					t.Errorf("Unexpected validation %+v", v)
+				}
+			},
+		},
+		{
+			name:     "SyntaxError",
+			policy:   "{\n\t\"acls\": [\n\t\t{\"action\": },\n\t],\n}",
+			apiCalls: 0,
+			check: func(t *testing.T, v *ACLValidation) {
+				if v.Valid || v.Stage != "syntax" || len(v.SyntaxErrors) != 1 {
+					t.Fatalf("Unexpected validation %+v", v)
+				}
+				if v.SyntaxErrors[0].Line != 3 || v.SyntaxErrors[0].Column == 0 || v.SyntaxErrors[0].Message == "" {
+					t.Errorf("Expected a positioned syntax error, got %+v", v.SyntaxErrors[0])
+				}
+			},
+		},
+		{
+			name:   "SemanticError",
+			policy: validTestPolicy,
+			apiErr: &tailscale.APIError{
+				Status:  http.StatusBadRequest,
+				Message: `unknown group "group:eng"`,
+				Data:    []tailscale.APIErrorData{{User: "alice@example.com", Errors: []string{"user not found"}}},
+			},
+			apiCalls: 1,
+			check: func(t *testing.T, v *ACLValidation) {
+				if v.Valid || v.Stage != "semantic" || len(v.SemanticErrors) != 2 {
+					t.Fatalf("Unexpected validation %+v", v)
+				}
+				if v.SemanticErrors[1] != "alice@example.com: user not found" {
+					t.Errorf("Unexpected semantic error %q", v.SemanticErrors[1])
+				}
+			},
+		},
+		{
+			name:   "TestsFailed",
+			policy: validTestPolicy,
+			apiErr: &tailscale.APIError{
+				Status:  http.StatusOK,
+				Message: "test(s) failed",
+				Data:    []tailscale.APIErrorData{{User: "bob@example.com", Errors: []string{"bob@example.com can access tag:db:5432"}}},
+			},
+			apiCalls: 1,
+			check: func(t *testing.T, v *ACLValidation) {
+				if v.Valid || v.Stage != "tests" || len(v.TestFailures) != 1 || v.TestFailures[0].User != "bob@example.com" {
+					t.Errorf("Unexpected validation %+v", v)
+				}
+				if v.Tests != 2 || v.SSHTests != 1 {
+					t.Errorf("Expected test counts, got %+v", v)
+				}
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls int
+			client := validatingClient(func(policy string) (*tailscale.APIError, error) {
+				return tc.apiErr, nil
+			}, &calls)
+
+			validation, err := validatePolicy(context.Background(), client, tc.policy)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if calls != tc.apiCalls {
+				t.Errorf("Expected %d API calls, got %d", tc.apiCalls, calls)
+			}
+			tc.check(t, validation)
+		})
+	}
+}
+
+func TestValidateACLTool(t *testing.T) {
+	var calls int
+	var received string
+	client := validatingClient(func(policy string) (*tailscale.APIError, error) {
+		received = policy
+		return nil, nil
+	}, &calls)
+
+	server := mcp.NewServer(&mcp.Implementation{}, &mcp.ServerOptions{})
+	RegisterACLTools(server, client)
+
+	result, text := callTool(t, server, "validate_acl", map[string]any{"policy": validTestPolicy})
+	if result.IsError {
+		t.Fatalf("Expected success, got error: %s", text)
+	}
+	if received != validTestPolicy {
+		t.Errorf("Expected the policy to be validated unchanged, got %q", received)
+	}
+
+	var validation ACLValidation
+	if err := json.Unmarshal([]byte(text), &validation); err != nil {
+		t.Fatalf("Failed to unmarshal output: %v", err)
+	}
+	if !validation.Valid || validation.Tests != 2 {
+		t.Errorf("Unexpected validation %s", text)
+	}
+}
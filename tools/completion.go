@@ -0,0 +1,264 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/R167/tailscale-mcp/internal"
+)
+
+// maxCompletionValues is the most values a completion may return
+const maxCompletionValues = 100
+
+// completionSource lists the values that can complete an argument whose current value is value
+type completionSource func(c *Completer, ctx context.Context, value string) ([]string, error)
+
+// promptArgumentCompletions maps prompt argument names to the values that complete them.
+// Arguments not listed here are not completed.
+var promptArgumentCompletions = map[string]completionSource{
+	"source":      (*Completer).deviceNames,
+	"destination": (*Completer).deviceNames,
+	"tags":        (*Completer).tagNames,
+}
+
+// resourceTemplateCompletions maps resource URI templates to the values that complete their
+// variable
+var resourceTemplateCompletions = map[string]completionSource{
+	deviceResourceURI: (*Completer).deviceNames,
+	keyResourceURI:    (*Completer).keyIDs,
+	userResourceURI:   (*Completer).userLogins,
+}
+
+// listArguments are arguments that hold a comma-separated list, of which only the last
+// element is completed
+var listArguments = []string{"tags"}
+
+// Completer answers completion/complete requests for prompt arguments and resource template
+// variables with the tailnet's devices, tags, users, and keys. MCP completion references a
+// prompt or resource template rather than a tool, so tool arguments are completed through the
+// resource templates that take the same values. Devices come from the device resolver's cached
+// list and the other lists are cached the same way, so completing as a user types does not call
+// the API on every keystroke.
+type Completer struct {
+	devices *DeviceResolver
+	tags    *cachedValues
+	users   *cachedValues
+	keys    *cachedValues
+}
+
+// NewCompleter creates a completer backed by client that completes device names from the
+// device list cached by resolver
+func NewCompleter(client internal.TailscaleClient, resolver *DeviceResolver) *Completer {
+	return &Completer{
+		devices: resolver,
+		tags: newCachedValues(func(ctx context.Context) ([]string, error) {
+			acl, err := client.PolicyFile().Get(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get ACL policy: %w", err)
+			}
+
+			tags := make([]string, 0, len(acl.TagOwners))
+			for tag := range acl.TagOwners {
+				tags = append(tags, tag)
+			}
+			return tags, nil
+		}),
+		users: newCachedValues(func(ctx context.Context) ([]string, error) {
+			users, err := client.Users().List(ctx, nil, nil)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list users: %w", err)
+			}
+
+			logins := make([]string, 0, len(users))
+			for _, user := range users {
+				logins = append(logins, user.LoginName)
+			}
+			return logins, nil
+		}),
+		keys: newCachedValues(func(ctx context.Context) ([]string, error) {
+			keys, err := client.Keys().List(ctx, true)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list API keys: %w", err)
+			}
+
+			ids := make([]string, 0, len(keys))
+			for _, key := range keys {
+				ids = append(ids, key.ID)
+			}
+			return ids, nil
+		}),
+	}
+}
+
+// Complete is the server's completion handler
+func (c *Completer) Complete(ctx context.Context, req *mcp.CompleteRequest) (*mcp.CompleteResult, error) {
+	result := &mcp.CompleteResult{Completion: mcp.CompletionResultDetails{Values: []string{}}}
+	if req.Params.Ref == nil {
+		return result, nil
+	}
+
+	name, value := req.Params.Argument.Name, req.Params.Argument.Value
+	var source completionSource
+	switch req.Params.Ref.Type {
+	case "ref/prompt":
+		source = promptArgumentCompletions[name]
+	case "ref/resource":
+		source = resourceTemplateCompletions[req.Params.Ref.URI]
+	}
+	if source == nil {
+		return result, nil
+	}
+
+	// Complete only the element being typed, keeping the ones before it
+	var prefix string
+	if slices.Contains(listArguments, name) {
+		if i := strings.LastIndex(value, ","); i >= 0 {
+			prefix, value = value[:i+1], strings.TrimSpace(value[i+1:])
+		}
+	}
+
+	candidates, err := source(c, ctx, value)
+	if err != nil {
+		return nil, errors.New(outputRedactor.Text(err.Error()))
+	}
+
+	matches := matchCompletions(candidates, value)
+	result.Completion.Total = len(matches)
+	if len(matches) > maxCompletionValues {
+		matches = matches[:maxCompletionValues]
+		result.Completion.HasMore = true
+	}
+	for _, match := range matches {
+		result.Completion.Values = append(result.Completion.Values, prefix+match)
+	}
+	return result, nil
+}
+
+// matchCompletions returns the candidates containing value, ignoring case. Candidates that
+// start with value come first, and each group is sorted.
+func matchCompletions(candidates []string, value string) []string {
+	value = strings.ToLower(value)
+
+	var prefixed, contained []string
+	for _, candidate := range candidates {
+		lower := strings.ToLower(candidate)
+		switch {
+		case strings.HasPrefix(lower, value):
+			prefixed = append(prefixed, candidate)
+		case strings.Contains(lower, value):
+			contained = append(contained, candidate)
+		}
+	}
+
+	slices.Sort(prefixed)
+	slices.Sort(contained)
+	return slices.Compact(append(prefixed, contained...))
+}
+
+// deviceNames lists the short MagicDNS name of every device, which every device argument
+// accepts. Once something has been typed, device IDs that start with it are offered too.
+func (c *Completer) deviceNames(ctx context.Context, value string) ([]string, error) {
+	devices, _, err := c.devices.list(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(devices))
+	for _, device := range devices {
+		if name, _, _ := strings.Cut(device.Name, "."); name != "" {
+			names = append(names, name)
+		}
+		if value != "" {
+			for _, id := range []string{device.ID, device.NodeID} {
+				if id != "" && strings.HasPrefix(strings.ToLower(id), strings.ToLower(value)) {
+					names = append(names, id)
+				}
+			}
+		}
+	}
+	return names, nil
+}
+
+// tagNames lists the tags declared in the policy's tagOwners and any tag a device carries
+func (c *Completer) tagNames(ctx context.Context, value string) ([]string, error) {
+	declared, err := c.tags.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	devices, _, err := c.devices.list(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := slices.Clone(declared)
+	for _, device := range devices {
+		tags = append(tags, device.Tags...)
+	}
+	return tags, nil
+}
+
+// userLogins lists the login name of every user, which every user argument accepts
+func (c *Completer) userLogins(ctx context.Context, value string) ([]string, error) {
+	return c.users.get(ctx)
+}
+
+// keyIDs lists the ID of every key in the tailnet
+func (c *Completer) keyIDs(ctx context.Context, value string) ([]string, error) {
+	return c.keys.get(ctx)
+}
+
+// cachedValues caches a list of completion values for deviceCacheTTL
+type cachedValues struct {
+	fetch func(ctx context.Context) ([]string, error)
+	ttl   time.Duration
+	now   func() time.Time
+
+	mu      sync.Mutex
+	values  []string
+	fetched time.Time
+}
+
+func newCachedValues(fetch func(ctx context.Context) ([]string, error)) *cachedValues {
+	return &cachedValues{
+		fetch: fetch,
+		ttl:   deviceCacheTTL,
+		now:   time.Now,
+	}
+}
+
+// get returns the cached values, fetching them when they are missing or older than the TTL.
+// The lock is not held while fetching, so a slow API call does not hold up completions that
+// can be answered from the cache.
+func (c *cachedValues) get(ctx context.Context) ([]string, error) {
+	c.mu.Lock()
+	now := c.now()
+	values, fetched := c.values, c.fetched
+	c.mu.Unlock()
+
+	if values != nil && now.Sub(fetched) < c.ttl {
+		return values, nil
+	}
+
+	values, err := c.fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Keep a list fetched concurrently since this fetch began
+	if !c.fetched.After(now) {
+		c.values = values
+		c.fetched = now
+	}
+	return values, nil
+}
@@ -0,0 +1,317 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	tailscale "tailscale.com/client/tailscale/v2"
+
+	"github.com/R167/tailscale-mcp/internal"
+)
+
+// completionCalls counts the API list calls made by a completer
+type completionCalls struct {
+	devices, keys int
+}
+
+func completionTestCompleter() (*Completer, *completionCalls) {
+	calls := &completionCalls{}
+	client := &internal.MockTailscaleClient{
+		DevicesFunc: func() internal.DevicesResource {
+			return &internal.MockDevicesResource{
+				ListFunc: func(ctx context.Context) ([]tailscale.Device, error) {
+					calls.devices++
+					devices := testDevices()
+					devices[0].NodeID = "nABC123CNTRL"
+					return devices, nil
+				},
+			}
+		},
+		KeysFunc: func() internal.KeysResource {
+			return &internal.MockKeysResource{
+				ListFunc: func(ctx context.Context, all bool) ([]tailscale.Key, error) {
+					calls.keys++
+					return testKeys(), nil
+				},
+			}
+		},
+		UsersFunc: func() internal.UsersResource {
+			return &internal.MockUsersResource{
+				ListFunc: func(ctx context.Context, userType *tailscale.UserType, role *tailscale.UserRole) ([]tailscale.User, error) {
+					return testUsers(), nil
+				},
+			}
+		},
+		PolicyFileFunc: func() internal.PolicyFileResource {
+			return &internal.MockPolicyFileResource{
+				GetFunc: func(ctx context.Context) (*tailscale.ACL, error) {
+					return &tailscale.ACL{TagOwners: map[string][]string{
+						"tag:prod":    {"group:eng"},
+						"tag:staging": {"group:eng"},
+					}}, nil
+				},
+			}
+		},
+	}
+
+	return NewCompleter(client, NewDeviceResolver(client)), calls
+}
+
+// complete calls the completer the way the server does for a completion/complete request
+func complete(t *testing.T, completer *Completer, ref *mcp.CompleteReference, argument, value string) []string {
+	t.Helper()
+
+	result, err := completer.Complete(context.Background(), &mcp.CompleteRequest{
+		Params: &mcp.CompleteParams{
+			Ref:      ref,
+			Argument: mcp.CompleteParamsArgument{Name: argument, Value: value},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to complete %s: %v", argument, err)
+	}
+	return result.Completion.Values
+}
+
+func TestMatchCompletions(t *testing.T) {
+	candidates := []string{"web-db", "db-2", "DB-1", "laptop", "db-2"}
+
+	if matches := matchCompletions(candidates, "db"); !slices.Equal(matches, []string{"DB-1", "db-2", "web-db"}) {
+		t.Errorf("Expected prefix matches before other matches, got %v", matches)
+	}
+	if matches := matchCompletions(candidates, ""); len(matches) != 4 {
+		t.Errorf("Expected every distinct candidate for an empty value, got %v", matches)
+	}
+}
+
+func TestCompletePromptArguments(t *testing.T) {
+	completer, _ := completionTestCompleter()
+
+	testCases := []struct {
+		name     string
+		prompt   string
+		argument string
+		value    string
+		expected []string
+	}{
+		{name: "Devices", prompt: "troubleshoot_connectivity", argument: "source", value: "db", expected: []string{"db-1", "db-2"}},
+		{name: "DevicesContaining", prompt: "troubleshoot_connectivity", argument: "destination", value: "laptop", expected: []string{"alice-laptop"}},
+		{name: "Tags", prompt: "onboard_device", argument: "tags", value: "tag:", expected: []string{"tag:db", "tag:prod", "tag:staging"}},
+		{name: "TagList", prompt: "onboard_device", argument: "tags", value: "tag:db, tag:s", expected: []string{"tag:db,tag:staging"}},
+		{name: "NotCompleted", prompt: "troubleshoot_connectivity", argument: "port", value: "4", expected: []string{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			values := complete(t, completer, &mcp.CompleteReference{Type: "ref/prompt", Name: tc.prompt}, tc.argument, tc.value)
+			if !slices.Equal(values, tc.expected) {
+				t.Errorf("Expected %v, got %v", tc.expected, values)
+			}
+		})
+	}
+}
+
+func TestCompleteResourceTemplates(t *testing.T) {
+	completer, calls := completionTestCompleter()
+
+	testCases := []struct {
+		name     string
+		uri      string
+		argument string
+		value    string
+		expected []string
+	}{
+		{name: "DeviceNames", uri: "tailscale://devices/{id}", argument: "id", value: "", expected: []string{"alice-laptop", "db-1", "db-2"}},
+		{name: "DeviceID", uri: "tailscale://devices/{id}", argument: "id", value: "nabc", expected: []string{"nABC123CNTRL"}},
+		{name: "KeyIDs", uri: "tailscale://keys/{id}", argument: "id", value: "ex", expected: []string{"expired", "expiring"}},
+		{name: "Users", uri: "tailscale://users/{+user}", argument: "user", value: "al", expected: []string{"alice@example.com"}},
+		{name: "NotCompleted", uri: "tailscale://acl", argument: "id", value: "", expected: []string{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			values := complete(t, completer, &mcp.CompleteReference{Type: "ref/resource", URI: tc.uri}, tc.argument, tc.value)
+			if !slices.Equal(values, tc.expected) {
+				t.Errorf("Expected %v, got %v", tc.expected, values)
+			}
+		})
+	}
+
+	// Completing as a user types reuses the lists fetched above
+	complete(t, completer, &mcp.CompleteReference{Type: "ref/resource", URI: "tailscale://keys/{id}"}, "id", "exp")
+	complete(t, completer, &mcp.CompleteReference{Type: "ref/resource", URI: "tailscale://devices/{id}"}, "id", "db")
+	if calls.devices != 1 || calls.keys != 1 {
+		t.Errorf("Expected each list to be fetched once, got %d device and %d key lists", calls.devices, calls.keys)
+	}
+}
+
+func TestCompleteLimitsValues(t *testing.T) {
+	devices := make([]tailscale.Device, 150)
+	for i := range devices {
+		devices[i] = tailscale.Device{ID: fmt.Sprintf("%d", i), Name: fmt.Sprintf("host-%03d.example.ts.net", i)}
+	}
+	client := &internal.MockTailscaleClient{
+		DevicesFunc: func() internal.DevicesResource {
+			return &internal.MockDevicesResource{
+				ListFunc: func(ctx context.Context) ([]tailscale.Device, error) {
+					return devices, nil
+				},
+			}
+		},
+	}
+	completer := NewCompleter(client, NewDeviceResolver(client))
+
+	result, err := completer.Complete(context.Background(), &mcp.CompleteRequest{
+		Params: &mcp.CompleteParams{
+			Ref:      &mcp.CompleteReference{Type: "ref/resource", URI: "tailscale://devices/{id}"},
+			Argument: mcp.CompleteParamsArgument{Name: "id", Value: "host"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to complete: %v", err)
+	}
+	if len(result.Completion.Values) != maxCompletionValues || result.Completion.Total != 150 || !result.Completion.HasMore {
+		t.Errorf("Expected %d of 150 values with more available, got %d of %d", maxCompletionValues, len(result.Completion.Values), result.Completion.Total)
+	}
+}
+
+func TestCompleteSeesInvalidatedDevices(t *testing.T) {
+	devices := testDevices()
+	client := &internal.MockTailscaleClient{
+		DevicesFunc: func() internal.DevicesResource {
+			return &internal.MockDevicesResource{
+				ListFunc: func(ctx context.Context) ([]tailscale.Device, error) {
+					return slices.Clone(devices), nil
+				},
+			}
+		},
+	}
+	resolver := NewDeviceResolver(client)
+	completer := NewCompleter(client, resolver)
+	ref := &mcp.CompleteReference{Type: "ref/resource", URI: "tailscale://devices/{id}"}
+
+	if values := complete(t, completer, ref, "id", "db-1"); !slices.Equal(values, []string{"db-1"}) {
+		t.Fatalf("Expected db-1, got %v", values)
+	}
+
+	// rename_device invalidates the shared resolver, so the old name is not offered again
+	devices[0].Name = "db-9.example.ts.net"
+	resolver.invalidate()
+	if values := complete(t, completer, ref, "id", "db-"); !slices.Equal(values, []string{"db-2", "db-9"}) {
+		t.Errorf("Expected the renamed device, got %v", values)
+	}
+}
+
+func TestCachedValuesFetchesWithoutLock(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	results := make(chan []string, 2)
+	results <- []string{"slow"}
+	results <- []string{"fast"}
+	cache := newCachedValues(func(ctx context.Context) ([]string, error) {
+		values := <-results
+		if values[0] == "slow" {
+			close(started)
+			<-release
+		}
+		return values, nil
+	})
+	clock := testNow
+	cache.now = func() time.Time {
+		clock = clock.Add(time.Second)
+		return clock
+	}
+
+	slow := make(chan []string)
+	go func() {
+		values, _ := cache.get(context.Background())
+		slow <- values
+	}()
+	<-started
+
+	// A second caller is not held up by the slow fetch
+	fast := make(chan []string)
+	go func() {
+		values, _ := cache.get(context.Background())
+		fast <- values
+	}()
+	select {
+	case values := <-fast:
+		if !slices.Equal(values, []string{"fast"}) {
+			t.Errorf("Expected the second fetch, got %v", values)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected get not to wait for another caller's fetch")
+	}
+
+	close(release)
+	<-slow
+	if values, _ := cache.get(context.Background()); !slices.Equal(values, []string{"fast"}) {
+		t.Errorf("Expected the newer list to stay cached, got %v", values)
+	}
+}
+
+func TestCompleteFetchesDevicesWithoutLock(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	results := make(chan []tailscale.Device, 2)
+	results <- testDevices()[:1]
+	results <- testDevices()
+	client := &internal.MockTailscaleClient{
+		DevicesFunc: func() internal.DevicesResource {
+			return &internal.MockDevicesResource{
+				ListFunc: func(ctx context.Context) ([]tailscale.Device, error) {
+					devices := <-results
+					if len(devices) == 1 {
+						close(started)
+						<-release
+					}
+					return devices, nil
+				},
+			}
+		},
+	}
+	resolver := NewDeviceResolver(client)
+	clock := testNow
+	resolver.now = func() time.Time {
+		clock = clock.Add(time.Second)
+		return clock
+	}
+	completer := NewCompleter(client, resolver)
+	ref := &mcp.CompleteReference{Type: "ref/resource", URI: "tailscale://devices/{id}"}
+
+	request := &mcp.CompleteRequest{
+		Params: &mcp.CompleteParams{Ref: ref, Argument: mcp.CompleteParamsArgument{Name: "id", Value: "db-"}},
+	}
+	slow := make(chan error)
+	go func() {
+		_, err := completer.Complete(context.Background(), request)
+		slow <- err
+	}()
+	<-started
+
+	// A second completion is not held up by the slow device fetch
+	fast := make(chan *mcp.CompleteResult)
+	go func() {
+		result, _ := completer.Complete(context.Background(), request)
+		fast <- result
+	}()
+	select {
+	case result := <-fast:
+		if result == nil || !slices.Equal(result.Completion.Values, []string{"db-1", "db-2"}) {
+			t.Errorf("Expected the second fetch, got %v", result)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected completion not to wait for another completion's device fetch")
+	}
+
+	close(release)
+	if err := <-slow; err != nil {
+		t.Fatalf("Failed to complete: %v", err)
+	}
+	if values := complete(t, completer, ref, "id", "db-"); !slices.Equal(values, []string{"db-1", "db-2"}) {
+		t.Errorf("Expected the newer device list to stay cached, got %v", values)
+	}
+}
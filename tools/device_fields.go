@@ -0,0 +1,130 @@
+package tools
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/jsonschema-go/jsonschema"
+	tailscale "tailscale.com/client/tailscale/v2"
+)
+
+// deviceField is a device attribute that callers can select with the fields argument
+type deviceField struct {
+	name string
+	// allFields is set for attributes the API only returns when all fields are requested
+	allFields bool
+	// value reads the attribute from a device. It is nil for status, which projectDevice
+	// derives from the time and online threshold.
+	value func(device tailscale.Device) any
+}
+
+// deviceFields lists the selectable device attributes in the order they are documented
+var deviceFields = []deviceField{
+	{name: "id", value: func(d tailscale.Device) any { return d.ID }},
+	{name: "nodeId", value: func(d tailscale.Device) any { return d.NodeID }},
+	{name: "name", value: func(d tailscale.Device) any { return d.Name }},
+	{name: "hostname", value: func(d tailscale.Device) any { return d.Hostname }},
+	{name: "addresses", value: func(d tailscale.Device) any { return d.Addresses }},
+	{name: "os", value: func(d tailscale.Device) any { return d.OS }},
+	{name: "user", value: func(d tailscale.Device) any { return d.User }},
+	{name: "tags", value: func(d tailscale.Device) any { return d.Tags }},
+	{name: "status"},
+	{name: "connectedToControl", value: func(d tailscale.Device) any { return d.ConnectedToControl }},
+	{name: "lastSeen", value: func(d tailscale.Device) any { return d.LastSeen }},
+	{name: "created", value: func(d tailscale.Device) any { return d.Created }},
+	{name: "expires", value: func(d tailscale.Device) any { return d.Expires }},
+	{name: "keyExpiryDisabled", value: func(d tailscale.Device) any { return d.KeyExpiryDisabled }},
+	{name: "authorized", value: func(d tailscale.Device) any { return d.Authorized }},
+	{name: "isEphemeral", value: func(d tailscale.Device) any { return d.IsEphemeral }},
+	{name: "isExternal", value: func(d tailscale.Device) any { return d.IsExternal }},
+	{name: "blocksIncomingConnections", value: func(d tailscale.Device) any { return d.BlocksIncomingConnections }},
+	{name: "clientVersion", value: func(d tailscale.Device) any { return d.ClientVersion }},
+	{name: "updateAvailable", value: func(d tailscale.Device) any { return d.UpdateAvailable }},
+	{name: "machineKey", value: func(d tailscale.Device) any { return d.MachineKey }},
+	{name: "nodeKey", value: func(d tailscale.Device) any { return d.NodeKey }},
+	{name: "tailnetLockKey", value: func(d tailscale.Device) any { return d.TailnetLockKey }},
+	{name: "tailnetLockError", value: func(d tailscale.Device) any { return d.TailnetLockError }},
+	{name: "sshEnabled", allFields: true, value: func(d tailscale.Device) any { return d.SSHEnabled }},
+	{name: "advertisedRoutes", allFields: true, value: func(d tailscale.Device) any { return d.AdvertisedRoutes }},
+	{name: "enabledRoutes", allFields: true, value: func(d tailscale.Device) any { return d.EnabledRoutes }},
+	{name: "clientConnectivity", allFields: true, value: func(d tailscale.Device) any { return d.ClientConnectivity }},
+	{name: "distro", allFields: true, value: func(d tailscale.Device) any { return d.Distro }},
+}
+
+// deviceFieldsSchema is the input schema for the fields argument of the device tools
+func deviceFieldsSchema() *jsonschema.Schema {
+	names := make([]any, 0, len(deviceFields))
+	for _, field := range deviceFields {
+		names = append(names, field.name)
+	}
+
+	return &jsonschema.Schema{
+		Type:        "array",
+		Description: "Only return these fields for each device (e.g. [\"name\", \"addresses\", \"clientVersion\", \"advertisedRoutes\"])",
+		Items:       &jsonschema.Schema{Type: "string", Enum: names},
+		MinItems:    jsonschema.Ptr(1),
+	}
+}
+
+// parseDeviceFields reads the optional fields argument, returning nil when it is absent.
+// Unknown fields are rejected and duplicates are dropped.
+func parseDeviceFields(params map[string]any) ([]deviceField, error) {
+	names, err := getOptionalStringSliceParam(params, "fields")
+	if err != nil || names == nil {
+		return nil, err
+	}
+
+	if len(names) == 0 {
+		return nil, fmt.Errorf("fields must not be empty")
+	}
+
+	selected := make([]deviceField, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		field, ok := lookupDeviceField(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown device field %q", name)
+		}
+		selected = append(selected, field)
+	}
+
+	return selected, nil
+}
+
+func lookupDeviceField(name string) (deviceField, bool) {
+	for _, field := range deviceFields {
+		if field.name == name {
+			return field, true
+		}
+	}
+	return deviceField{}, false
+}
+
+// needsAllFields reports whether any of the fields is only populated when devices are
+// fetched with all fields
+func needsAllFields(fields []deviceField) bool {
+	for _, field := range fields {
+		if field.allFields {
+			return true
+		}
+	}
+	return false
+}
+
+// projectDevice returns only the selected fields of a device, keyed by field name
+func projectDevice(device tailscale.Device, fields []deviceField, now time.Time, onlineThreshold time.Duration) map[string]any {
+	projected := make(map[string]any, len(fields))
+	for _, field := range fields {
+		if field.value == nil {
+			projected[field.name] = deviceStatus(device, now, onlineThreshold)
+			continue
+		}
+		projected[field.name] = field.value(device)
+	}
+	return projected
+}
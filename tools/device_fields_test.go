@@ -0,0 +1,87 @@
+package tools
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+
+	tailscale "tailscale.com/client/tailscale/v2"
+)
+
+func TestParseDeviceFields(t *testing.T) {
+	fields, err := parseDeviceFields(map[string]any{})
+	if err != nil || fields != nil {
+		t.Fatalf("Expected no fields when absent, got %v, %v", fields, err)
+	}
+
+	fields, err = parseDeviceFields(map[string]any{"fields": []any{"name", "advertisedRoutes", "name"}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(fields) != 2 || fields[0].name != "name" || fields[1].name != "advertisedRoutes" {
+		t.Errorf("Unexpected fields %+v", fields)
+	}
+	if !needsAllFields(fields) {
+		t.Error("Expected advertisedRoutes to require all fields")
+	}
+	if needsAllFields(fields[:1]) {
+		t.Error("Expected name not to require all fields")
+	}
+
+	for _, params := range []map[string]any{
+		{"fields": []any{}},
+		{"fields": []any{"color"}},
+		{"fields": "name"},
+		{"fields": []any{1}},
+	} {
+		if _, err := parseDeviceFields(params); err == nil {
+			t.Errorf("Expected error for %v", params)
+		}
+	}
+}
+
+func TestDeviceFieldsSchema(t *testing.T) {
+	schema := deviceFieldsSchema()
+
+	var names []string
+	for _, name := range schema.Items.Enum {
+		names = append(names, name.(string))
+	}
+	for _, expected := range []string{"name", "addresses", "clientVersion", "advertisedRoutes", "status"} {
+		if !slices.Contains(names, expected) {
+			t.Errorf("Expected field enum to contain %s, got %v", expected, names)
+		}
+	}
+	if len(names) != len(deviceFields) {
+		t.Errorf("Expected %d fields in enum, got %d", len(deviceFields), len(names))
+	}
+}
+
+func TestProjectDevice(t *testing.T) {
+	device := tailscale.Device{
+		ID:               "1",
+		Name:             "db-1.example.ts.net",
+		Addresses:        []string{"100.64.0.1"},
+		ClientVersion:    "1.80.0",
+		AdvertisedRoutes: []string{"10.0.0.0/24"},
+		LastSeen:         &tailscale.Time{Time: testNow.Add(-time.Minute)},
+	}
+
+	fields, err := parseDeviceFields(map[string]any{"fields": []any{"name", "clientVersion", "advertisedRoutes", "status"}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	projected := projectDevice(device, fields, testNow, defaultOnlineThreshold)
+
+	output, err := json.Marshal(projected)
+	if err != nil {
+		t.Fatalf("Failed to marshal projection: %v", err)
+	}
+
+	expected := `{"advertisedRoutes":["10.0.0.0/24"],"clientVersion":"1.80.0","name":"db-1.example.ts.net","status":"online"}`
+	if string(output) != expected {
+		t.Errorf("Expected %s, got %s", expected, output)
+	}
+}
@@ -0,0 +1,219 @@
+package tools
+
+import (
+	"cmp"
+	"fmt"
+	"net/netip"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	tailscale "tailscale.com/client/tailscale/v2"
+)
+
+// deviceSortFields lists the fields list_devices can sort by
+var deviceSortFields = []any{"name", "hostname", "os", "user", "lastSeen", "created"}
+
+// deviceFilter holds the criteria used to select devices. Zero values match every device.
+type deviceFilter struct {
+	// Tags that a device must all carry; the "tag:" prefix is optional
+	Tags []string
+	// OS matches the device operating system, case-insensitively
+	OS string
+	// User matches a substring of the owning user's login name, case-insensitively
+	User string
+	// Status is "online" or "offline"
+	Status string
+	// OnlineThreshold is how recently a device not connected to control must have been seen
+	// to count as online
+	OnlineThreshold time.Duration
+	// Name is a substring or glob matched against the MagicDNS name, short name, and hostname
+	Name string
+	// Address is an IP address the device must have, or a CIDR containing one of its addresses
+	Address string
+	// UpdateAvailable, if set, matches devices by whether a client update is available
+	UpdateAvailable *bool
+}
+
+// parseDeviceFilter builds a deviceFilter from list_devices tool arguments
+func parseDeviceFilter(params map[string]any) (deviceFilter, error) {
+	var filter deviceFilter
+	var err error
+
+	if filter.Tags, err = getOptionalStringSliceParam(params, "tags"); err != nil {
+		return filter, err
+	}
+	if filter.OS, err = getOptionalStringParam(params, "os"); err != nil {
+		return filter, err
+	}
+	if filter.User, err = getOptionalStringParam(params, "user"); err != nil {
+		return filter, err
+	}
+	if filter.Status, err = getOptionalStringParam(params, "status"); err != nil {
+		return filter, err
+	}
+	if filter.Name, err = getOptionalStringParam(params, "name"); err != nil {
+		return filter, err
+	}
+	if filter.Address, err = getOptionalStringParam(params, "address"); err != nil {
+		return filter, err
+	}
+	if filter.UpdateAvailable, err = getOptionalBoolParam(params, "update_available"); err != nil {
+		return filter, err
+	}
+
+	thresholdMinutes, err := getOptionalIntParam(params, "online_threshold_minutes", int(defaultOnlineThreshold/time.Minute))
+	if err != nil {
+		return filter, err
+	}
+	if thresholdMinutes < 0 || thresholdMinutes > maxOnlineThresholdMinutes {
+		return filter, fmt.Errorf("online_threshold_minutes must be between 0 and %d", maxOnlineThresholdMinutes)
+	}
+	filter.OnlineThreshold = time.Duration(thresholdMinutes) * time.Minute
+
+	if filter.Status != "" && filter.Status != "online" && filter.Status != "offline" {
+		return filter, fmt.Errorf("status must be online or offline")
+	}
+
+	if filter.Name != "" {
+		if _, err := path.Match(strings.ToLower(filter.Name), ""); err != nil {
+			return filter, fmt.Errorf("invalid name pattern %q: %w", filter.Name, err)
+		}
+	}
+
+	if filter.Address != "" {
+		if _, err := parseAddressFilter(filter.Address); err != nil {
+			return filter, err
+		}
+	}
+
+	return filter, nil
+}
+
+// filterDevices returns the devices that match every criterion in filter, evaluating
+// online status as of now
+func filterDevices(devices []tailscale.Device, filter deviceFilter, now time.Time) []tailscale.Device {
+	var prefix netip.Prefix
+	if filter.Address != "" {
+		// parseDeviceFilter has already validated the address
+		prefix, _ = parseAddressFilter(filter.Address)
+	}
+
+	var matched []tailscale.Device
+	for _, device := range devices {
+		if !deviceHasTags(device, filter.Tags) {
+			continue
+		}
+		if filter.OS != "" && !strings.EqualFold(device.OS, filter.OS) {
+			continue
+		}
+		if filter.User != "" && !strings.Contains(strings.ToLower(device.User), strings.ToLower(filter.User)) {
+			continue
+		}
+		if filter.Status != "" && deviceStatus(device, now, filter.OnlineThreshold) != filter.Status {
+			continue
+		}
+		if filter.Name != "" && !deviceNameMatches(device, filter.Name) {
+			continue
+		}
+		if prefix.IsValid() && !deviceInPrefix(device, prefix) {
+			continue
+		}
+		if filter.UpdateAvailable != nil && device.UpdateAvailable != *filter.UpdateAvailable {
+			continue
+		}
+		matched = append(matched, device)
+	}
+
+	return matched
+}
+
+// sortDevices sorts devices in place by the named field
+func sortDevices(devices []tailscale.Device, sortBy string, descending bool) error {
+	var compare func(a, b tailscale.Device) int
+	switch sortBy {
+	case "", "name":
+		compare = func(a, b tailscale.Device) int { return cmp.Compare(a.Name, b.Name) }
+	case "hostname":
+		compare = func(a, b tailscale.Device) int { return cmp.Compare(a.Hostname, b.Hostname) }
+	case "os":
+		compare = func(a, b tailscale.Device) int { return cmp.Compare(a.OS, b.OS) }
+	case "user":
+		compare = func(a, b tailscale.Device) int { return cmp.Compare(a.User, b.User) }
+	case "lastSeen":
+		compare = func(a, b tailscale.Device) int { return deviceLastSeen(a).Compare(deviceLastSeen(b)) }
+	case "created":
+		compare = func(a, b tailscale.Device) int { return a.Created.Compare(b.Created.Time) }
+	default:
+		return fmt.Errorf("cannot sort by %q", sortBy)
+	}
+
+	slices.SortStableFunc(devices, func(a, b tailscale.Device) int {
+		if descending {
+			return compare(b, a)
+		}
+		return compare(a, b)
+	})
+
+	return nil
+}
+
+// parseAddressFilter parses an IP address or CIDR into a prefix. A bare address becomes a
+// single-address prefix.
+func parseAddressFilter(address string) (netip.Prefix, error) {
+	if strings.Contains(address, "/") {
+		prefix, err := netip.ParsePrefix(address)
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("invalid address filter %q: %w", address, err)
+		}
+		return prefix.Masked(), nil
+	}
+
+	addr, err := netip.ParseAddr(address)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("invalid address filter %q: %w", address, err)
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// deviceHasTags reports whether the device carries every tag
+func deviceHasTags(device tailscale.Device, tags []string) bool {
+	for _, tag := range tags {
+		if !strings.HasPrefix(tag, "tag:") {
+			tag = "tag:" + tag
+		}
+		if !slices.Contains(device.Tags, tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// deviceNameMatches matches a substring or glob pattern against the device's names
+func deviceNameMatches(device tailscale.Device, pattern string) bool {
+	pattern = strings.ToLower(pattern)
+	isGlob := strings.ContainsAny(pattern, "*?[")
+
+	for _, name := range []string{device.Name, shortDeviceName(device.Name), device.Hostname} {
+		name = strings.ToLower(name)
+		if isGlob {
+			if ok, _ := path.Match(pattern, name); ok {
+				return true
+			}
+		} else if strings.Contains(name, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// deviceInPrefix reports whether any of the device's addresses fall within prefix
+func deviceInPrefix(device tailscale.Device, prefix netip.Prefix) bool {
+	for _, address := range device.Addresses {
+		if addr, err := netip.ParseAddr(address); err == nil && prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
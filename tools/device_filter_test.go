@@ -0,0 +1,163 @@
+package tools
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	tailscale "tailscale.com/client/tailscale/v2"
+)
+
+// testNow is the fixed clock used by device tests
+var testNow = time.Date(2025, 8, 1, 12, 0, 0, 0, time.UTC)
+
+func testDevices() []tailscale.Device {
+	now := testNow
+	return []tailscale.Device{
+		{
+			ID:        "1",
+			Name:      "db-1.example.ts.net",
+			Hostname:  "db-1",
+			Addresses: []string{"100.101.2.3", "fd7a:115c:a1e0::1"},
+			OS:        "linux",
+			User:      "alice@example.com",
+			Tags:      []string{"tag:prod", "tag:db"},
+			Created:   tailscale.Time{Time: now.Add(-48 * time.Hour)},
+
+			ConnectedToControl: true,
+		},
+		{
+			ID:              "2",
+			Name:            "db-2.example.ts.net",
+			Hostname:        "db-2",
+			Addresses:       []string{"100.101.2.4"},
+			OS:              "linux",
+			User:            "bob@example.com",
+			Tags:            []string{"tag:db"},
+			UpdateAvailable: true,
+			Created:         tailscale.Time{Time: now.Add(-24 * time.Hour)},
+		},
+		{
+			ID:        "3",
+			Name:      "alice-laptop.example.ts.net",
+			Hostname:  "Alice's MacBook",
+			Addresses: []string{"100.64.0.5"},
+			OS:        "macOS",
+			User:      "alice@example.com",
+			LastSeen:  &tailscale.Time{Time: now.Add(-time.Hour)},
+			Created:   tailscale.Time{Time: now.Add(-72 * time.Hour)},
+		},
+	}
+}
+
+func deviceIDs(devices []tailscale.Device) []string {
+	ids := make([]string, 0, len(devices))
+	for _, device := range devices {
+		ids = append(ids, device.ID)
+	}
+	return ids
+}
+
+func TestFilterDevices(t *testing.T) {
+	yes := true
+
+	testCases := []struct {
+		name     string
+		filter   deviceFilter
+		expected []string
+	}{
+		{name: "NoFilter", filter: deviceFilter{}, expected: []string{"1", "2", "3"}},
+		{name: "Tag", filter: deviceFilter{Tags: []string{"tag:db"}}, expected: []string{"1", "2"}},
+		{name: "TagWithoutPrefix", filter: deviceFilter{Tags: []string{"prod"}}, expected: []string{"1"}},
+		{name: "AllTags", filter: deviceFilter{Tags: []string{"tag:db", "tag:prod"}}, expected: []string{"1"}},
+		{name: "OSCaseInsensitive", filter: deviceFilter{OS: "MACOS"}, expected: []string{"3"}},
+		{name: "User", filter: deviceFilter{User: "alice"}, expected: []string{"1", "3"}},
+		{name: "Online", filter: deviceFilter{Status: "online"}, expected: []string{"1"}},
+		{name: "Offline", filter: deviceFilter{Status: "offline"}, expected: []string{"2", "3"}},
+		{name: "OnlineWithinThreshold", filter: deviceFilter{Status: "online", OnlineThreshold: 2 * time.Hour}, expected: []string{"1", "3"}},
+		{name: "NameSubstring", filter: deviceFilter{Name: "laptop"}, expected: []string{"3"}},
+		{name: "NameGlob", filter: deviceFilter{Name: "db-*"}, expected: []string{"1", "2"}},
+		{name: "HostnameSubstring", filter: deviceFilter{Name: "macbook"}, expected: []string{"3"}},
+		{name: "ExactIP", filter: deviceFilter{Address: "100.101.2.4"}, expected: []string{"2"}},
+		{name: "IPv6", filter: deviceFilter{Address: "fd7a:115c:a1e0::1"}, expected: []string{"1"}},
+		{name: "CIDR", filter: deviceFilter{Address: "100.101.0.0/16"}, expected: []string{"1", "2"}},
+		{name: "UpdateAvailable", filter: deviceFilter{UpdateAvailable: &yes}, expected: []string{"2"}},
+		{name: "Combined", filter: deviceFilter{Tags: []string{"tag:db"}, User: "bob", OS: "linux"}, expected: []string{"2"}},
+		{name: "NoMatch", filter: deviceFilter{OS: "windows"}, expected: []string{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := deviceIDs(filterDevices(testDevices(), tc.filter, testNow))
+			if !slices.Equal(got, tc.expected) {
+				t.Errorf("Expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestParseDeviceFilter(t *testing.T) {
+	filter, err := parseDeviceFilter(map[string]any{
+		"tags":             []any{"tag:db"},
+		"os":               "linux",
+		"status":           "online",
+		"address":          "100.64.0.0/10",
+		"update_available": false,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if filter.OS != "linux" || filter.Status != "online" || filter.UpdateAvailable == nil || *filter.UpdateAvailable {
+		t.Errorf("Unexpected filter %+v", filter)
+	}
+	if filter.OnlineThreshold != defaultOnlineThreshold {
+		t.Errorf("Expected default online threshold, got %v", filter.OnlineThreshold)
+	}
+
+	invalid := []map[string]any{
+		{"status": "sleeping"},
+		{"address": "not-an-ip"},
+		{"address": "100.64.0.0/99"},
+		{"name": "db-["},
+		{"tags": "tag:db"},
+		{"update_available": "yes"},
+		{"online_threshold_minutes": -1},
+		{"online_threshold_minutes": maxOnlineThresholdMinutes + 1},
+	}
+	for _, params := range invalid {
+		if _, err := parseDeviceFilter(params); err == nil {
+			t.Errorf("Expected error for %v", params)
+		}
+	}
+}
+
+func TestSortDevices(t *testing.T) {
+	testCases := []struct {
+		sortBy     string
+		descending bool
+		expected   []string
+	}{
+		{sortBy: "", expected: []string{"3", "1", "2"}},
+		{sortBy: "name", descending: true, expected: []string{"2", "1", "3"}},
+		{sortBy: "hostname", expected: []string{"3", "1", "2"}},
+		{sortBy: "lastSeen", descending: true, expected: []string{"3", "1", "2"}},
+		{sortBy: "created", expected: []string{"3", "1", "2"}},
+		{sortBy: "user", expected: []string{"1", "3", "2"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.sortBy, func(t *testing.T) {
+			devices := testDevices()
+			if err := sortDevices(devices, tc.sortBy, tc.descending); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if got := deviceIDs(devices); !slices.Equal(got, tc.expected) {
+				t.Errorf("Expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+
+	if err := sortDevices(testDevices(), "color", false); err == nil {
+		t.Error("Expected error for unknown sort field")
+	}
+}
@@ -0,0 +1,195 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+
+	tailscale "tailscale.com/client/tailscale/v2"
+
+	"github.com/R167/tailscale-mcp/internal"
+)
+
+// deviceCacheTTL is how long the device resolver reuses a device list before fetching it again
+const deviceCacheTTL = 30 * time.Second
+
+// DeviceResolver turns the device references users naturally give (device IDs, MagicDNS
+// names, hostnames, and Tailscale IP addresses) into device IDs. It keeps a cached copy
+// of the device list so that resolving a device does not cost an API call per lookup.
+// The server shares one resolver between the tools, resources, and completions that look
+// devices up, so that a rename or delete invalidates the cached list everywhere.
+type DeviceResolver struct {
+	client internal.TailscaleClient
+	ttl    time.Duration
+	now    func() time.Time
+
+	mu          sync.Mutex
+	devices     []tailscale.Device
+	fetched     time.Time
+	invalidated int // counts invalidations, so fetches begun before one are not cached
+}
+
+// NewDeviceResolver creates a resolver that lists devices with client
+func NewDeviceResolver(client internal.TailscaleClient) *DeviceResolver {
+	return &DeviceResolver{
+		client: client,
+		ttl:    deviceCacheTTL,
+		now:    time.Now,
+	}
+}
+
+// ambiguousDeviceError is returned when a reference matches more than one device
+type ambiguousDeviceError struct {
+	ref        string
+	candidates []tailscale.Device
+}
+
+func (e *ambiguousDeviceError) Error() string {
+	candidates := make([]string, 0, len(e.candidates))
+	for _, device := range e.candidates {
+		candidates = append(candidates, fmt.Sprintf("%s (id %s, %s)", device.Name, device.ID, strings.Join(device.Addresses, ", ")))
+	}
+	return fmt.Sprintf("%q matches %d devices, use a device ID or full MagicDNS name instead: %s",
+		e.ref, len(e.candidates), strings.Join(candidates, "; "))
+}
+
+// resolve returns the ID of the device that ref refers to. A reference that matches no
+// known device is passed through unchanged if it looks like a device ID, so that
+// devices added since the list was fetched can still be addressed.
+func (r *DeviceResolver) resolve(ctx context.Context, ref string) (string, error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return "", fmt.Errorf("device cannot be empty")
+	}
+
+	devices, refreshed, err := r.list(ctx, false)
+	if err != nil {
+		return "", err
+	}
+
+	matches := matchDevices(devices, ref)
+	if len(matches) == 0 && !refreshed {
+		// The device may have joined since the list was cached
+		if devices, _, err = r.list(ctx, true); err != nil {
+			return "", err
+		}
+		matches = matchDevices(devices, ref)
+	}
+
+	switch len(matches) {
+	case 1:
+		return matches[0].ID, nil
+	case 0:
+		if looksLikeDeviceID(ref) {
+			return ref, nil
+		}
+		return "", fmt.Errorf("no device matches %q by ID, name, hostname, or Tailscale IP", ref)
+	default:
+		return "", &ambiguousDeviceError{ref: ref, candidates: matches}
+	}
+}
+
+// looksLikeDeviceID reports whether ref could be a device ID rather than a name or address
+func looksLikeDeviceID(ref string) bool {
+	if _, err := netip.ParseAddr(ref); err == nil {
+		return false
+	}
+	return !strings.Contains(ref, ".") && validateDeviceID(ref) == nil
+}
+
+// invalidate drops the cached device list, for use after a device is renamed or deleted
+func (r *DeviceResolver) invalidate() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.devices = nil
+	r.fetched = time.Time{}
+	r.invalidated++
+}
+
+// list returns the cached device list, fetching it when it is missing, older than the TTL,
+// or force is set. It also reports whether the list was just fetched. The lock is not held
+// while fetching, so a slow API call does not hold up other lookups.
+func (r *DeviceResolver) list(ctx context.Context, force bool) ([]tailscale.Device, bool, error) {
+	r.mu.Lock()
+	now := r.now()
+	devices, fetched, invalidated := r.devices, r.fetched, r.invalidated
+	r.mu.Unlock()
+
+	if !force && devices != nil && now.Sub(fetched) < r.ttl {
+		return devices, false, nil
+	}
+
+	devices, err := r.client.Devices().List(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to list devices: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Keep a list fetched concurrently since this fetch began, and drop this one if the
+	// cache was invalidated while it was in flight
+	if !r.fetched.After(now) && r.invalidated == invalidated {
+		r.devices = devices
+		r.fetched = now
+	}
+	return devices, true, nil
+}
+
+// findDevice returns the single device in devices that ref identifies
+func findDevice(devices []tailscale.Device, ref string) (*tailscale.Device, error) {
+	matches := matchDevices(devices, strings.TrimSpace(ref))
+	switch len(matches) {
+	case 1:
+		return &matches[0], nil
+	case 0:
+		return nil, fmt.Errorf("no device matches %q by ID, name, hostname, or Tailscale IP", ref)
+	default:
+		return nil, &ambiguousDeviceError{ref: ref, candidates: matches}
+	}
+}
+
+// matchDevices returns the devices that ref identifies. Device IDs, full MagicDNS names, and
+// Tailscale IP addresses identify a device exactly and take precedence over short names and
+// hostnames, which may match several devices.
+func matchDevices(devices []tailscale.Device, ref string) []tailscale.Device {
+	for _, device := range devices {
+		if device.ID == ref || device.NodeID == ref {
+			return []tailscale.Device{device}
+		}
+	}
+
+	name := strings.ToLower(strings.TrimSuffix(ref, "."))
+	if strings.Contains(name, ".") {
+		for _, device := range devices {
+			if strings.EqualFold(strings.TrimSuffix(device.Name, "."), name) {
+				return []tailscale.Device{device}
+			}
+		}
+	}
+
+	if addr, err := netip.ParseAddr(ref); err == nil {
+		var matches []tailscale.Device
+		for _, device := range devices {
+			for _, address := range device.Addresses {
+				if deviceAddr, err := netip.ParseAddr(address); err == nil && deviceAddr == addr.Unmap() {
+					matches = append(matches, device)
+					break
+				}
+			}
+		}
+		return matches
+	}
+
+	var matches []tailscale.Device
+	for _, device := range devices {
+		if strings.EqualFold(shortDeviceName(device.Name), name) || strings.EqualFold(device.Hostname, name) {
+			matches = append(matches, device)
+		}
+	}
+	return matches
+}
@@ -0,0 +1,203 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	tailscale "tailscale.com/client/tailscale/v2"
+
+	"github.com/R167/tailscale-mcp/internal"
+)
+
+func TestMatchDevices(t *testing.T) {
+	devices := testDevices()
+	devices = append(devices, tailscale.Device{
+		ID:        "4",
+		NodeID:    "nABC123CNTRL",
+		Name:      "db-1.other.ts.net",
+		Hostname:  "db-1",
+		Addresses: []string{"100.101.9.9"},
+	})
+
+	testCases := []struct {
+		name     string
+		ref      string
+		expected []string
+	}{
+		{name: "ID", ref: "2", expected: []string{"2"}},
+		{name: "NodeID", ref: "nABC123CNTRL", expected: []string{"4"}},
+		{name: "MagicDNSName", ref: "db-1.example.ts.net", expected: []string{"1"}},
+		{name: "MagicDNSNameTrailingDot", ref: "DB-1.example.ts.net.", expected: []string{"1"}},
+		{name: "IPv4", ref: "100.101.2.4", expected: []string{"2"}},
+		{name: "IPv6", ref: "fd7a:115c:a1e0:0::1", expected: []string{"1"}},
+		{name: "ShortName", ref: "alice-laptop", expected: []string{"3"}},
+		{name: "Hostname", ref: "alice's macbook", expected: []string{"3"}},
+		{name: "AmbiguousHostname", ref: "db-1", expected: []string{"1", "4"}},
+		{name: "UnknownIP", ref: "100.100.100.100", expected: []string{}},
+		{name: "Unknown", ref: "web-1", expected: []string{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := deviceIDs(matchDevices(devices, tc.ref)); !slices.Equal(got, tc.expected) {
+				t.Errorf("Expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+// countingClient returns a mock client whose device list is served by list and counts the calls
+func countingClient(list func() ([]tailscale.Device, error), calls *int) *internal.MockTailscaleClient {
+	return &internal.MockTailscaleClient{
+		DevicesFunc: func() internal.DevicesResource {
+			return &internal.MockDevicesResource{
+				ListFunc: func(ctx context.Context) ([]tailscale.Device, error) {
+					*calls++
+					return list()
+				},
+			}
+		},
+	}
+}
+
+func TestDeviceResolverResolve(t *testing.T) {
+	var calls int
+	resolver := NewDeviceResolver(countingClient(func() ([]tailscale.Device, error) { return testDevices(), nil }, &calls))
+
+	for ref, expected := range map[string]string{
+		"1":                   "1",
+		"db-2":                "2",
+		"100.64.0.5":          "3",
+		"db-1.example.ts.net": "1",
+	} {
+		id, err := resolver.resolve(context.Background(), ref)
+		if err != nil {
+			t.Fatalf("Unexpected error resolving %q: %v", ref, err)
+		}
+		if id != expected {
+			t.Errorf("Expected %q to resolve to %s, got %s", ref, expected, id)
+		}
+	}
+
+	if calls != 1 {
+		t.Errorf("Expected the device list to be fetched once, got %d", calls)
+	}
+
+	// Unknown but well-formed IDs are passed through after refreshing the list once
+	id, err := resolver.resolve(context.Background(), "nNEW456CNTRL")
+	if err != nil || id != "nNEW456CNTRL" {
+		t.Errorf("Expected unknown ID to pass through, got %q, %v", id, err)
+	}
+	if calls != 2 {
+		t.Errorf("Expected a refresh for an unknown reference, got %d calls", calls)
+	}
+
+	if _, err := resolver.resolve(context.Background(), "100.100.100.100 "); err == nil {
+		t.Error("Expected error for an unknown IP address")
+	}
+	if _, err := resolver.resolve(context.Background(), " "); err == nil {
+		t.Error("Expected error for an empty reference")
+	}
+}
+
+func TestDeviceResolverAmbiguous(t *testing.T) {
+	var calls int
+	devices := append(testDevices(), tailscale.Device{ID: "4", Name: "db-1.other.ts.net", Addresses: []string{"100.101.9.9"}})
+	resolver := NewDeviceResolver(countingClient(func() ([]tailscale.Device, error) { return devices, nil }, &calls))
+
+	_, err := resolver.resolve(context.Background(), "db-1")
+
+	var ambiguous *ambiguousDeviceError
+	if !errors.As(err, &ambiguous) {
+		t.Fatalf("Expected an ambiguity error, got %v", err)
+	}
+	if len(ambiguous.candidates) != 2 {
+		t.Errorf("Expected 2 candidates, got %+v", ambiguous.candidates)
+	}
+	for _, candidate := range []string{"db-1.example.ts.net", "db-1.other.ts.net", "100.101.9.9"} {
+		if !strings.Contains(err.Error(), candidate) {
+			t.Errorf("Expected error to mention %s, got %v", candidate, err)
+		}
+	}
+}
+
+func TestDeviceResolverCache(t *testing.T) {
+	var calls int
+	now := testNow
+	resolver := NewDeviceResolver(countingClient(func() ([]tailscale.Device, error) { return testDevices(), nil }, &calls))
+	resolver.now = func() time.Time { return now }
+
+	resolve := func(ref string) {
+		t.Helper()
+		if _, err := resolver.resolve(context.Background(), ref); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	resolve("db-1")
+	now = now.Add(deviceCacheTTL - time.Second)
+	resolve("db-2")
+	if calls != 1 {
+		t.Errorf("Expected cached list within the TTL, got %d calls", calls)
+	}
+
+	now = now.Add(2 * time.Second)
+	resolve("db-1")
+	if calls != 2 {
+		t.Errorf("Expected refresh after the TTL, got %d calls", calls)
+	}
+
+	resolver.invalidate()
+	resolve("db-1")
+	if calls != 3 {
+		t.Errorf("Expected refresh after invalidation, got %d calls", calls)
+	}
+}
+
+func TestDeviceResolverInvalidateDuringFetch(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	var calls int
+	resolver := NewDeviceResolver(countingClient(func() ([]tailscale.Device, error) {
+		if calls == 1 {
+			close(started)
+			<-release
+		}
+		return testDevices(), nil
+	}, &calls))
+
+	done := make(chan error)
+	go func() {
+		_, err := resolver.resolve(context.Background(), "db-1")
+		done <- err
+	}()
+	<-started
+	resolver.invalidate()
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The list fetched before the invalidation is not reused
+	if _, err := resolver.resolve(context.Background(), "db-1"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("Expected a fresh fetch after invalidation, got %d calls", calls)
+	}
+}
+
+func TestDeviceResolverListError(t *testing.T) {
+	var calls int
+	resolver := NewDeviceResolver(countingClient(func() ([]tailscale.Device, error) {
+		return nil, fmt.Errorf("unauthorized")
+	}, &calls))
+
+	if _, err := resolver.resolve(context.Background(), "db-1"); err == nil || !strings.Contains(err.Error(), "unauthorized") {
+		t.Errorf("Expected list error, got %v", err)
+	}
+}
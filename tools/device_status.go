@@ -0,0 +1,107 @@
+package tools
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	tailscale "tailscale.com/client/tailscale/v2"
+)
+
+const (
+	// defaultOnlineThreshold is how recently a device must have been seen to count as online
+	// when it is not reported as connected to the control plane
+	defaultOnlineThreshold = 5 * time.Minute
+	// defaultStaleDays is the number of days without contact after which stale_devices reports a device
+	defaultStaleDays = 30
+	// maxDays is the largest number of days a tool accepts, keeping day and minute counts well
+	// within the range of a time.Duration
+	maxDays = 3650
+	// maxOnlineThresholdMinutes is the largest online threshold list_devices accepts
+	maxOnlineThresholdMinutes = maxDays * 24 * 60
+)
+
+// deviceStatus reports whether a device is "online" or "offline" as of now. Devices connected
+// to the control plane are online. Otherwise a device is online only if it was last seen
+// within threshold; devices that have never been seen are offline.
+func deviceStatus(device tailscale.Device, now time.Time, threshold time.Duration) string {
+	if device.ConnectedToControl {
+		return "online"
+	}
+
+	lastSeen := deviceLastSeen(device)
+	if lastSeen.IsZero() {
+		return "offline"
+	}
+
+	if now.Sub(lastSeen) <= threshold {
+		return "online"
+	}
+	return "offline"
+}
+
+// deviceLastSeen returns when the device was last seen, or the zero time if it was never
+// seen or is currently connected
+func deviceLastSeen(device tailscale.Device) time.Time {
+	if device.LastSeen == nil {
+		return time.Time{}
+	}
+	return device.LastSeen.Time
+}
+
+// StaleDevice describes a device that has not been seen for a while, returned by stale_devices
+type StaleDevice struct {
+	ID                string   `json:"id"`
+	Name              string   `json:"name"`
+	User              string   `json:"user"`
+	Tags              []string `json:"tags,omitempty"`
+	OS                string   `json:"os"`
+	LastSeen          string   `json:"lastSeen"`
+	DaysSinceSeen     int      `json:"daysSinceSeen,omitempty"`
+	KeyExpiryDisabled bool     `json:"keyExpiryDisabled"`
+	KeyExpires        string   `json:"keyExpires,omitempty"`
+	IsEphemeral       bool     `json:"isEphemeral,omitempty"`
+}
+
+// findStaleDevices returns devices that are not connected and have not been seen within
+// maxAge of now, oldest first. Devices that have never been seen are listed first.
+func findStaleDevices(devices []tailscale.Device, now time.Time, maxAge time.Duration) []StaleDevice {
+	var stale []tailscale.Device
+	for _, device := range devices {
+		if device.ConnectedToControl {
+			continue
+		}
+		lastSeen := deviceLastSeen(device)
+		if lastSeen.IsZero() || now.Sub(lastSeen) > maxAge {
+			stale = append(stale, device)
+		}
+	}
+
+	slices.SortStableFunc(stale, func(a, b tailscale.Device) int {
+		return cmp.Or(deviceLastSeen(a).Compare(deviceLastSeen(b)), cmp.Compare(a.Name, b.Name))
+	})
+
+	result := make([]StaleDevice, 0, len(stale))
+	for _, device := range stale {
+		entry := StaleDevice{
+			ID:                device.ID,
+			Name:              device.Name,
+			User:              device.User,
+			Tags:              device.Tags,
+			OS:                device.OS,
+			LastSeen:          "never",
+			KeyExpiryDisabled: device.KeyExpiryDisabled,
+			IsEphemeral:       device.IsEphemeral,
+		}
+		if lastSeen := deviceLastSeen(device); !lastSeen.IsZero() {
+			entry.LastSeen = lastSeen.Format(time.RFC3339)
+			entry.DaysSinceSeen = int(now.Sub(lastSeen).Hours() / 24)
+		}
+		if !device.KeyExpiryDisabled && !device.Expires.IsZero() {
+			entry.KeyExpires = device.Expires.Format(time.RFC3339)
+		}
+		result = append(result, entry)
+	}
+
+	return result
+}
@@ -0,0 +1,122 @@
+package tools
+
+import (
+	"testing"
+	"time"
+
+	tailscale "tailscale.com/client/tailscale/v2"
+)
+
+func TestDeviceStatus(t *testing.T) {
+	seen := func(ago time.Duration) *tailscale.Time {
+		return &tailscale.Time{Time: testNow.Add(-ago)}
+	}
+
+	testCases := []struct {
+		name      string
+		device    tailscale.Device
+		threshold time.Duration
+		expected  string
+	}{
+		{
+			name:     "ConnectedToControl",
+			device:   tailscale.Device{ConnectedToControl: true},
+			expected: "online",
+		},
+		{
+			name:      "ConnectedIgnoresStaleLastSeen",
+			device:    tailscale.Device{ConnectedToControl: true, LastSeen: seen(90 * 24 * time.Hour)},
+			threshold: time.Minute,
+			expected:  "online",
+		},
+		{
+			name:      "NeverSeen",
+			device:    tailscale.Device{},
+			threshold: time.Hour,
+			expected:  "offline",
+		},
+		{
+			name:      "ZeroLastSeen",
+			device:    tailscale.Device{LastSeen: &tailscale.Time{}},
+			threshold: time.Hour,
+			expected:  "offline",
+		},
+		{
+			name:      "SeenWithinThreshold",
+			device:    tailscale.Device{LastSeen: seen(2 * time.Minute)},
+			threshold: 5 * time.Minute,
+			expected:  "online",
+		},
+		{
+			name:      "SeenAtThreshold",
+			device:    tailscale.Device{LastSeen: seen(5 * time.Minute)},
+			threshold: 5 * time.Minute,
+			expected:  "online",
+		},
+		{
+			name:      "SeenBeforeThreshold",
+			device:    tailscale.Device{LastSeen: seen(6 * time.Minute)},
+			threshold: 5 * time.Minute,
+			expected:  "offline",
+		},
+		{
+			name:      "ZeroThreshold",
+			device:    tailscale.Device{LastSeen: seen(time.Second)},
+			threshold: 0,
+			expected:  "offline",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := deviceStatus(tc.device, testNow, tc.threshold); got != tc.expected {
+				t.Errorf("Expected %s, got %s", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestFindStaleDevices(t *testing.T) {
+	devices := []tailscale.Device{
+		{ID: "connected", Name: "connected", ConnectedToControl: true},
+		{ID: "recent", Name: "recent", LastSeen: &tailscale.Time{Time: testNow.Add(-2 * 24 * time.Hour)}},
+		{
+			ID:       "old",
+			Name:     "old",
+			User:     "alice@example.com",
+			Tags:     []string{"tag:ci"},
+			LastSeen: &tailscale.Time{Time: testNow.Add(-45 * 24 * time.Hour)},
+			Expires:  tailscale.Time{Time: testNow.Add(-15 * 24 * time.Hour)},
+		},
+		{
+			ID:                "older",
+			Name:              "older",
+			LastSeen:          &tailscale.Time{Time: testNow.Add(-90 * 24 * time.Hour)},
+			KeyExpiryDisabled: true,
+			Expires:           tailscale.Time{Time: testNow.Add(-15 * 24 * time.Hour)},
+		},
+		{ID: "never", Name: "never"},
+	}
+
+	stale := findStaleDevices(devices, testNow, 30*24*time.Hour)
+
+	expected := []string{"never", "older", "old"}
+	if len(stale) != len(expected) {
+		t.Fatalf("Expected %d stale devices, got %+v", len(expected), stale)
+	}
+	for i, id := range expected {
+		if stale[i].ID != id {
+			t.Errorf("Expected device %d to be %s, got %s", i, id, stale[i].ID)
+		}
+	}
+
+	if stale[0].LastSeen != "never" || stale[0].DaysSinceSeen != 0 {
+		t.Errorf("Unexpected never-seen entry %+v", stale[0])
+	}
+	if stale[1].DaysSinceSeen != 90 || stale[1].KeyExpires != "" || !stale[1].KeyExpiryDisabled {
+		t.Errorf("Unexpected entry %+v", stale[1])
+	}
+	if stale[2].User != "alice@example.com" || len(stale[2].Tags) != 1 || stale[2].KeyExpires == "" {
+		t.Errorf("Unexpected entry %+v", stale[2])
+	}
+}
@@ -3,75 +3,279 @@ package tools
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"math"
+	"net/netip"
+	"slices"
+	"time"
 
-	"github.com/modelcontextprotocol/go-sdk/jsonschema"
+	"github.com/google/jsonschema-go/jsonschema"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
+	tailscale "tailscale.com/client/tailscale/v2"
 
 	"github.com/R167/tailscale-mcp/internal"
 )
 
-func RegisterDeviceTools(server *mcp.Server, client internal.TailscaleClient) {
+// deviceRefDescription documents the device references every device tool accepts
+const deviceRefDescription = "a device ID, MagicDNS name (e.g. db-1.example.ts.net), hostname (e.g. db-1), or Tailscale IP address"
+
+const (
+	// defaultDeviceLimit is the number of devices list_devices returns when no limit is given
+	defaultDeviceLimit = 100
+	// maxDeviceLimit is the largest page size list_devices accepts
+	maxDeviceLimit = 1000
+	// maxDeviceOffset is the largest offset list_devices accepts
+	maxDeviceOffset = math.MaxInt32
+)
+
+// DeviceSummary is the concise view of a device returned by list_devices
+type DeviceSummary struct {
+	ID        string   `json:"id"`
+	Name      string   `json:"name"`
+	Hostname  string   `json:"hostname"`
+	Addresses []string `json:"addresses"`
+	OS        string   `json:"os"`
+	User      string   `json:"user"`
+	Tags      []string `json:"tags,omitempty"`
+	Status    string   `json:"status"`
+	LastSeen  string   `json:"lastSeen,omitempty"`
+}
+
+// summarizeDevice builds the DeviceSummary for a device, evaluating its status as of now
+func summarizeDevice(device tailscale.Device, now time.Time, onlineThreshold time.Duration) DeviceSummary {
+	summary := DeviceSummary{
+		ID:        device.ID,
+		Name:      device.Name,
+		Hostname:  device.Hostname,
+		Addresses: device.Addresses,
+		OS:        device.OS,
+		User:      device.User,
+		Tags:      device.Tags,
+		Status:    deviceStatus(device, now, onlineThreshold),
+	}
+
+	if lastSeen := deviceLastSeen(device); !lastSeen.IsZero() {
+		summary.LastSeen = lastSeen.String()
+	}
+
+	return summary
+}
+
+func RegisterDeviceTools(server *mcp.Server, client internal.TailscaleClient, resolver *DeviceResolver) {
 	// List devices tool
 	mcp.AddTool(
 		server,
 		&mcp.Tool{
-			Name:        "list_devices",
-			Description: "List all devices in the Tailscale network with basic information (name, addresses, status, OS, user, tags)",
+			Name: "list_devices",
+			Description: "List devices in the Tailscale network with basic information (name, addresses, status, OS, user, tags). " +
+				"Supports filtering, sorting, and pagination; results include the total number of matching devices. " +
+				"Use fields to choose exactly which device attributes are returned, such as routes or client versions.",
 			InputSchema: &jsonschema.Schema{
-				Type:                 "object",
+				Type: "object",
+				Properties: map[string]*jsonschema.Schema{
+					"tags": {
+						Type:        "array",
+						Description: "Only include devices carrying all of these tags (e.g. [\"tag:prod\"])",
+						Items:       &jsonschema.Schema{Type: "string"},
+					},
+					"os": {
+						Type:        "string",
+						Description: "Only include devices running this OS (e.g. linux, windows, macOS, iOS, android)",
+					},
+					"user": {
+						Type:        "string",
+						Description: "Only include devices whose owner login contains this text",
+					},
+					"status": {
+						Type:        "string",
+						Description: "Only include online or offline devices",
+						Enum:        []any{"online", "offline"},
+					},
+					"name": {
+						Type:        "string",
+						Description: "Substring or glob (e.g. db-*) matched against the device name and hostname",
+					},
+					"address": {
+						Type:        "string",
+						Description: "Tailscale IP address of the device, or a CIDR containing one of its addresses",
+					},
+					"update_available": {
+						Type:        "boolean",
+						Description: "Only include devices that do (true) or do not (false) have a client update available",
+					},
+					"online_threshold_minutes": {
+						Type: "integer",
+						Description: fmt.Sprintf("Devices not connected to the control plane count as online if seen within this many minutes "+
+							"(default: %d, max: %d)", int(defaultOnlineThreshold/time.Minute), maxOnlineThresholdMinutes),
+						Minimum: jsonschema.Ptr(0.0),
+						Maximum: jsonschema.Ptr(float64(maxOnlineThresholdMinutes)),
+					},
+					"sort_by": {
+						Type:        "string",
+						Description: "Field to sort by (default: name)",
+						Enum:        deviceSortFields,
+					},
+					"descending": {
+						Type:        "boolean",
+						Description: "Sort in descending order",
+					},
+					"limit": {
+						Type:        "integer",
+						Description: fmt.Sprintf("Maximum number of devices to return (default: %d, max: %d)", defaultDeviceLimit, maxDeviceLimit),
+						Minimum:     jsonschema.Ptr(1.0),
+						Maximum:     jsonschema.Ptr(float64(maxDeviceLimit)),
+					},
+					"offset": {
+						Type:        "integer",
+						Description: "Number of matching devices to skip",
+						Minimum:     jsonschema.Ptr(0.0),
+						Maximum:     jsonschema.Ptr(float64(maxDeviceOffset)),
+					},
+					"fields": deviceFieldsSchema(),
+				},
 				AdditionalProperties: &jsonschema.Schema{Not: &jsonschema.Schema{}},
 			},
 		},
-		func(ctx context.Context, session *mcp.ServerSession, params *mcp.CallToolParamsFor[map[string]any]) (*mcp.CallToolResultFor[any], error) {
-			devices, err := client.Devices().List(ctx)
+		func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
+			filter, err := parseDeviceFilter(args)
 			if err != nil {
-				return toolError("Failed to list devices", err), nil
+				return toolError("Invalid filter parameters", err), nil, nil
 			}
 
-			// Create concise summary for each device
-			type DeviceSummary struct {
-				ID        string   `json:"id"`
-				Name      string   `json:"name"`
-				Hostname  string   `json:"hostname"`
-				Addresses []string `json:"addresses"`
-				OS        string   `json:"os"`
-				User      string   `json:"user"`
-				Tags      []string `json:"tags,omitempty"`
-				Status    string   `json:"status"`
-				LastSeen  string   `json:"lastSeen,omitempty"`
+			sortBy, err := getOptionalStringParam(args, "sort_by")
+			if err != nil {
+				return toolError("Invalid sort_by parameter", err), nil, nil
 			}
 
-			var summaries []DeviceSummary
-			for _, device := range devices {
-				status := "offline"
-				if !device.LastSeen.IsZero() {
-					status = "online"
-				}
+			descending, err := getOptionalBoolParam(args, "descending")
+			if err != nil {
+				return toolError("Invalid descending parameter", err), nil, nil
+			}
 
-				summary := DeviceSummary{
-					ID:        device.ID,
-					Name:      device.Name,
-					Hostname:  device.Hostname,
-					Addresses: device.Addresses,
-					OS:        device.OS,
-					User:      device.User,
-					Tags:      device.Tags,
-					Status:    status,
-				}
+			limit, err := getOptionalIntParam(args, "limit", defaultDeviceLimit)
+			if err != nil {
+				return toolError("Invalid limit parameter", err), nil, nil
+			}
+
+			offset, err := getOptionalIntParam(args, "offset", 0)
+			if err != nil {
+				return toolError("Invalid offset parameter", err), nil, nil
+			}
+
+			if limit < 1 || limit > maxDeviceLimit || offset < 0 || offset > maxDeviceOffset {
+				return toolError("Invalid pagination parameters",
+					fmt.Errorf("limit must be between 1 and %d and offset between 0 and %d", maxDeviceLimit, maxDeviceOffset)), nil, nil
+			}
+
+			fields, err := parseDeviceFields(args)
+			if err != nil {
+				return toolError("Invalid fields parameter", err), nil, nil
+			}
+
+			var devices []tailscale.Device
+			if needsAllFields(fields) {
+				devices, err = client.Devices().ListWithAllFields(ctx)
+			} else {
+				devices, err = client.Devices().List(ctx)
+			}
+			if err != nil {
+				return toolError("Failed to list devices", err), nil, nil
+			}
+
+			now := time.Now()
+			matched := filterDevices(devices, filter, now)
+			if err := sortDevices(matched, sortBy, descending != nil && *descending); err != nil {
+				return toolError("Invalid sort_by parameter", err), nil, nil
+			}
+
+			start := min(offset, len(matched))
+			page := matched[start:min(start+limit, len(matched))]
 
-				if !device.LastSeen.IsZero() {
-					summary.LastSeen = device.LastSeen.String()
+			summaries := make([]any, 0, len(page))
+			for _, device := range page {
+				if fields != nil {
+					summaries = append(summaries, projectDevice(device, fields, now, filter.OnlineThreshold))
+				} else {
+					summaries = append(summaries, summarizeDevice(device, now, filter.OnlineThreshold))
 				}
+			}
 
-				summaries = append(summaries, summary)
+			result := struct {
+				Total   int   `json:"total"`
+				Offset  int   `json:"offset"`
+				Count   int   `json:"count"`
+				HasMore bool  `json:"hasMore"`
+				Devices []any `json:"devices"`
+			}{
+				Total:   len(matched),
+				Offset:  offset,
+				Count:   len(summaries),
+				HasMore: offset+len(summaries) < len(matched),
+				Devices: summaries,
 			}
 
-			output, err := json.MarshalIndent(summaries, "", "  ")
+			output, err := json.MarshalIndent(result, "", "  ")
 			if err != nil {
-				return toolError("Failed to serialize device list", err), nil
+				return toolError("Failed to serialize device list", err), nil, nil
 			}
 
-			return toolSuccess(string(output)), nil
+			return toolSuccess(string(output)), nil, nil
+		},
+	)
+
+	// Stale devices tool
+	mcp.AddTool(
+		server,
+		&mcp.Tool{
+			Name: "stale_devices",
+			Description: "Report devices that are not connected and have not been seen for a number of days, oldest first, " +
+				"with their owner, tags, and key expiry to help clean up the tailnet",
+			InputSchema: &jsonschema.Schema{
+				Type: "object",
+				Properties: map[string]*jsonschema.Schema{
+					"days": {
+						Type:        "integer",
+						Description: fmt.Sprintf("Report devices not seen for more than this many days (default: %d, max: %d)", defaultStaleDays, maxDays),
+						Minimum:     jsonschema.Ptr(0.0),
+						Maximum:     jsonschema.Ptr(float64(maxDays)),
+					},
+				},
+				AdditionalProperties: &jsonschema.Schema{Not: &jsonschema.Schema{}},
+			},
+		},
+		func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
+			days, err := getOptionalIntParam(args, "days", defaultStaleDays)
+			if err != nil {
+				return toolError("Invalid days parameter", err), nil, nil
+			}
+
+			if days < 0 || days > maxDays {
+				return toolError("Invalid days parameter", fmt.Errorf("days must be between 0 and %d", maxDays)), nil, nil
+			}
+
+			devices, err := client.Devices().List(ctx)
+			if err != nil {
+				return toolError("Failed to list devices", err), nil, nil
+			}
+
+			stale := findStaleDevices(devices, time.Now(), time.Duration(days)*24*time.Hour)
+
+			result := struct {
+				Days    int           `json:"days"`
+				Count   int           `json:"count"`
+				Devices []StaleDevice `json:"devices"`
+			}{
+				Days:    days,
+				Count:   len(stale),
+				Devices: stale,
+			}
+
+			output, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				return toolError("Failed to serialize stale devices", err), nil, nil
+			}
+
+			return toolSuccess(string(output)), nil, nil
 		},
 	)
 
@@ -79,41 +283,728 @@ func RegisterDeviceTools(server *mcp.Server, client internal.TailscaleClient) {
 	mcp.AddTool(
 		server,
 		&mcp.Tool{
-			Name:        "get_device_details",
-			Description: "Get detailed information about a specific device including connectivity, routes, and security details",
+			Name: "get_device_details",
+			Description: "Get detailed information about a specific device including connectivity, routes, and security details. " +
+				"Use fields to return only selected attributes.",
+			InputSchema: &jsonschema.Schema{
+				Type: "object",
+				Properties: map[string]*jsonschema.Schema{
+					"deviceID": {
+						Type:        "string",
+						Description: "The device to get details for: " + deviceRefDescription,
+					},
+					"fields": deviceFieldsSchema(),
+				},
+				Required:             []string{"deviceID"},
+				AdditionalProperties: &jsonschema.Schema{Not: &jsonschema.Schema{}},
+			},
+		},
+		func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
+			deviceID, err := getStringParam(args, "deviceID")
+			if err != nil {
+				return toolError("Invalid device ID parameter", err), nil, nil
+			}
+
+			deviceID, err = resolver.resolve(ctx, deviceID)
+			if err != nil {
+				return toolError("Failed to resolve device", err), nil, nil
+			}
+
+			fields, err := parseDeviceFields(args)
+			if err != nil {
+				return toolError("Invalid fields parameter", err), nil, nil
+			}
+
+			device, err := client.Devices().GetWithAllFields(ctx, deviceID)
+			if err != nil {
+				return toolError("Failed to get device details", err), nil, nil
+			}
+
+			var details any = device
+			if fields != nil {
+				details = projectDevice(*device, fields, time.Now(), defaultOnlineThreshold)
+			}
+
+			output, err := json.MarshalIndent(details, "", "  ")
+			if err != nil {
+				return toolError("Failed to serialize device details", err), nil, nil
+			}
+
+			return toolSuccess(string(output)), nil, nil
+		},
+	)
+
+	// Get device routes tool
+	mcp.AddTool(
+		server,
+		&mcp.Tool{
+			Name:        "get_device_routes",
+			Description: "Get the subnet routes a device advertises and which of them are enabled",
+			InputSchema: &jsonschema.Schema{
+				Type: "object",
+				Properties: map[string]*jsonschema.Schema{
+					"deviceID": {
+						Type:        "string",
+						Description: "The device to get routes for: " + deviceRefDescription,
+					},
+				},
+				Required:             []string{"deviceID"},
+				AdditionalProperties: &jsonschema.Schema{Not: &jsonschema.Schema{}},
+			},
+		},
+		func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
+			deviceID, err := getStringParam(args, "deviceID")
+			if err != nil {
+				return toolError("Invalid device ID parameter", err), nil, nil
+			}
+
+			deviceID, err = resolver.resolve(ctx, deviceID)
+			if err != nil {
+				return toolError("Failed to resolve device", err), nil, nil
+			}
+
+			routes, err := client.Devices().SubnetRoutes(ctx, deviceID)
+			if err != nil {
+				return toolError("Failed to get device routes", err), nil, nil
+			}
+
+			output, err := json.MarshalIndent(routes, "", "  ")
+			if err != nil {
+				return toolError("Failed to serialize device routes", err), nil, nil
+			}
+
+			return toolSuccess(string(output)), nil, nil
+		},
+	)
+
+	// Set device routes tool
+	mcp.AddTool(
+		server,
+		&mcp.Tool{
+			Name:        "set_device_routes",
+			Description: "Enable or disable subnet routes advertised by a device. Only prefixes the device advertises can be enabled.",
+			InputSchema: &jsonschema.Schema{
+				Type: "object",
+				Properties: map[string]*jsonschema.Schema{
+					"deviceID": {
+						Type:        "string",
+						Description: "The device to update routes for: " + deviceRefDescription,
+					},
+					"enable": {
+						Type:        "array",
+						Description: "CIDR prefixes to enable (e.g. 10.0.0.0/24, 0.0.0.0/0 for an exit node)",
+						Items:       &jsonschema.Schema{Type: "string"},
+					},
+					"disable": {
+						Type:        "array",
+						Description: "CIDR prefixes to disable",
+						Items:       &jsonschema.Schema{Type: "string"},
+					},
+				},
+				Required:             []string{"deviceID"},
+				AdditionalProperties: &jsonschema.Schema{Not: &jsonschema.Schema{}},
+			},
+		},
+		func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
+			deviceID, err := getStringParam(args, "deviceID")
+			if err != nil {
+				return toolError("Invalid device ID parameter", err), nil, nil
+			}
+
+			deviceID, err = resolver.resolve(ctx, deviceID)
+			if err != nil {
+				return toolError("Failed to resolve device", err), nil, nil
+			}
+
+			enable, err := getOptionalStringSliceParam(args, "enable")
+			if err != nil {
+				return toolError("Invalid enable parameter", err), nil, nil
+			}
+
+			disable, err := getOptionalStringSliceParam(args, "disable")
+			if err != nil {
+				return toolError("Invalid disable parameter", err), nil, nil
+			}
+
+			if len(enable) == 0 && len(disable) == 0 {
+				return toolError("Invalid parameters", fmt.Errorf("at least one of enable or disable is required")), nil, nil
+			}
+
+			current, err := client.Devices().SubnetRoutes(ctx, deviceID)
+			if err != nil {
+				return toolError("Failed to get device routes", err), nil, nil
+			}
+
+			enabled, err := applyRouteChanges(current, enable, disable)
+			if err != nil {
+				return toolError("Route validation failed", err), nil, nil
+			}
+
+			if err := client.Devices().SetSubnetRoutes(ctx, deviceID, enabled); err != nil {
+				return toolError("Failed to set device routes", err), nil, nil
+			}
+
+			updated, err := client.Devices().SubnetRoutes(ctx, deviceID)
+			if err != nil {
+				return toolError("Routes were updated but could not be re-read", err), nil, nil
+			}
+
+			result := struct {
+				DeviceID        string   `json:"deviceId"`
+				Advertised      []string `json:"advertisedRoutes"`
+				PreviousEnabled []string `json:"previousEnabledRoutes"`
+				Enabled         []string `json:"enabledRoutes"`
+			}{
+				DeviceID:        deviceID,
+				Advertised:      updated.Advertised,
+				PreviousEnabled: current.Enabled,
+				Enabled:         updated.Enabled,
+			}
+
+			output, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				return toolError("Failed to serialize device routes", err), nil, nil
+			}
+
+			return toolSuccess(string(output)), nil, nil
+		},
+	)
+
+	// Authorize device tool
+	mcp.AddTool(
+		server,
+		&mcp.Tool{
+			Name:        "authorize_device",
+			Description: "Approve a device that is waiting for authorization to join the tailnet",
+			InputSchema: deviceIDSchema("The device to authorize"),
+		},
+		deviceAuthorizationHandler(client, resolver, true),
+	)
+
+	// Deauthorize device tool
+	mcp.AddTool(
+		server,
+		&mcp.Tool{
+			Name:        "deauthorize_device",
+			Description: "Revoke authorization for a device, disconnecting it from the tailnet until it is approved again",
+			InputSchema: deviceIDSchema("The device to deauthorize"),
+		},
+		deviceAuthorizationHandler(client, resolver, false),
+	)
+
+	// Set device tags tool
+	mcp.AddTool(
+		server,
+		&mcp.Tool{
+			Name: "set_device_tags",
+			Description: "Replace, add, or remove the ACL tags on a device. Every resulting tag must be declared in the policy's " +
+				"tagOwners; use either tags to replace the full set or add/remove to modify it.",
 			InputSchema: &jsonschema.Schema{
 				Type: "object",
 				Properties: map[string]*jsonschema.Schema{
 					"deviceID": {
 						Type:        "string",
-						Description: "The device ID to get details for",
+						Description: "The device to update tags for: " + deviceRefDescription,
+					},
+					"tags": {
+						Type:        "array",
+						Description: "Replace the device's tags with this list (e.g. [\"tag:prod\", \"tag:db\"])",
+						Items:       &jsonschema.Schema{Type: "string"},
+					},
+					"add": {
+						Type:        "array",
+						Description: "Tags to add to the device",
+						Items:       &jsonschema.Schema{Type: "string"},
+					},
+					"remove": {
+						Type:        "array",
+						Description: "Tags to remove from the device",
+						Items:       &jsonschema.Schema{Type: "string"},
 					},
 				},
 				Required:             []string{"deviceID"},
 				AdditionalProperties: &jsonschema.Schema{Not: &jsonschema.Schema{}},
 			},
 		},
-		func(ctx context.Context, session *mcp.ServerSession, params *mcp.CallToolParamsFor[map[string]any]) (*mcp.CallToolResultFor[any], error) {
-			deviceID, err := getStringParam(params.Arguments, "deviceID")
+		func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
+			deviceID, err := getStringParam(args, "deviceID")
+			if err != nil {
+				return toolError("Invalid device ID parameter", err), nil, nil
+			}
+
+			deviceID, err = resolver.resolve(ctx, deviceID)
+			if err != nil {
+				return toolError("Failed to resolve device", err), nil, nil
+			}
+
+			replace, err := getOptionalStringSliceParam(args, "tags")
+			if err != nil {
+				return toolError("Invalid tags parameter", err), nil, nil
+			}
+
+			add, err := getOptionalStringSliceParam(args, "add")
+			if err != nil {
+				return toolError("Invalid add parameter", err), nil, nil
+			}
+
+			remove, err := getOptionalStringSliceParam(args, "remove")
+			if err != nil {
+				return toolError("Invalid remove parameter", err), nil, nil
+			}
+
+			_, replacing := args["tags"]
+			if replacing && (add != nil || remove != nil) {
+				return toolError("Invalid parameters", fmt.Errorf("tags cannot be combined with add or remove")), nil, nil
+			}
+			if !replacing && len(add) == 0 && len(remove) == 0 {
+				return toolError("Invalid parameters", fmt.Errorf("one of tags, add, or remove is required")), nil, nil
+			}
+
+			device, err := client.Devices().GetWithAllFields(ctx, deviceID)
+			if err != nil {
+				return toolError("Failed to get device details", err), nil, nil
+			}
+
+			tags := replace
+			if !replacing {
+				tags = mergeTags(device.Tags, add, remove)
+			}
+
+			acl, err := client.PolicyFile().Get(ctx)
+			if err != nil {
+				return toolError("Failed to get ACL policy", err), nil, nil
+			}
+
+			if err := checkTagsDeclared(acl, tags); err != nil {
+				return toolError("Tag validation failed", err), nil, nil
+			}
+
+			if err := client.Devices().SetTags(ctx, deviceID, tags); err != nil {
+				return toolError("Failed to set device tags", err), nil, nil
+			}
+
+			result := struct {
+				DeviceID     string   `json:"deviceId"`
+				Name         string   `json:"name"`
+				PreviousTags []string `json:"previousTags"`
+				Tags         []string `json:"tags"`
+			}{
+				DeviceID:     deviceID,
+				Name:         device.Name,
+				PreviousTags: device.Tags,
+				Tags:         tags,
+			}
+
+			output, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				return toolError("Failed to serialize device tags", err), nil, nil
+			}
+
+			return toolSuccess(string(output)), nil, nil
+		},
+	)
+
+	// Rename device tool
+	mcp.AddTool(
+		server,
+		&mcp.Tool{
+			Name:        "rename_device",
+			Description: "Rename a device, changing its MagicDNS name",
+			InputSchema: &jsonschema.Schema{
+				Type: "object",
+				Properties: map[string]*jsonschema.Schema{
+					"deviceID": {
+						Type:        "string",
+						Description: "The device to rename: " + deviceRefDescription,
+					},
+					"name": {
+						Type:        "string",
+						Description: "The new device name (e.g. db-1)",
+					},
+				},
+				Required:             []string{"deviceID", "name"},
+				AdditionalProperties: &jsonschema.Schema{Not: &jsonschema.Schema{}},
+			},
+		},
+		func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
+			deviceID, err := getStringParam(args, "deviceID")
+			if err != nil {
+				return toolError("Invalid device ID parameter", err), nil, nil
+			}
+
+			deviceID, err = resolver.resolve(ctx, deviceID)
+			if err != nil {
+				return toolError("Failed to resolve device", err), nil, nil
+			}
+
+			name, err := getStringParam(args, "name")
+			if err != nil {
+				return toolError("Invalid name parameter", err), nil, nil
+			}
+
+			if err := validateDeviceName(name); err != nil {
+				return toolError("Device name validation failed", err), nil, nil
+			}
+
+			before, err := client.Devices().GetWithAllFields(ctx, deviceID)
+			if err != nil {
+				return toolError("Failed to get device details", err), nil, nil
+			}
+
+			if err := client.Devices().SetName(ctx, deviceID, name); err != nil {
+				return toolError("Failed to rename device", err), nil, nil
+			}
+			resolver.invalidate()
+
+			after, err := client.Devices().GetWithAllFields(ctx, deviceID)
+			if err != nil {
+				return toolError("Device was renamed but could not be re-read", err), nil, nil
+			}
+
+			result := struct {
+				DeviceID     string `json:"deviceId"`
+				PreviousName string `json:"previousName"`
+				Name         string `json:"name"`
+			}{
+				DeviceID:     deviceID,
+				PreviousName: before.Name,
+				Name:         after.Name,
+			}
+
+			output, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				return toolError("Failed to serialize device name", err), nil, nil
+			}
+
+			return toolSuccess(string(output)), nil, nil
+		},
+	)
+
+	// Delete device tool
+	mcp.AddTool(
+		server,
+		&mcp.Tool{
+			Name: "delete_device",
+			Description: "Permanently remove a device from the tailnet. The confirm argument must repeat the device's name " +
+				"(full MagicDNS name or short hostname) exactly, as shown by get_device_details.",
+			InputSchema: &jsonschema.Schema{
+				Type: "object",
+				Properties: map[string]*jsonschema.Schema{
+					"deviceID": {
+						Type:        "string",
+						Description: "The device to delete: " + deviceRefDescription,
+					},
+					"confirm": {
+						Type:        "string",
+						Description: "The name of the device being deleted, to confirm the right device is targeted",
+					},
+				},
+				Required:             []string{"deviceID", "confirm"},
+				AdditionalProperties: &jsonschema.Schema{Not: &jsonschema.Schema{}},
+			},
+		},
+		func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
+			deviceID, err := getStringParam(args, "deviceID")
 			if err != nil {
-				return toolError("Invalid device ID parameter", err), nil
+				return toolError("Invalid device ID parameter", err), nil, nil
 			}
 
-			if err := validateDeviceID(deviceID); err != nil {
-				return toolError("Device ID validation failed", err), nil
+			deviceID, err = resolver.resolve(ctx, deviceID)
+			if err != nil {
+				return toolError("Failed to resolve device", err), nil, nil
+			}
+
+			confirm, err := getStringParam(args, "confirm")
+			if err != nil {
+				return toolError("Invalid confirm parameter", err), nil, nil
 			}
 
 			device, err := client.Devices().GetWithAllFields(ctx, deviceID)
 			if err != nil {
-				return toolError("Failed to get device details", err), nil
+				return toolError("Failed to get device details", err), nil, nil
+			}
+
+			if confirm != device.Name && confirm != shortDeviceName(device.Name) {
+				return toolError("Deletion not confirmed",
+					fmt.Errorf("confirm %q does not match the name of device %s (%q)", confirm, deviceID, device.Name)), nil, nil
+			}
+
+			if err := client.Devices().Delete(ctx, deviceID); err != nil {
+				return toolError("Failed to delete device", err), nil, nil
+			}
+			resolver.invalidate()
+
+			result := struct {
+				DeviceID string   `json:"deviceId"`
+				Name     string   `json:"name"`
+				Hostname string   `json:"hostname"`
+				User     string   `json:"user"`
+				Tags     []string `json:"tags,omitempty"`
+				Deleted  bool     `json:"deleted"`
+			}{
+				DeviceID: deviceID,
+				Name:     device.Name,
+				Hostname: device.Hostname,
+				User:     device.User,
+				Tags:     device.Tags,
+				Deleted:  true,
+			}
+
+			output, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				return toolError("Failed to serialize deleted device", err), nil, nil
+			}
+
+			return toolSuccess(string(output)), nil, nil
+		},
+	)
+
+	// Set device key expiry tool
+	mcp.AddTool(
+		server,
+		&mcp.Tool{
+			Name:        "set_device_key_expiry",
+			Description: "Disable or re-enable node key expiry for a device (typically disabled for servers)",
+			InputSchema: &jsonschema.Schema{
+				Type: "object",
+				Properties: map[string]*jsonschema.Schema{
+					"deviceID": {
+						Type:        "string",
+						Description: "The device to update: " + deviceRefDescription,
+					},
+					"keyExpiryDisabled": {
+						Type:        "boolean",
+						Description: "true to disable key expiry, false to enable it again",
+					},
+				},
+				Required:             []string{"deviceID", "keyExpiryDisabled"},
+				AdditionalProperties: &jsonschema.Schema{Not: &jsonschema.Schema{}},
+			},
+		},
+		func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
+			deviceID, err := getStringParam(args, "deviceID")
+			if err != nil {
+				return toolError("Invalid device ID parameter", err), nil, nil
+			}
+
+			deviceID, err = resolver.resolve(ctx, deviceID)
+			if err != nil {
+				return toolError("Failed to resolve device", err), nil, nil
 			}
 
-			output, err := json.MarshalIndent(device, "", "  ")
+			disabled, err := getBoolParam(args, "keyExpiryDisabled")
 			if err != nil {
-				return toolError("Failed to serialize device details", err), nil
+				return toolError("Invalid keyExpiryDisabled parameter", err), nil, nil
 			}
 
-			return toolSuccess(string(output)), nil
+			before, err := client.Devices().GetWithAllFields(ctx, deviceID)
+			if err != nil {
+				return toolError("Failed to get device details", err), nil, nil
+			}
+
+			if err := client.Devices().SetKey(ctx, deviceID, tailscale.DeviceKey{KeyExpiryDisabled: disabled}); err != nil {
+				return toolError("Failed to update device key expiry", err), nil, nil
+			}
+
+			after, err := client.Devices().GetWithAllFields(ctx, deviceID)
+			if err != nil {
+				return toolError("Key expiry was updated but the device could not be re-read", err), nil, nil
+			}
+
+			type keyState struct {
+				KeyExpiryDisabled bool   `json:"keyExpiryDisabled"`
+				Expires           string `json:"expires,omitempty"`
+			}
+
+			stateOf := func(device *tailscale.Device) keyState {
+				state := keyState{KeyExpiryDisabled: device.KeyExpiryDisabled}
+				if !device.Expires.IsZero() {
+					state.Expires = device.Expires.String()
+				}
+				return state
+			}
+
+			result := struct {
+				DeviceID string   `json:"deviceId"`
+				Name     string   `json:"name"`
+				Before   keyState `json:"before"`
+				After    keyState `json:"after"`
+			}{
+				DeviceID: deviceID,
+				Name:     after.Name,
+				Before:   stateOf(before),
+				After:    stateOf(after),
+			}
+
+			output, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				return toolError("Failed to serialize device key expiry", err), nil, nil
+			}
+
+			return toolSuccess(string(output)), nil, nil
 		},
 	)
 }
+
+// mergeTags returns current with the add tags appended and the remove tags dropped, without duplicates
+func mergeTags(current, add, remove []string) []string {
+	tags := make([]string, 0, len(current)+len(add))
+	for _, tag := range slices.Concat(current, add) {
+		if !slices.Contains(tags, tag) && !slices.Contains(remove, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// deviceIDSchema returns an input schema that takes only a device reference
+func deviceIDSchema(description string) *jsonschema.Schema {
+	return &jsonschema.Schema{
+		Type: "object",
+		Properties: map[string]*jsonschema.Schema{
+			"deviceID": {
+				Type:        "string",
+				Description: description + ": " + deviceRefDescription,
+			},
+		},
+		Required:             []string{"deviceID"},
+		AdditionalProperties: &jsonschema.Schema{Not: &jsonschema.Schema{}},
+	}
+}
+
+// deviceAuthorizationHandler returns a tool handler that sets the authorization state of a
+// device and reports its state before and after the change
+func deviceAuthorizationHandler(client internal.TailscaleClient, resolver *DeviceResolver, authorized bool) mcp.ToolHandlerFor[map[string]any, any] {
+	return func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
+		deviceID, err := getStringParam(args, "deviceID")
+		if err != nil {
+			return toolError("Invalid device ID parameter", err), nil, nil
+		}
+
+		deviceID, err = resolver.resolve(ctx, deviceID)
+		if err != nil {
+			return toolError("Failed to resolve device", err), nil, nil
+		}
+
+		before, err := client.Devices().GetWithAllFields(ctx, deviceID)
+		if err != nil {
+			return toolError("Failed to get device details", err), nil, nil
+		}
+
+		after := before
+		if before.Authorized != authorized {
+			if err := client.Devices().SetAuthorized(ctx, deviceID, authorized); err != nil {
+				return toolError("Failed to update device authorization", err), nil, nil
+			}
+
+			after, err = client.Devices().GetWithAllFields(ctx, deviceID)
+			if err != nil {
+				return toolError("Authorization was updated but the device could not be re-read", err), nil, nil
+			}
+		}
+
+		type deviceState struct {
+			Authorized bool   `json:"authorized"`
+			LastSeen   string `json:"lastSeen,omitempty"`
+		}
+
+		stateOf := func(device *tailscale.Device) deviceState {
+			state := deviceState{Authorized: device.Authorized}
+			if lastSeen := deviceLastSeen(*device); !lastSeen.IsZero() {
+				state.LastSeen = lastSeen.String()
+			}
+			return state
+		}
+
+		result := struct {
+			DeviceID string      `json:"deviceId"`
+			Name     string      `json:"name"`
+			Changed  bool        `json:"changed"`
+			Before   deviceState `json:"before"`
+			After    deviceState `json:"after"`
+		}{
+			DeviceID: deviceID,
+			Name:     before.Name,
+			Changed:  before.Authorized != after.Authorized,
+			Before:   stateOf(before),
+			After:    stateOf(after),
+		}
+
+		output, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return toolError("Failed to serialize device authorization", err), nil, nil
+		}
+
+		return toolSuccess(string(output)), nil, nil
+	}
+}
+
+// applyRouteChanges returns the enabled route list that results from enabling and disabling
+// the given prefixes. Enabled prefixes must be advertised by the device; disabled prefixes
+// must be either advertised or currently enabled.
+func applyRouteChanges(routes *tailscale.DeviceRoutes, enable, disable []string) ([]string, error) {
+	advertised := make(map[netip.Prefix]bool, len(routes.Advertised))
+	for _, route := range routes.Advertised {
+		if prefix, err := netip.ParsePrefix(route); err == nil {
+			advertised[prefix.Masked()] = true
+		}
+	}
+
+	var enabled []netip.Prefix
+	for _, route := range routes.Enabled {
+		if prefix, err := netip.ParsePrefix(route); err == nil {
+			enabled = append(enabled, prefix.Masked())
+		}
+	}
+
+	toEnable, err := parseRoutePrefixes(enable)
+	if err != nil {
+		return nil, err
+	}
+
+	toDisable, err := parseRoutePrefixes(disable)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, prefix := range toEnable {
+		if slices.Contains(toDisable, prefix) {
+			return nil, fmt.Errorf("route %s cannot be both enabled and disabled", prefix)
+		}
+		if !advertised[prefix] {
+			return nil, fmt.Errorf("route %s is not advertised by the device (advertised: %v)", prefix, routes.Advertised)
+		}
+		if !slices.Contains(enabled, prefix) {
+			enabled = append(enabled, prefix)
+		}
+	}
+
+	for _, prefix := range toDisable {
+		if !advertised[prefix] && !slices.Contains(enabled, prefix) {
+			return nil, fmt.Errorf("route %s is neither advertised nor enabled on the device", prefix)
+		}
+		enabled = slices.DeleteFunc(enabled, func(p netip.Prefix) bool { return p == prefix })
+	}
+
+	result := make([]string, 0, len(enabled))
+	for _, prefix := range enabled {
+		result = append(result, prefix.String())
+	}
+	return result, nil
+}
+
+// parseRoutePrefixes parses CIDR strings, rejecting prefixes with host bits set
+func parseRoutePrefixes(routes []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(routes))
+	for _, route := range routes {
+		prefix, err := netip.ParsePrefix(route)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q: %w", route, err)
+		}
+		if prefix != prefix.Masked() {
+			return nil, fmt.Errorf("invalid CIDR %q: host bits are set (did you mean %s?)", route, prefix.Masked())
+		}
+		prefixes = append(prefixes, prefix)
+	}
+	return prefixes, nil
+}
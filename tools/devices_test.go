@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
@@ -18,7 +20,7 @@ func TestRegisterDeviceTools(t *testing.T) {
 	mockClient := &internal.MockTailscaleClient{}
 
 	// This should not panic
-	RegisterDeviceTools(server, mockClient)
+	RegisterDeviceTools(server, mockClient, NewDeviceResolver(mockClient))
 }
 
 func TestListDevicesSuccess(t *testing.T) {
@@ -39,12 +41,151 @@ func TestListDevicesSuccess(t *testing.T) {
 
 	impl := &mcp.Implementation{}
 	server := mcp.NewServer(impl, &mcp.ServerOptions{})
-	RegisterDeviceTools(server, mockClient)
+	RegisterDeviceTools(server, mockClient, NewDeviceResolver(mockClient))
 
 	// We can't easily test the actual tool function directly, so we just ensure registration doesn't panic
 	// In a real test environment, you'd use the MCP client to call the tool
 }
 
+func TestListDevicesFilterAndPaginate(t *testing.T) {
+	mockDevices := &internal.MockDevicesResource{
+		ListFunc: func(ctx context.Context) ([]tailscale.Device, error) {
+			return testDevices(), nil
+		},
+	}
+
+	mockClient := &internal.MockTailscaleClient{
+		DevicesFunc: func() internal.DevicesResource {
+			return mockDevices
+		},
+	}
+
+	server := mcp.NewServer(&mcp.Implementation{}, &mcp.ServerOptions{})
+	RegisterDeviceTools(server, mockClient, NewDeviceResolver(mockClient))
+
+	result, text := callTool(t, server, "list_devices", map[string]any{
+		"tags":    []any{"tag:db"},
+		"sort_by": "name",
+		"limit":   1,
+		"offset":  1,
+	})
+	if result.IsError {
+		t.Fatalf("Expected success, got error: %s", text)
+	}
+
+	var output struct {
+		Total   int             `json:"total"`
+		Count   int             `json:"count"`
+		HasMore bool            `json:"hasMore"`
+		Devices []DeviceSummary `json:"devices"`
+	}
+	if err := json.Unmarshal([]byte(text), &output); err != nil {
+		t.Fatalf("Failed to unmarshal output: %v", err)
+	}
+	if output.Total != 2 || output.Count != 1 || output.HasMore {
+		t.Errorf("Unexpected pagination: %s", text)
+	}
+	if len(output.Devices) != 1 || output.Devices[0].ID != "2" {
+		t.Errorf("Expected device 2, got %+v", output.Devices)
+	}
+
+	result, text = callTool(t, server, "list_devices", map[string]any{"offset": 10})
+	if result.IsError {
+		t.Fatalf("Expected success for offset past the end, got error: %s", text)
+	}
+	if !strings.Contains(text, `"devices": []`) {
+		t.Errorf("Expected empty device page, got %s", text)
+	}
+
+	result, text = callTool(t, server, "list_devices", map[string]any{"offset": maxDeviceOffset, "limit": maxDeviceLimit})
+	if result.IsError || !strings.Contains(text, `"devices": []`) {
+		t.Errorf("Expected an empty page for the largest offset, got %s", text)
+	}
+}
+
+func TestListDevicesFields(t *testing.T) {
+	var listedAll bool
+	mockClient := &internal.MockTailscaleClient{
+		DevicesFunc: func() internal.DevicesResource {
+			return &internal.MockDevicesResource{
+				ListFunc: func(ctx context.Context) ([]tailscale.Device, error) {
+					return testDevices(), nil
+				},
+				ListWithAllFieldsFunc: func(ctx context.Context) ([]tailscale.Device, error) {
+					listedAll = true
+					devices := testDevices()
+					devices[0].AdvertisedRoutes = []string{"10.0.0.0/24"}
+					return devices, nil
+				},
+			}
+		},
+	}
+
+	server := mcp.NewServer(&mcp.Implementation{}, &mcp.ServerOptions{})
+	RegisterDeviceTools(server, mockClient, NewDeviceResolver(mockClient))
+
+	result, text := callTool(t, server, "list_devices", map[string]any{
+		"fields": []any{"name", "advertisedRoutes"},
+		"limit":  1,
+	})
+	if result.IsError {
+		t.Fatalf("Expected success, got error: %s", text)
+	}
+	if !listedAll {
+		t.Error("Expected devices to be listed with all fields for advertisedRoutes")
+	}
+
+	var output struct {
+		Devices []map[string]any `json:"devices"`
+	}
+	if err := json.Unmarshal([]byte(text), &output); err != nil {
+		t.Fatalf("Failed to unmarshal output: %v", err)
+	}
+	if len(output.Devices) != 1 || len(output.Devices[0]) != 2 {
+		t.Fatalf("Expected one device with two fields, got %s", text)
+	}
+	if output.Devices[0]["name"] != "alice-laptop.example.ts.net" {
+		t.Errorf("Unexpected device %v", output.Devices[0])
+	}
+}
+
+func TestStaleDevices(t *testing.T) {
+	mockClient := &internal.MockTailscaleClient{
+		DevicesFunc: func() internal.DevicesResource {
+			return &internal.MockDevicesResource{
+				ListFunc: func(ctx context.Context) ([]tailscale.Device, error) {
+					return testDevices(), nil
+				},
+			}
+		},
+	}
+
+	server := mcp.NewServer(&mcp.Implementation{}, &mcp.ServerOptions{})
+	RegisterDeviceTools(server, mockClient, NewDeviceResolver(mockClient))
+
+	result, text := callTool(t, server, "stale_devices", map[string]any{"days": 30})
+	if result.IsError {
+		t.Fatalf("Expected success, got error: %s", text)
+	}
+
+	var output struct {
+		Days    int           `json:"days"`
+		Count   int           `json:"count"`
+		Devices []StaleDevice `json:"devices"`
+	}
+	if err := json.Unmarshal([]byte(text), &output); err != nil {
+		t.Fatalf("Failed to unmarshal output: %v", err)
+	}
+
+	// Device 1 is connected to control and is never stale; device 2 has never been seen
+	if output.Days != 30 || output.Count != 2 {
+		t.Fatalf("Unexpected stale report: %s", text)
+	}
+	if output.Devices[0].ID != "2" || output.Devices[0].LastSeen != "never" || output.Devices[1].ID != "3" {
+		t.Errorf("Unexpected stale devices: %+v", output.Devices)
+	}
+}
+
 func TestGetDeviceDetailsSuccess(t *testing.T) {
 	mockDevices := &internal.MockDevicesResource{
 		GetWithAllFieldsFunc: func(ctx context.Context, deviceID string) (*tailscale.Device, error) {
@@ -67,7 +208,44 @@ func TestGetDeviceDetailsSuccess(t *testing.T) {
 
 	impl := &mcp.Implementation{}
 	server := mcp.NewServer(impl, &mcp.ServerOptions{})
-	RegisterDeviceTools(server, mockClient)
+	RegisterDeviceTools(server, mockClient, NewDeviceResolver(mockClient))
+}
+
+func TestGetDeviceDetailsFields(t *testing.T) {
+	mockClient := &internal.MockTailscaleClient{
+		DevicesFunc: func() internal.DevicesResource {
+			return &internal.MockDevicesResource{
+				GetWithAllFieldsFunc: func(ctx context.Context, deviceID string) (*tailscale.Device, error) {
+					return &tailscale.Device{
+						ID:               deviceID,
+						Name:             "router.example.ts.net",
+						ClientVersion:    "1.80.0",
+						AdvertisedRoutes: []string{"10.0.0.0/24"},
+						EnabledRoutes:    []string{"10.0.0.0/24"},
+					}, nil
+				},
+			}
+		},
+	}
+
+	server := mcp.NewServer(&mcp.Implementation{}, &mcp.ServerOptions{})
+	RegisterDeviceTools(server, mockClient, NewDeviceResolver(mockClient))
+
+	result, text := callTool(t, server, "get_device_details", map[string]any{
+		"deviceID": "device123",
+		"fields":   []any{"clientVersion", "enabledRoutes"},
+	})
+	if result.IsError {
+		t.Fatalf("Expected success, got error: %s", text)
+	}
+
+	var output map[string]any
+	if err := json.Unmarshal([]byte(text), &output); err != nil {
+		t.Fatalf("Failed to unmarshal output: %v", err)
+	}
+	if len(output) != 2 || output["clientVersion"] != "1.80.0" {
+		t.Errorf("Unexpected projection: %s", text)
+	}
 }
 
 func TestGetDeviceDetailsInvalidID(t *testing.T) {
@@ -110,7 +288,54 @@ func TestGetDeviceRoutesSuccess(t *testing.T) {
 
 	impl := &mcp.Implementation{}
 	server := mcp.NewServer(impl, &mcp.ServerOptions{})
-	RegisterDeviceTools(server, mockClient)
+	RegisterDeviceTools(server, mockClient, NewDeviceResolver(mockClient))
+
+	result, text := callTool(t, server, "get_device_routes", map[string]any{"deviceID": "test-device"})
+	if result.IsError {
+		t.Fatalf("Expected success, got error: %s", text)
+	}
+
+	var routes tailscale.DeviceRoutes
+	if err := json.Unmarshal([]byte(text), &routes); err != nil {
+		t.Fatalf("Failed to unmarshal routes: %v", err)
+	}
+	if len(routes.Advertised) != 1 || routes.Advertised[0] != "10.0.0.0/24" {
+		t.Errorf("Unexpected advertised routes %v", routes.Advertised)
+	}
+}
+
+func TestDeviceToolsResolveReferences(t *testing.T) {
+	var requested []string
+	mockClient := &internal.MockTailscaleClient{
+		DevicesFunc: func() internal.DevicesResource {
+			return &internal.MockDevicesResource{
+				ListFunc: func(ctx context.Context) ([]tailscale.Device, error) {
+					return testDevices(), nil
+				},
+				SubnetRoutesFunc: func(ctx context.Context, deviceID string) (*tailscale.DeviceRoutes, error) {
+					requested = append(requested, deviceID)
+					return &tailscale.DeviceRoutes{}, nil
+				},
+			}
+		},
+	}
+
+	server := mcp.NewServer(&mcp.Implementation{}, &mcp.ServerOptions{})
+	RegisterDeviceTools(server, mockClient, NewDeviceResolver(mockClient))
+
+	for _, ref := range []string{"db-2", "db-2.example.ts.net", "100.101.2.4"} {
+		if result, text := callTool(t, server, "get_device_routes", map[string]any{"deviceID": ref}); result.IsError {
+			t.Fatalf("Expected success for %q, got error: %s", ref, text)
+		}
+	}
+	if !slices.Equal(requested, []string{"2", "2", "2"}) {
+		t.Errorf("Expected every reference to resolve to device 2, got %v", requested)
+	}
+
+	result, text := callTool(t, server, "get_device_routes", map[string]any{"deviceID": "100.99.99.99"})
+	if !result.IsError || !strings.Contains(text, "no device matches") {
+		t.Errorf("Expected resolution error, got %s", text)
+	}
 }
 
 func TestGetDeviceRoutesError(t *testing.T) {
@@ -128,7 +353,472 @@ func TestGetDeviceRoutesError(t *testing.T) {
 
 	impl := &mcp.Implementation{}
 	server := mcp.NewServer(impl, &mcp.ServerOptions{})
-	RegisterDeviceTools(server, mockClient)
+	RegisterDeviceTools(server, mockClient, NewDeviceResolver(mockClient))
+
+	result, text := callTool(t, server, "get_device_routes", map[string]any{"deviceID": "test-device"})
+	if !result.IsError {
+		t.Fatal("Expected error result")
+	}
+	if !strings.Contains(text, "Failed to get device routes") {
+		t.Errorf("Unexpected error text %q", text)
+	}
+}
+
+func TestSetDeviceRoutes(t *testing.T) {
+	var setRoutes []string
+	routes := &tailscale.DeviceRoutes{
+		Advertised: []string{"10.0.0.0/24", "192.168.1.0/24", "0.0.0.0/0", "::/0"},
+		Enabled:    []string{"10.0.0.0/24"},
+	}
+
+	mockDevices := &internal.MockDevicesResource{
+		SubnetRoutesFunc: func(ctx context.Context, deviceID string) (*tailscale.DeviceRoutes, error) {
+			return routes, nil
+		},
+		SetSubnetRoutesFunc: func(ctx context.Context, deviceID string, r []string) error {
+			setRoutes = r
+			routes = &tailscale.DeviceRoutes{Advertised: routes.Advertised, Enabled: r}
+			return nil
+		},
+	}
+
+	mockClient := &internal.MockTailscaleClient{
+		DevicesFunc: func() internal.DevicesResource {
+			return mockDevices
+		},
+	}
+
+	server := mcp.NewServer(&mcp.Implementation{}, &mcp.ServerOptions{})
+	RegisterDeviceTools(server, mockClient, NewDeviceResolver(mockClient))
+
+	result, text := callTool(t, server, "set_device_routes", map[string]any{
+		"deviceID": "test-device",
+		"enable":   []any{"192.168.1.0/24"},
+		"disable":  []any{"10.0.0.0/24"},
+	})
+	if result.IsError {
+		t.Fatalf("Expected success, got error: %s", text)
+	}
+
+	if len(setRoutes) != 1 || setRoutes[0] != "192.168.1.0/24" {
+		t.Errorf("Expected routes [192.168.1.0/24] to be set, got %v", setRoutes)
+	}
+	if !strings.Contains(text, `"previousEnabledRoutes"`) {
+		t.Errorf("Expected previous routes in output, got %s", text)
+	}
+
+	result, text = callTool(t, server, "set_device_routes", map[string]any{
+		"deviceID": "test-device",
+		"enable":   []any{"172.16.0.0/12"},
+	})
+	if !result.IsError || !strings.Contains(text, "not advertised") {
+		t.Errorf("Expected not advertised error, got %s", text)
+	}
+
+	result, text = callTool(t, server, "set_device_routes", map[string]any{"deviceID": "test-device"})
+	if !result.IsError || !strings.Contains(text, "at least one of enable or disable") {
+		t.Errorf("Expected missing changes error, got %s", text)
+	}
+}
+
+func TestApplyRouteChanges(t *testing.T) {
+	routes := &tailscale.DeviceRoutes{
+		Advertised: []string{"10.0.0.0/24", "192.168.1.0/24", "0.0.0.0/0"},
+		Enabled:    []string{"10.0.0.0/24", "172.16.0.0/16"},
+	}
+
+	testCases := []struct {
+		name      string
+		enable    []string
+		disable   []string
+		expected  []string
+		expectErr string
+	}{
+		{
+			name:     "EnableAdvertised",
+			enable:   []string{"192.168.1.0/24"},
+			expected: []string{"10.0.0.0/24", "172.16.0.0/16", "192.168.1.0/24"},
+		},
+		{
+			name:     "EnableAlreadyEnabled",
+			enable:   []string{"10.0.0.0/24"},
+			expected: []string{"10.0.0.0/24", "172.16.0.0/16"},
+		},
+		{
+			name:     "EnableExitNode",
+			enable:   []string{"0.0.0.0/0"},
+			expected: []string{"10.0.0.0/24", "172.16.0.0/16", "0.0.0.0/0"},
+		},
+		{
+			name:     "DisablePreEnabled",
+			disable:  []string{"172.16.0.0/16"},
+			expected: []string{"10.0.0.0/24"},
+		},
+		{
+			name:      "EnableNotAdvertised",
+			enable:    []string{"10.1.0.0/24"},
+			expectErr: "not advertised",
+		},
+		{
+			name:      "DisableUnknown",
+			disable:   []string{"10.1.0.0/24"},
+			expectErr: "neither advertised nor enabled",
+		},
+		{
+			name:      "InvalidCIDR",
+			enable:    []string{"10.0.0.0"},
+			expectErr: "invalid CIDR",
+		},
+		{
+			name:      "HostBitsSet",
+			enable:    []string{"10.0.0.1/24"},
+			expectErr: "did you mean 10.0.0.0/24",
+		},
+		{
+			name:      "EnableAndDisable",
+			enable:    []string{"10.0.0.0/24"},
+			disable:   []string{"10.0.0.0/24"},
+			expectErr: "both enabled and disabled",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			enabled, err := applyRouteChanges(routes, tc.enable, tc.disable)
+			if tc.expectErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectErr) {
+					t.Fatalf("Expected error containing %q, got %v", tc.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if !slices.Equal(enabled, tc.expected) {
+				t.Errorf("Expected %v, got %v", tc.expected, enabled)
+			}
+		})
+	}
+}
+
+func TestAuthorizeDevice(t *testing.T) {
+	device := &tailscale.Device{ID: "pending-device", Name: "new-laptop", Authorized: false}
+	var calls []bool
+
+	mockDevices := &internal.MockDevicesResource{
+		GetWithAllFieldsFunc: func(ctx context.Context, deviceID string) (*tailscale.Device, error) {
+			copied := *device
+			return &copied, nil
+		},
+		SetAuthorizedFunc: func(ctx context.Context, deviceID string, authorized bool) error {
+			calls = append(calls, authorized)
+			device.Authorized = authorized
+			return nil
+		},
+	}
+
+	mockClient := &internal.MockTailscaleClient{
+		DevicesFunc: func() internal.DevicesResource {
+			return mockDevices
+		},
+	}
+
+	server := mcp.NewServer(&mcp.Implementation{}, &mcp.ServerOptions{})
+	RegisterDeviceTools(server, mockClient, NewDeviceResolver(mockClient))
+
+	result, text := callTool(t, server, "authorize_device", map[string]any{"deviceID": "pending-device"})
+	if result.IsError {
+		t.Fatalf("Expected success, got error: %s", text)
+	}
+
+	var output struct {
+		Changed bool `json:"changed"`
+		Before  struct {
+			Authorized bool `json:"authorized"`
+		} `json:"before"`
+		After struct {
+			Authorized bool `json:"authorized"`
+		} `json:"after"`
+	}
+	if err := json.Unmarshal([]byte(text), &output); err != nil {
+		t.Fatalf("Failed to unmarshal output: %v", err)
+	}
+	if !output.Changed || output.Before.Authorized || !output.After.Authorized {
+		t.Errorf("Unexpected authorization output: %s", text)
+	}
+
+	// Authorizing again is a no-op that does not call the API
+	result, text = callTool(t, server, "authorize_device", map[string]any{"deviceID": "pending-device"})
+	if result.IsError {
+		t.Fatalf("Expected success, got error: %s", text)
+	}
+	if len(calls) != 1 {
+		t.Errorf("Expected 1 SetAuthorized call, got %d", len(calls))
+	}
+
+	result, text = callTool(t, server, "deauthorize_device", map[string]any{"deviceID": "pending-device"})
+	if result.IsError {
+		t.Fatalf("Expected success, got error: %s", text)
+	}
+	if len(calls) != 2 || calls[1] {
+		t.Errorf("Expected deauthorize call, got %v", calls)
+	}
+}
+
+func TestAuthorizeDeviceError(t *testing.T) {
+	mockDevices := &internal.MockDevicesResource{
+		SetAuthorizedFunc: func(ctx context.Context, deviceID string, authorized bool) error {
+			return fmt.Errorf("API error: forbidden")
+		},
+	}
+
+	mockClient := &internal.MockTailscaleClient{
+		DevicesFunc: func() internal.DevicesResource {
+			return mockDevices
+		},
+	}
+
+	server := mcp.NewServer(&mcp.Implementation{}, &mcp.ServerOptions{})
+	RegisterDeviceTools(server, mockClient, NewDeviceResolver(mockClient))
+
+	result, text := callTool(t, server, "authorize_device", map[string]any{"deviceID": "device1"})
+	if !result.IsError || !strings.Contains(text, "Failed to update device authorization") {
+		t.Errorf("Expected authorization error, got %s", text)
+	}
+
+	result, text = callTool(t, server, "authorize_device", map[string]any{"deviceID": "invalid"})
+	if !result.IsError || !strings.Contains(text, "Failed to get device details") {
+		t.Errorf("Expected lookup error, got %s", text)
+	}
+}
+
+func TestSetDeviceTags(t *testing.T) {
+	var setTags []string
+
+	mockDevices := &internal.MockDevicesResource{
+		GetWithAllFieldsFunc: func(ctx context.Context, deviceID string) (*tailscale.Device, error) {
+			return &tailscale.Device{ID: deviceID, Name: "db-1", Tags: []string{"tag:prod"}}, nil
+		},
+		SetTagsFunc: func(ctx context.Context, deviceID string, tags []string) error {
+			setTags = tags
+			return nil
+		},
+	}
+
+	mockPolicyFile := &internal.MockPolicyFileResource{
+		GetFunc: func(ctx context.Context) (*tailscale.ACL, error) {
+			return &tailscale.ACL{
+				TagOwners: map[string][]string{
+					"tag:prod": {"group:ops"},
+					"tag:db":   {"group:ops"},
+				},
+			}, nil
+		},
+	}
+
+	mockClient := &internal.MockTailscaleClient{
+		DevicesFunc: func() internal.DevicesResource {
+			return mockDevices
+		},
+		PolicyFileFunc: func() internal.PolicyFileResource {
+			return mockPolicyFile
+		},
+	}
+
+	server := mcp.NewServer(&mcp.Implementation{}, &mcp.ServerOptions{})
+	RegisterDeviceTools(server, mockClient, NewDeviceResolver(mockClient))
+
+	testCases := []struct {
+		name      string
+		args      map[string]any
+		expected  []string
+		expectErr string
+	}{
+		{
+			name:     "Add",
+			args:     map[string]any{"deviceID": "device1", "add": []any{"tag:db"}},
+			expected: []string{"tag:prod", "tag:db"},
+		},
+		{
+			name:     "Remove",
+			args:     map[string]any{"deviceID": "device1", "remove": []any{"tag:prod"}},
+			expected: []string{},
+		},
+		{
+			name:     "Replace",
+			args:     map[string]any{"deviceID": "device1", "tags": []any{"tag:db"}},
+			expected: []string{"tag:db"},
+		},
+		{
+			name:      "Undeclared",
+			args:      map[string]any{"deviceID": "device1", "add": []any{"tag:web"}},
+			expectErr: "tags not declared in the policy's tagOwners: tag:web",
+		},
+		{
+			name:      "ReplaceAndAdd",
+			args:      map[string]any{"deviceID": "device1", "tags": []any{"tag:db"}, "add": []any{"tag:prod"}},
+			expectErr: "tags cannot be combined with add or remove",
+		},
+		{
+			name:      "NoChanges",
+			args:      map[string]any{"deviceID": "device1"},
+			expectErr: "one of tags, add, or remove is required",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			setTags = nil
+			result, text := callTool(t, server, "set_device_tags", tc.args)
+			if tc.expectErr != "" {
+				if !result.IsError || !strings.Contains(text, tc.expectErr) {
+					t.Errorf("Expected error containing %q, got %s", tc.expectErr, text)
+				}
+				if setTags != nil {
+					t.Errorf("Expected SetTags not to be called, got %v", setTags)
+				}
+				return
+			}
+			if result.IsError {
+				t.Fatalf("Expected success, got error: %s", text)
+			}
+			if !slices.Equal(setTags, tc.expected) {
+				t.Errorf("Expected tags %v, got %v", tc.expected, setTags)
+			}
+		})
+	}
+}
+
+func TestMergeTags(t *testing.T) {
+	tags := mergeTags([]string{"tag:a", "tag:b"}, []string{"tag:b", "tag:c"}, []string{"tag:a"})
+	expected := []string{"tag:b", "tag:c"}
+	if !slices.Equal(tags, expected) {
+		t.Errorf("Expected %v, got %v", expected, tags)
+	}
+}
+
+func TestRenameDevice(t *testing.T) {
+	name := "old-name.example.ts.net"
+	mockDevices := &internal.MockDevicesResource{
+		GetWithAllFieldsFunc: func(ctx context.Context, deviceID string) (*tailscale.Device, error) {
+			return &tailscale.Device{ID: deviceID, Name: name}, nil
+		},
+		SetNameFunc: func(ctx context.Context, deviceID, newName string) error {
+			name = newName + ".example.ts.net"
+			return nil
+		},
+	}
+
+	mockClient := &internal.MockTailscaleClient{
+		DevicesFunc: func() internal.DevicesResource {
+			return mockDevices
+		},
+	}
+
+	server := mcp.NewServer(&mcp.Implementation{}, &mcp.ServerOptions{})
+	RegisterDeviceTools(server, mockClient, NewDeviceResolver(mockClient))
+
+	result, text := callTool(t, server, "rename_device", map[string]any{"deviceID": "device1", "name": "db-1"})
+	if result.IsError {
+		t.Fatalf("Expected success, got error: %s", text)
+	}
+	if !strings.Contains(text, `"previousName": "old-name.example.ts.net"`) || !strings.Contains(text, `"name": "db-1.example.ts.net"`) {
+		t.Errorf("Unexpected rename output: %s", text)
+	}
+
+	result, text = callTool(t, server, "rename_device", map[string]any{"deviceID": "device1", "name": "bad name"})
+	if !result.IsError || !strings.Contains(text, "Device name validation failed") {
+		t.Errorf("Expected validation error, got %s", text)
+	}
+}
+
+func TestDeleteDeviceRequiresConfirmation(t *testing.T) {
+	var deleted []string
+	mockDevices := &internal.MockDevicesResource{
+		GetWithAllFieldsFunc: func(ctx context.Context, deviceID string) (*tailscale.Device, error) {
+			return &tailscale.Device{ID: deviceID, Name: "ephemeral-runner.example.ts.net"}, nil
+		},
+		DeleteFunc: func(ctx context.Context, deviceID string) error {
+			deleted = append(deleted, deviceID)
+			return nil
+		},
+	}
+
+	mockClient := &internal.MockTailscaleClient{
+		DevicesFunc: func() internal.DevicesResource {
+			return mockDevices
+		},
+	}
+
+	server := mcp.NewServer(&mcp.Implementation{}, &mcp.ServerOptions{})
+	RegisterDeviceTools(server, mockClient, NewDeviceResolver(mockClient))
+
+	result, text := callTool(t, server, "delete_device", map[string]any{"deviceID": "device1", "confirm": "other-runner"})
+	if !result.IsError || !strings.Contains(text, "Deletion not confirmed") {
+		t.Errorf("Expected confirmation error, got %s", text)
+	}
+	if len(deleted) != 0 {
+		t.Fatalf("Expected no deletion, got %v", deleted)
+	}
+
+	for _, confirm := range []string{"ephemeral-runner", "ephemeral-runner.example.ts.net"} {
+		result, text = callTool(t, server, "delete_device", map[string]any{"deviceID": "device1", "confirm": confirm})
+		if result.IsError {
+			t.Fatalf("Expected success confirming with %q, got error: %s", confirm, text)
+		}
+	}
+	if len(deleted) != 2 {
+		t.Errorf("Expected 2 deletions, got %v", deleted)
+	}
+}
+
+func TestSetDeviceKeyExpiry(t *testing.T) {
+	var disabled *bool
+	mockDevices := &internal.MockDevicesResource{
+		GetWithAllFieldsFunc: func(ctx context.Context, deviceID string) (*tailscale.Device, error) {
+			device := &tailscale.Device{ID: deviceID, Name: "server-1"}
+			if disabled != nil {
+				device.KeyExpiryDisabled = *disabled
+			}
+			return device, nil
+		},
+		SetKeyFunc: func(ctx context.Context, deviceID string, key tailscale.DeviceKey) error {
+			disabled = &key.KeyExpiryDisabled
+			return nil
+		},
+	}
+
+	mockClient := &internal.MockTailscaleClient{
+		DevicesFunc: func() internal.DevicesResource {
+			return mockDevices
+		},
+	}
+
+	server := mcp.NewServer(&mcp.Implementation{}, &mcp.ServerOptions{})
+	RegisterDeviceTools(server, mockClient, NewDeviceResolver(mockClient))
+
+	result, text := callTool(t, server, "set_device_key_expiry", map[string]any{"deviceID": "device1", "keyExpiryDisabled": true})
+	if result.IsError {
+		t.Fatalf("Expected success, got error: %s", text)
+	}
+	if disabled == nil || !*disabled {
+		t.Fatal("Expected key expiry to be disabled")
+	}
+
+	var output struct {
+		Before struct {
+			KeyExpiryDisabled bool `json:"keyExpiryDisabled"`
+		} `json:"before"`
+		After struct {
+			KeyExpiryDisabled bool `json:"keyExpiryDisabled"`
+		} `json:"after"`
+	}
+	if err := json.Unmarshal([]byte(text), &output); err != nil {
+		t.Fatalf("Failed to unmarshal output: %v", err)
+	}
+	if output.Before.KeyExpiryDisabled || !output.After.KeyExpiryDisabled {
+		t.Errorf("Unexpected key expiry output: %s", text)
+	}
 }
 
 // Test helper functions
@@ -0,0 +1,189 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// diffContextLines is the number of unchanged lines shown around each change in a unified diff
+	diffContextLines = 3
+	// maxLCSCells bounds the size of the LCS table, about 4 MB. Larger changed regions, such as
+	// a policy rewritten or reformatted throughout, are shown as a single removal and addition.
+	maxLCSCells = 1 << 20
+)
+
+type diffOp struct {
+	kind byte // ' ', '-', or '+'
+	// line includes its trailing newline, except for a last line that has none
+	line string
+}
+
+// unifiedDiff returns a unified diff turning before into after, or "" if they are equal
+func unifiedDiff(beforeName, afterName, before, after string) string {
+	if before == after {
+		return ""
+	}
+
+	ops := diffLines(splitLines(before), splitLines(after))
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", beforeName, afterName)
+
+	// Line numbers (1-based) in before and after at the start of each op
+	beforeLine, afterLine := make([]int, len(ops)+1), make([]int, len(ops)+1)
+	beforeLine[0], afterLine[0] = 1, 1
+	for i, op := range ops {
+		beforeLine[i+1], afterLine[i+1] = beforeLine[i], afterLine[i]
+		if op.kind != '+' {
+			beforeLine[i+1]++
+		}
+		if op.kind != '-' {
+			afterLine[i+1]++
+		}
+	}
+
+	for start := 0; start < len(ops); {
+		// Find the next change and extend the hunk while changes are within twice the context
+		first := start
+		for first < len(ops) && ops[first].kind == ' ' {
+			first++
+		}
+		if first == len(ops) {
+			break
+		}
+
+		last := first
+		for i := first; i < len(ops); i++ {
+			if ops[i].kind != ' ' {
+				last = i
+			} else if i-last > 2*diffContextLines {
+				break
+			}
+		}
+
+		from := max(first-diffContextLines, start)
+		to := min(last+diffContextLines+1, len(ops))
+
+		beforeCount, afterCount := 0, 0
+		for _, op := range ops[from:to] {
+			if op.kind != '+' {
+				beforeCount++
+			}
+			if op.kind != '-' {
+				afterCount++
+			}
+		}
+
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n",
+			hunkRange(beforeLine[from], beforeCount), hunkRange(afterLine[from], afterCount))
+		for _, op := range ops[from:to] {
+			out.WriteByte(op.kind)
+			out.WriteString(op.line)
+			if !strings.HasSuffix(op.line, "\n") {
+				out.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+
+		start = to
+	}
+
+	return out.String()
+}
+
+// hunkRange formats the start,count range of a hunk. Empty ranges refer to the line before.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		start--
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// splitLines splits text into lines that keep their newline, so that a missing newline at
+// the end of the text shows up as a change to the last line
+func splitLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines computes a line edit script from a to b. Common leading and trailing lines are
+// trimmed first, which keeps typical policy edits cheap, and the lines between are aligned with
+// lcsDiff when they fit within maxLCSCells.
+func diffLines(a, b []string) []diffOp {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	ops := make([]diffOp, 0, len(a)+len(b))
+	for _, line := range a[:prefix] {
+		ops = append(ops, diffOp{' ', line})
+	}
+
+	midA, midB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	if len(midA)*len(midB) > maxLCSCells {
+		for _, line := range midA {
+			ops = append(ops, diffOp{'-', line})
+		}
+		for _, line := range midB {
+			ops = append(ops, diffOp{'+', line})
+		}
+	} else {
+		ops = append(ops, lcsDiff(midA, midB)...)
+	}
+
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, diffOp{' ', line})
+	}
+
+	return ops
+}
+
+// lcsDiff computes a line edit script from a to b using the longest common subsequence, in
+// O(len(a)*len(b)) time and space
+func lcsDiff(a, b []string) []diffOp {
+	ops := make([]diffOp, 0, len(a)+len(b))
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int32, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i++
+			j++
+		case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+
+	return ops
+}
@@ -0,0 +1,130 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	testCases := []struct {
+		name     string
+		before   string
+		after    string
+		expected string
+	}{
+		{name: "Equal", before: "a\nb\n", after: "a\nb\n", expected: ""},
+		{
+			name:   "ChangeLine",
+			before: "a\nb\nc\n",
+			after:  "a\nB\nc\n",
+			expected: "--- current\n+++ proposed\n" +
+				"@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n",
+		},
+		{
+			name:   "InsertAtStart",
+			before: "b\n",
+			after:  "a\nb\n",
+			expected: "--- current\n+++ proposed\n" +
+				"@@ -1 +1,2 @@\n+a\n b\n",
+		},
+		{
+			name:   "FromEmpty",
+			before: "",
+			after:  "a\n",
+			expected: "--- current\n+++ proposed\n" +
+				"@@ -0,0 +1 @@\n+a\n",
+		},
+		{
+			name:   "AddNewlineAtEnd",
+			before: "a\nb",
+			after:  "a\nb\n",
+			expected: "--- current\n+++ proposed\n" +
+				"@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+b\n",
+		},
+		{
+			name:   "RemoveNewlineAtEnd",
+			before: "a\n",
+			after:  "a",
+			expected: "--- current\n+++ proposed\n" +
+				"@@ -1 +1 @@\n-a\n+a\n\\ No newline at end of file\n",
+		},
+		{
+			name:   "DeleteAtEnd",
+			before: "a\nb\nc\nd\ne\n",
+			after:  "a\nb\nc\nd\n",
+			expected: "--- current\n+++ proposed\n" +
+				"@@ -2,4 +2,3 @@\n b\n c\n d\n-e\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := unifiedDiff("current", "proposed", tc.before, tc.after); got != tc.expected {
+				t.Errorf("Expected:\n%s\ngot:\n%s", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestUnifiedDiffSeparateHunks(t *testing.T) {
+	var before, after []string
+	for i := 1; i <= 20; i++ {
+		line := fmt.Sprintf("line %d", i)
+		before = append(before, line)
+		switch i {
+		case 2:
+			after = append(after, "changed 2")
+		case 18:
+			after = append(after, "changed 18")
+		default:
+			after = append(after, line)
+		}
+	}
+
+	diff := unifiedDiff("current", "proposed", strings.Join(before, "\n"), strings.Join(after, "\n"))
+
+	if strings.Count(diff, "@@ -") != 2 {
+		t.Fatalf("Expected two hunks, got:\n%s", diff)
+	}
+	for _, header := range []string{"@@ -1,5 +1,5 @@", "@@ -15,6 +15,6 @@"} {
+		if !strings.Contains(diff, header) {
+			t.Errorf("Expected hunk header %s in:\n%s", header, diff)
+		}
+	}
+	if strings.Contains(diff, " line 10\n") {
+		t.Errorf("Expected lines far from changes to be omitted:\n%s", diff)
+	}
+}
+
+func TestDiffLinesLargeRewrite(t *testing.T) {
+	// Every middle line changes except one, and the middle is too large to align line by line
+	const size = 1100
+	before, after := []string{"head"}, []string{"head"}
+	for i := range size {
+		before = append(before, fmt.Sprintf("old %d", i))
+		if i == size/2 {
+			after = append(after, before[len(before)-1])
+		} else {
+			after = append(after, fmt.Sprintf("new %d", i))
+		}
+	}
+	before, after = append(before, "tail"), append(after, "tail")
+	if size*size <= maxLCSCells {
+		t.Fatalf("Expected %d lines to exceed the LCS limit", size)
+	}
+
+	ops := diffLines(before, after)
+
+	var kinds strings.Builder
+	for _, op := range ops {
+		kinds.WriteByte(op.kind)
+	}
+	expected := " " + strings.Repeat("-", size) + strings.Repeat("+", size) + " "
+	if kinds.String() != expected {
+		t.Errorf("Expected the changed region as one removal and addition, got %d ops", len(ops))
+	}
+	if ops[size].line != "old 1099" || ops[size+1].line != "new 0" {
+		t.Errorf("Expected removed lines before added lines, got %q then %q", ops[size].line, ops[size+1].line)
+	}
+}
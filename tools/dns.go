@@ -0,0 +1,408 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	tailscale "tailscale.com/client/tailscale/v2"
+
+	"github.com/R167/tailscale-mcp/internal"
+)
+
+func RegisterDNSTools(server *mcp.Server, client internal.TailscaleClient) {
+	// Get DNS config tool
+	mcp.AddTool(
+		server,
+		&mcp.Tool{
+			Name:        "get_dns_config",
+			Description: "Get the tailnet's DNS configuration: global nameservers, search paths, split DNS, and whether MagicDNS is enabled",
+			InputSchema: &jsonschema.Schema{
+				Type:                 "object",
+				AdditionalProperties: &jsonschema.Schema{Not: &jsonschema.Schema{}},
+			},
+		},
+		func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
+			nameservers, err := client.DNS().Nameservers(ctx)
+			if err != nil {
+				return toolError("Failed to get DNS nameservers", err), nil, nil
+			}
+
+			searchPaths, err := client.DNS().SearchPaths(ctx)
+			if err != nil {
+				return toolError("Failed to get DNS search paths", err), nil, nil
+			}
+
+			splitDNS, err := client.DNS().SplitDNS(ctx)
+			if err != nil {
+				return toolError("Failed to get split DNS", err), nil, nil
+			}
+
+			preferences, err := client.DNS().Preferences(ctx)
+			if err != nil {
+				return toolError("Failed to get DNS preferences", err), nil, nil
+			}
+
+			result := struct {
+				Nameservers []string            `json:"nameservers"`
+				SearchPaths []string            `json:"searchPaths"`
+				SplitDNS    map[string][]string `json:"splitDNS"`
+				MagicDNS    bool                `json:"magicDNS"`
+			}{
+				Nameservers: emptyIfNil(nameservers),
+				SearchPaths: emptyIfNil(searchPaths),
+				SplitDNS:    splitDNSMap(splitDNS),
+				MagicDNS:    preferences.MagicDNS,
+			}
+
+			output, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				return toolError("Failed to serialize DNS configuration", err), nil, nil
+			}
+
+			return toolSuccess(string(output)), nil, nil
+		},
+	)
+
+	// Set DNS nameservers tool
+	mcp.AddTool(
+		server,
+		&mcp.Tool{
+			Name:        "set_dns_nameservers",
+			Description: "Replace the tailnet's global DNS nameservers. An empty list removes them all.",
+			InputSchema: &jsonschema.Schema{
+				Type: "object",
+				Properties: map[string]*jsonschema.Schema{
+					"nameservers": {
+						Type:        "array",
+						Description: "Nameserver IPv4 or IPv6 addresses, in the order they should be used",
+						Items:       &jsonschema.Schema{Type: "string"},
+					},
+				},
+				Required:             []string{"nameservers"},
+				AdditionalProperties: &jsonschema.Schema{Not: &jsonschema.Schema{}},
+			},
+		},
+		func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
+			nameservers, err := getOptionalStringSliceParam(args, "nameservers")
+			if err != nil {
+				return toolError("Invalid nameservers parameter", err), nil, nil
+			}
+
+			nameservers, err = normalizeNameservers(nameservers)
+			if err != nil {
+				return toolError("Invalid nameservers parameter", err), nil, nil
+			}
+
+			previous, err := client.DNS().Nameservers(ctx)
+			if err != nil {
+				return toolError("Failed to get DNS nameservers", err), nil, nil
+			}
+
+			if err := client.DNS().SetNameservers(ctx, nameservers); err != nil {
+				return toolError("Failed to set DNS nameservers", err), nil, nil
+			}
+
+			result := struct {
+				Previous    []string `json:"previous"`
+				Nameservers []string `json:"nameservers"`
+			}{
+				Previous:    emptyIfNil(previous),
+				Nameservers: nameservers,
+			}
+
+			output, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				return toolError("Failed to serialize DNS nameservers", err), nil, nil
+			}
+
+			return toolSuccess(string(output)), nil, nil
+		},
+	)
+
+	// Set DNS search paths tool
+	mcp.AddTool(
+		server,
+		&mcp.Tool{
+			Name:        "set_dns_search_paths",
+			Description: "Replace the tailnet's DNS search paths. An empty list removes them all.",
+			InputSchema: &jsonschema.Schema{
+				Type: "object",
+				Properties: map[string]*jsonschema.Schema{
+					"searchPaths": {
+						Type:        "array",
+						Description: "Domains to search for unqualified names (e.g. corp.example.com)",
+						Items:       &jsonschema.Schema{Type: "string"},
+					},
+				},
+				Required:             []string{"searchPaths"},
+				AdditionalProperties: &jsonschema.Schema{Not: &jsonschema.Schema{}},
+			},
+		},
+		func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
+			searchPaths, err := getOptionalStringSliceParam(args, "searchPaths")
+			if err != nil {
+				return toolError("Invalid searchPaths parameter", err), nil, nil
+			}
+
+			searchPaths, err = normalizeDomains(searchPaths)
+			if err != nil {
+				return toolError("Invalid searchPaths parameter", err), nil, nil
+			}
+
+			previous, err := client.DNS().SearchPaths(ctx)
+			if err != nil {
+				return toolError("Failed to get DNS search paths", err), nil, nil
+			}
+
+			if err := client.DNS().SetSearchPaths(ctx, searchPaths); err != nil {
+				return toolError("Failed to set DNS search paths", err), nil, nil
+			}
+
+			result := struct {
+				Previous    []string `json:"previous"`
+				SearchPaths []string `json:"searchPaths"`
+			}{
+				Previous:    emptyIfNil(previous),
+				SearchPaths: searchPaths,
+			}
+
+			output, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				return toolError("Failed to serialize DNS search paths", err), nil, nil
+			}
+
+			return toolSuccess(string(output)), nil, nil
+		},
+	)
+
+	// Set split DNS tool
+	mcp.AddTool(
+		server,
+		&mcp.Tool{
+			Name: "set_split_dns",
+			Description: "Set the nameservers used for specific domains. By default only the given domains change and an " +
+				"empty nameserver list removes a domain; with replace, the given domains become the whole split DNS configuration.",
+			InputSchema: &jsonschema.Schema{
+				Type: "object",
+				Properties: map[string]*jsonschema.Schema{
+					"domains": {
+						Type:        "object",
+						Description: "Map of domain (e.g. corp.example.com) to nameserver IP addresses",
+						AdditionalProperties: &jsonschema.Schema{
+							Type:  "array",
+							Items: &jsonschema.Schema{Type: "string"},
+						},
+					},
+					"replace": {
+						Type:        "boolean",
+						Description: "Replace the whole split DNS configuration instead of updating only these domains (default: false)",
+					},
+				},
+				Required:             []string{"domains"},
+				AdditionalProperties: &jsonschema.Schema{Not: &jsonschema.Schema{}},
+			},
+		},
+		func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
+			request, err := getSplitDNSParam(args, "domains")
+			if err != nil {
+				return toolError("Invalid domains parameter", err), nil, nil
+			}
+
+			replace, err := getOptionalBoolParam(args, "replace")
+			if err != nil {
+				return toolError("Invalid replace parameter", err), nil, nil
+			}
+
+			previous, err := client.DNS().SplitDNS(ctx)
+			if err != nil {
+				return toolError("Failed to get split DNS", err), nil, nil
+			}
+
+			var updated tailscale.SplitDNSResponse
+			if replace != nil && *replace {
+				for domain, nameservers := range request {
+					if len(nameservers) == 0 {
+						delete(request, domain)
+					}
+				}
+				if err := client.DNS().SetSplitDNS(ctx, request); err != nil {
+					return toolError("Failed to set split DNS", err), nil, nil
+				}
+				updated = tailscale.SplitDNSResponse(request)
+			} else {
+				if len(request) == 0 {
+					return toolError("Invalid domains parameter", fmt.Errorf("at least one domain is required unless replace is set")), nil, nil
+				}
+				updated, err = client.DNS().UpdateSplitDNS(ctx, request)
+				if err != nil {
+					return toolError("Failed to update split DNS", err), nil, nil
+				}
+			}
+
+			result := struct {
+				Previous map[string][]string `json:"previous"`
+				SplitDNS map[string][]string `json:"splitDNS"`
+			}{
+				Previous: splitDNSMap(previous),
+				SplitDNS: splitDNSMap(updated),
+			}
+
+			output, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				return toolError("Failed to serialize split DNS", err), nil, nil
+			}
+
+			return toolSuccess(string(output)), nil, nil
+		},
+	)
+
+	// Set MagicDNS tool
+	mcp.AddTool(
+		server,
+		&mcp.Tool{
+			Name:        "set_magic_dns",
+			Description: "Enable or disable MagicDNS, which lets devices reach each other by name",
+			InputSchema: &jsonschema.Schema{
+				Type: "object",
+				Properties: map[string]*jsonschema.Schema{
+					"enabled": {
+						Type:        "boolean",
+						Description: "Whether MagicDNS should be enabled",
+					},
+				},
+				Required:             []string{"enabled"},
+				AdditionalProperties: &jsonschema.Schema{Not: &jsonschema.Schema{}},
+			},
+		},
+		func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
+			enabled, err := getBoolParam(args, "enabled")
+			if err != nil {
+				return toolError("Invalid enabled parameter", err), nil, nil
+			}
+
+			previous, err := client.DNS().Preferences(ctx)
+			if err != nil {
+				return toolError("Failed to get DNS preferences", err), nil, nil
+			}
+
+			if err := client.DNS().SetPreferences(ctx, tailscale.DNSPreferences{MagicDNS: enabled}); err != nil {
+				return toolError("Failed to set DNS preferences", err), nil, nil
+			}
+
+			result := struct {
+				Previous bool `json:"previous"`
+				MagicDNS bool `json:"magicDNS"`
+			}{
+				Previous: previous.MagicDNS,
+				MagicDNS: enabled,
+			}
+
+			output, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				return toolError("Failed to serialize DNS preferences", err), nil, nil
+			}
+
+			return toolSuccess(string(output)), nil, nil
+		},
+	)
+}
+
+// getSplitDNSParam extracts a map of domain to nameserver addresses, validating and
+// normalizing both. Domains with no nameservers map to nil.
+func getSplitDNSParam(params map[string]any, key string) (tailscale.SplitDNSRequest, error) {
+	value, ok := params[key].(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%s parameter must be an object mapping domains to nameservers", key)
+	}
+
+	request := make(tailscale.SplitDNSRequest, len(value))
+	for domain := range value {
+		normalized, err := normalizeDomain(domain)
+		if err != nil {
+			return nil, err
+		}
+		if _, exists := request[normalized]; exists {
+			return nil, fmt.Errorf("domain %s is listed more than once", normalized)
+		}
+
+		nameservers, err := getOptionalStringSliceParam(value, domain)
+		if err != nil {
+			return nil, fmt.Errorf("nameservers for %s must be an array of IP addresses", domain)
+		}
+		nameservers, err = normalizeNameservers(nameservers)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", domain, err)
+		}
+
+		if len(nameservers) == 0 {
+			// The API removes domains whose nameservers are null
+			nameservers = nil
+		}
+		request[normalized] = nameservers
+	}
+
+	return request, nil
+}
+
+// normalizeNameservers checks that every nameserver is an IP address and returns them in
+// canonical form without duplicates
+func normalizeNameservers(nameservers []string) ([]string, error) {
+	result := make([]string, 0, len(nameservers))
+	for _, nameserver := range nameservers {
+		addr, err := netip.ParseAddr(strings.TrimSpace(nameserver))
+		if err != nil || addr.Zone() != "" {
+			return nil, fmt.Errorf("invalid nameserver %q: must be an IPv4 or IPv6 address", nameserver)
+		}
+		if !slices.Contains(result, addr.String()) {
+			result = append(result, addr.String())
+		}
+	}
+	return result, nil
+}
+
+// normalizeDomains checks that every entry is a valid domain and returns them lower-cased,
+// without trailing dots or duplicates
+func normalizeDomains(domains []string) ([]string, error) {
+	result := make([]string, 0, len(domains))
+	for _, domain := range domains {
+		normalized, err := normalizeDomain(domain)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(result, normalized) {
+			result = append(result, normalized)
+		}
+	}
+	return result, nil
+}
+
+func normalizeDomain(domain string) (string, error) {
+	normalized := strings.ToLower(strings.TrimSuffix(strings.TrimSpace(domain), "."))
+	if err := validateDNSName("domain", normalized); err != nil {
+		return "", fmt.Errorf("invalid domain %q: %w", domain, err)
+	}
+	return normalized, nil
+}
+
+// splitDNSMap converts a split DNS response into a non-nil map for output
+func splitDNSMap(response tailscale.SplitDNSResponse) map[string][]string {
+	result := make(map[string][]string, len(response))
+	for domain, nameservers := range response {
+		result[domain] = emptyIfNil(nameservers)
+	}
+	return result
+}
+
+// emptyIfNil returns an empty slice instead of nil so lists serialize as []
+func emptyIfNil(items []string) []string {
+	if items == nil {
+		return []string{}
+	}
+	return items
+}
@@ -0,0 +1,184 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	tailscale "tailscale.com/client/tailscale/v2"
+
+	"github.com/R167/tailscale-mcp/internal"
+)
+
+func dnsTestServer(dns *internal.MockDNSResource) *mcp.Server {
+	client := &internal.MockTailscaleClient{
+		DNSFunc: func() internal.DNSResource { return dns },
+	}
+
+	server := mcp.NewServer(&mcp.Implementation{}, &mcp.ServerOptions{})
+	RegisterDNSTools(server, client)
+	return server
+}
+
+func TestGetDNSConfig(t *testing.T) {
+	server := dnsTestServer(&internal.MockDNSResource{
+		SearchPathsFunc: func(ctx context.Context) ([]string, error) {
+			return nil, nil
+		},
+	})
+
+	result, text := callTool(t, server, "get_dns_config", nil)
+	if result.IsError {
+		t.Fatalf("Expected success, got error: %s", text)
+	}
+
+	var output struct {
+		Nameservers []string            `json:"nameservers"`
+		SearchPaths []string            `json:"searchPaths"`
+		SplitDNS    map[string][]string `json:"splitDNS"`
+		MagicDNS    bool                `json:"magicDNS"`
+	}
+	if err := json.Unmarshal([]byte(text), &output); err != nil {
+		t.Fatalf("Failed to unmarshal output: %v", err)
+	}
+
+	if !slices.Equal(output.Nameservers, []string{"1.1.1.1", "8.8.8.8"}) || !output.MagicDNS {
+		t.Errorf("Unexpected DNS configuration: %s", text)
+	}
+	if !slices.Equal(output.SplitDNS["corp.example.com"], []string{"10.0.0.53"}) {
+		t.Errorf("Expected split DNS entries: %s", text)
+	}
+	if !strings.Contains(text, `"searchPaths": []`) {
+		t.Errorf("Expected empty search paths to be an empty list: %s", text)
+	}
+}
+
+func TestSetDNSNameservers(t *testing.T) {
+	var set []string
+	calls := 0
+	server := dnsTestServer(&internal.MockDNSResource{
+		SetNameserversFunc: func(ctx context.Context, nameservers []string) error {
+			calls++
+			set = nameservers
+			return nil
+		},
+	})
+
+	result, text := callTool(t, server, "set_dns_nameservers", map[string]any{
+		"nameservers": []any{" 9.9.9.9", "2620:fe::FE", "9.9.9.9"},
+	})
+	if result.IsError {
+		t.Fatalf("Expected success, got error: %s", text)
+	}
+	if !slices.Equal(set, []string{"9.9.9.9", "2620:fe::fe"}) {
+		t.Errorf("Expected normalized nameservers, got %v", set)
+	}
+	if !strings.Contains(text, `"previous"`) || !strings.Contains(text, "1.1.1.1") {
+		t.Errorf("Expected the previous nameservers in the output: %s", text)
+	}
+
+	result, text = callTool(t, server, "set_dns_nameservers", map[string]any{"nameservers": []any{"dns.google"}})
+	if !result.IsError || !strings.Contains(text, "must be an IPv4 or IPv6 address") {
+		t.Errorf("Expected an invalid nameserver error, got %s", text)
+	}
+	if calls != 1 {
+		t.Errorf("Expected invalid nameservers not to be sent, got %d calls", calls)
+	}
+}
+
+func TestSetDNSSearchPaths(t *testing.T) {
+	var set []string
+	server := dnsTestServer(&internal.MockDNSResource{
+		SetSearchPathsFunc: func(ctx context.Context, searchPaths []string) error {
+			set = searchPaths
+			return nil
+		},
+	})
+
+	result, text := callTool(t, server, "set_dns_search_paths", map[string]any{
+		"searchPaths": []any{"Corp.Example.com.", "lab.example.com"},
+	})
+	if result.IsError {
+		t.Fatalf("Expected success, got error: %s", text)
+	}
+	if !slices.Equal(set, []string{"corp.example.com", "lab.example.com"}) {
+		t.Errorf("Expected normalized search paths, got %v", set)
+	}
+
+	result, text = callTool(t, server, "set_dns_search_paths", map[string]any{"searchPaths": []any{"bad_domain.com"}})
+	if !result.IsError || !strings.Contains(text, "invalid domain") {
+		t.Errorf("Expected an invalid domain error, got %s", text)
+	}
+}
+
+func TestSetSplitDNS(t *testing.T) {
+	var updated, replaced tailscale.SplitDNSRequest
+	server := dnsTestServer(&internal.MockDNSResource{
+		UpdateSplitDNSFunc: func(ctx context.Context, request tailscale.SplitDNSRequest) (tailscale.SplitDNSResponse, error) {
+			updated = request
+			return tailscale.SplitDNSResponse{"lab.example.com": {"10.1.0.53"}}, nil
+		},
+		SetSplitDNSFunc: func(ctx context.Context, request tailscale.SplitDNSRequest) error {
+			replaced = request
+			return nil
+		},
+	})
+
+	result, text := callTool(t, server, "set_split_dns", map[string]any{
+		"domains": map[string]any{"Lab.Example.com": []any{"10.1.0.53"}, "corp.example.com": []any{}},
+	})
+	if result.IsError {
+		t.Fatalf("Expected success, got error: %s", text)
+	}
+	if !slices.Equal(updated["lab.example.com"], []string{"10.1.0.53"}) {
+		t.Errorf("Expected the normalized domain to be updated, got %v", updated)
+	}
+	if servers, ok := updated["corp.example.com"]; !ok || servers != nil {
+		t.Errorf("Expected an empty list to remove the domain with null, got %v", updated)
+	}
+	if replaced != nil {
+		t.Error("Expected an update not to replace the configuration")
+	}
+
+	result, text = callTool(t, server, "set_split_dns", map[string]any{
+		"domains": map[string]any{"lab.example.com": []any{"10.1.0.53"}, "corp.example.com": []any{}},
+		"replace": true,
+	})
+	if result.IsError {
+		t.Fatalf("Expected success, got error: %s", text)
+	}
+	if len(replaced) != 1 || !slices.Equal(replaced["lab.example.com"], []string{"10.1.0.53"}) {
+		t.Errorf("Expected only domains with nameservers to be kept on replace, got %v", replaced)
+	}
+
+	result, text = callTool(t, server, "set_split_dns", map[string]any{
+		"domains": map[string]any{"lab.example.com": []any{"not-an-ip"}},
+	})
+	if !result.IsError || !strings.Contains(text, "lab.example.com") {
+		t.Errorf("Expected an invalid nameserver error naming the domain, got %s", text)
+	}
+}
+
+func TestSetMagicDNS(t *testing.T) {
+	var set *tailscale.DNSPreferences
+	server := dnsTestServer(&internal.MockDNSResource{
+		SetPreferencesFunc: func(ctx context.Context, preferences tailscale.DNSPreferences) error {
+			set = &preferences
+			return nil
+		},
+	})
+
+	result, text := callTool(t, server, "set_magic_dns", map[string]any{"enabled": false})
+	if result.IsError {
+		t.Fatalf("Expected success, got error: %s", text)
+	}
+	if set == nil || set.MagicDNS {
+		t.Errorf("Expected MagicDNS to be disabled, got %+v", set)
+	}
+	if !strings.Contains(text, `"previous": true`) {
+		t.Errorf("Expected the previous preference in the output: %s", text)
+	}
+}
@@ -2,29 +2,51 @@ package tools
 
 import (
 	"fmt"
+	"math"
 	"strings"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/R167/tailscale-mcp/redact"
 )
 
+// outputRedactor masks secrets and sensitive fields in every tool response
+var outputRedactor = redact.Default
+
+// SetRedactor replaces the redactor applied to tool output. It must be called before the
+// server starts handling requests.
+func SetRedactor(r *redact.Redactor) {
+	outputRedactor = r
+}
+
 // toolError creates a standardized error response for MCP tools
-func toolError(message string, err error) *mcp.CallToolResultFor[any] {
-	return &mcp.CallToolResultFor[any]{
+func toolError(message string, err error) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
 		IsError: true,
 		Content: []mcp.Content{
 			&mcp.TextContent{
-				Text: fmt.Sprintf("%s: %v", message, err),
+				Text: outputRedactor.Text(fmt.Sprintf("%s: %v", message, err)),
 			},
 		},
 	}
 }
 
 // toolSuccess creates a standardized success response for MCP tools
-func toolSuccess(data string) *mcp.CallToolResultFor[any] {
-	return &mcp.CallToolResultFor[any]{
+func toolSuccess(data string) *mcp.CallToolResult {
+	return toolSecretSuccess(data)
+}
+
+// toolSecretSuccess creates a success response like toolSuccess that leaves the given paths
+// unredacted, for tools whose purpose is to return a secret
+func toolSecretSuccess(data string, secrets ...redact.Path) *mcp.CallToolResult {
+	redactor := outputRedactor
+	if len(secrets) > 0 {
+		redactor = redactor.Allow(secrets...)
+	}
+	return &mcp.CallToolResult{
 		Content: []mcp.Content{
 			&mcp.TextContent{
-				Text: data,
+				Text: redactor.JSON(data),
 			},
 		},
 	}
@@ -63,3 +85,160 @@ func getStringParam(params map[string]any, key string) (string, error) {
 
 	return str, nil
 }
+
+// getIDParam extracts a required resource ID, such as a key or webhook ID, that is used as a
+// segment of an API path
+func getIDParam(params map[string]any, key string) (string, error) {
+	id, err := getStringParam(params, key)
+	if err != nil {
+		return "", err
+	}
+	id = strings.TrimSpace(id)
+	if err := validateID(key, id); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// validateID checks that id, called name in errors, is safe to use as a segment of an API path
+func validateID(name, id string) error {
+	if id == "" {
+		return fmt.Errorf("%s cannot be empty", name)
+	}
+	if strings.ContainsAny(id, "/?# \t\n\r") {
+		return fmt.Errorf("%s contains invalid characters", name)
+	}
+	return nil
+}
+
+// getBoolParam safely extracts a required boolean parameter from MCP tool arguments
+func getBoolParam(params map[string]any, key string) (bool, error) {
+	value, exists := params[key]
+	if !exists {
+		return false, fmt.Errorf("%s parameter is required", key)
+	}
+
+	b, ok := value.(bool)
+	if !ok {
+		return false, fmt.Errorf("%s parameter must be a boolean", key)
+	}
+
+	return b, nil
+}
+
+// getOptionalStringParam extracts an optional string parameter from MCP tool arguments.
+// It returns the empty string when the parameter is absent.
+func getOptionalStringParam(params map[string]any, key string) (string, error) {
+	value, exists := params[key]
+	if !exists || value == nil {
+		return "", nil
+	}
+
+	str, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("%s parameter must be a string", key)
+	}
+
+	return str, nil
+}
+
+// getOptionalBoolParam extracts an optional boolean parameter from MCP tool arguments.
+// It returns nil when the parameter is absent.
+func getOptionalBoolParam(params map[string]any, key string) (*bool, error) {
+	value, exists := params[key]
+	if !exists || value == nil {
+		return nil, nil
+	}
+
+	b, ok := value.(bool)
+	if !ok {
+		return nil, fmt.Errorf("%s parameter must be a boolean", key)
+	}
+
+	return &b, nil
+}
+
+// getOptionalIntParam extracts an optional integer parameter from MCP tool arguments.
+// It returns defaultValue when the parameter is absent. Values outside the int32 range are
+// rejected, so callers can add and multiply them without overflowing.
+func getOptionalIntParam(params map[string]any, key string, defaultValue int) (int, error) {
+	value, exists := params[key]
+	if !exists || value == nil {
+		return defaultValue, nil
+	}
+
+	num, ok := value.(float64)
+	if !ok || num != math.Trunc(num) {
+		return 0, fmt.Errorf("%s parameter must be an integer", key)
+	}
+	if math.Abs(num) > math.MaxInt32 {
+		return 0, fmt.Errorf("%s parameter is out of range", key)
+	}
+
+	return int(num), nil
+}
+
+// getOptionalStringSliceParam extracts an optional string array parameter from MCP tool arguments.
+// It returns nil when the parameter is absent.
+func getOptionalStringSliceParam(params map[string]any, key string) ([]string, error) {
+	value, exists := params[key]
+	if !exists || value == nil {
+		return nil, nil
+	}
+
+	items, ok := value.([]any)
+	if !ok {
+		return nil, fmt.Errorf("%s parameter must be an array of strings", key)
+	}
+
+	result := make([]string, 0, len(items))
+	for _, item := range items {
+		str, ok := item.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s parameter must be an array of strings", key)
+		}
+		result = append(result, str)
+	}
+
+	return result, nil
+}
+
+// validateDeviceName checks that a device name is a valid DNS name made of labels of
+// letters, digits, and hyphens
+func validateDeviceName(name string) error {
+	return validateDNSName("device name", name)
+}
+
+// validateDNSName checks that name is a valid DNS name made of labels of letters, digits, and
+// hyphens, describing it as what in errors
+func validateDNSName(what, name string) error {
+	if name == "" {
+		return fmt.Errorf("%s cannot be empty", what)
+	}
+
+	if len(name) > 253 {
+		return fmt.Errorf("%s must be at most 253 characters", what)
+	}
+
+	for _, label := range strings.Split(strings.TrimSuffix(name, "."), ".") {
+		if label == "" || len(label) > 63 {
+			return fmt.Errorf("%s labels must be between 1 and 63 characters", what)
+		}
+		if strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+			return fmt.Errorf("%s labels cannot start or end with a hyphen", what)
+		}
+		for _, r := range label {
+			if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-') {
+				return fmt.Errorf("%s contains invalid character %q", what, r)
+			}
+		}
+	}
+
+	return nil
+}
+
+// shortDeviceName returns the first label of a device's MagicDNS name
+func shortDeviceName(name string) string {
+	short, _, _ := strings.Cut(name, ".")
+	return short
+}
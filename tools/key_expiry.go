@@ -0,0 +1,129 @@
+package tools
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	tailscale "tailscale.com/client/tailscale/v2"
+)
+
+// defaultKeyExpiryDays is how far ahead key_expiry_report looks for expiring keys by default
+const defaultKeyExpiryDays = 30
+
+// Key statuses reported by key_expiry_report, in the order they sort
+const (
+	keyStatusExpired  = "expired"
+	keyStatusExpiring = "expiring"
+	keyStatusHealthy  = "healthy"
+)
+
+var keyStatuses = []any{keyStatusExpired, keyStatusExpiring, keyStatusHealthy}
+
+// keyReportSorts lists the orders key_expiry_report supports, the first being the default
+var keyReportSorts = []any{"expires", "created", "status", "creator"}
+
+// KeyReportEntry describes a key's expiry without its secret
+type KeyReportEntry struct {
+	ID              string   `json:"id"`
+	KeyType         string   `json:"keyType"`
+	Description     string   `json:"description,omitempty"`
+	CreatorID       string   `json:"creatorId,omitempty"`
+	Creator         string   `json:"creator,omitempty"`
+	Tags            []string `json:"tags,omitempty"`
+	Status          string   `json:"status"`
+	Created         string   `json:"created,omitempty"`
+	Expires         string   `json:"expires,omitempty"`
+	DaysUntilExpiry *int     `json:"daysUntilExpiry,omitempty"`
+	Revoked         string   `json:"revoked,omitempty"`
+	Invalid         bool     `json:"invalid,omitempty"`
+
+	created time.Time
+	expires time.Time
+}
+
+// keyStatus classifies a key as expired, expiring within the given duration of now, or healthy.
+// Revoked and invalid keys can no longer be used, so they count as expired. Keys without an
+// expiry are healthy.
+func keyStatus(key tailscale.Key, now time.Time, within time.Duration) string {
+	switch {
+	case key.Invalid || !key.Revoked.IsZero():
+		return keyStatusExpired
+	case key.Expires.IsZero():
+		return keyStatusHealthy
+	case !key.Expires.After(now):
+		return keyStatusExpired
+	case key.Expires.Sub(now) <= within:
+		return keyStatusExpiring
+	default:
+		return keyStatusHealthy
+	}
+}
+
+// summarizeKey describes a key for reports, resolving its creator through creators, a map of
+// user ID to login name. The key secret is never copied.
+func summarizeKey(key tailscale.Key, creators map[string]string, now time.Time, within time.Duration) KeyReportEntry {
+	entry := KeyReportEntry{
+		ID:          key.ID,
+		KeyType:     key.KeyType,
+		Description: key.Description,
+		CreatorID:   key.UserID,
+		Creator:     creators[key.UserID],
+		Tags:        key.Tags,
+		Status:      keyStatus(key, now, within),
+		Invalid:     key.Invalid,
+		created:     key.Created,
+		expires:     key.Expires,
+	}
+	if len(entry.Tags) == 0 {
+		entry.Tags = key.Capabilities.Devices.Create.Tags
+	}
+	if !key.Created.IsZero() {
+		entry.Created = key.Created.Format(time.RFC3339)
+	}
+	if !key.Expires.IsZero() {
+		entry.Expires = key.Expires.Format(time.RFC3339)
+		days := int(key.Expires.Sub(now).Hours() / 24)
+		entry.DaysUntilExpiry = &days
+	}
+	if !key.Revoked.IsZero() {
+		entry.Revoked = key.Revoked.Format(time.RFC3339)
+	}
+	return entry
+}
+
+// keyExpiryReport classifies keys relative to now and sorts them by sortBy: "expires" (soonest
+// first, keys without an expiry last), "created" (oldest first), "status" (expired, expiring,
+// then healthy), or "creator". Ties are broken by expiry and then key ID.
+func keyExpiryReport(keys []tailscale.Key, creators map[string]string, now time.Time, within time.Duration, sortBy string) []KeyReportEntry {
+	entries := make([]KeyReportEntry, 0, len(keys))
+	for _, key := range keys {
+		entries = append(entries, summarizeKey(key, creators, now, within))
+	}
+
+	byExpiry := func(a, b KeyReportEntry) int {
+		// Keys that never expire sort after every key that does
+		if a.expires.IsZero() != b.expires.IsZero() {
+			if a.expires.IsZero() {
+				return 1
+			}
+			return -1
+		}
+		return a.expires.Compare(b.expires)
+	}
+
+	slices.SortStableFunc(entries, func(a, b KeyReportEntry) int {
+		var primary int
+		switch sortBy {
+		case "created":
+			primary = a.created.Compare(b.created)
+		case "status":
+			primary = cmp.Compare(slices.Index(keyStatuses, any(a.Status)), slices.Index(keyStatuses, any(b.Status)))
+		case "creator":
+			primary = cmp.Compare(cmp.Or(a.Creator, a.CreatorID), cmp.Or(b.Creator, b.CreatorID))
+		}
+		return cmp.Or(primary, byExpiry(a, b), cmp.Compare(a.ID, b.ID))
+	})
+
+	return entries
+}
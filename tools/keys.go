@@ -3,13 +3,44 @@ package tools
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"time"
 
-	"github.com/modelcontextprotocol/go-sdk/jsonschema"
+	"github.com/google/jsonschema-go/jsonschema"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
+	tailscale "tailscale.com/client/tailscale/v2"
 
 	"github.com/R167/tailscale-mcp/internal"
+	"github.com/R167/tailscale-mcp/redact"
 )
 
+const (
+	// maxAuthKeyExpiry is the longest expiry the API accepts for an auth key
+	maxAuthKeyExpiry = 90 * 24 * time.Hour
+	// maxKeyDescriptionLength is the longest key description the API accepts
+	maxKeyDescriptionLength = 50
+)
+
+// authKeyTemplate is a named set of capabilities and expiry that create_auth_key starts from
+type authKeyTemplate struct {
+	reusable      bool
+	ephemeral     bool
+	preauthorized bool
+	expiry        time.Duration
+}
+
+var authKeyTemplates = map[string]authKeyTemplate{
+	// ci-runner keys register one short-lived runner that is removed when it goes offline
+	"ci-runner": {ephemeral: true, preauthorized: true, expiry: time.Hour},
+	// server keys register one long-lived machine without manual approval
+	"server": {preauthorized: true, expiry: 24 * time.Hour},
+	// fleet keys register many long-lived machines without manual approval
+	"fleet": {reusable: true, preauthorized: true, expiry: 7 * 24 * time.Hour},
+}
+
+// authKeyTemplateNames lists the authKeyTemplates in the order they are documented
+var authKeyTemplateNames = []any{"ci-runner", "server", "fleet"}
+
 func RegisterKeyTools(server *mcp.Server, client internal.TailscaleClient) {
 	// List keys tool
 	mcp.AddTool(
@@ -22,18 +53,410 @@ func RegisterKeyTools(server *mcp.Server, client internal.TailscaleClient) {
 				AdditionalProperties: &jsonschema.Schema{Not: &jsonschema.Schema{}},
 			},
 		},
-		func(ctx context.Context, session *mcp.ServerSession, params *mcp.CallToolParamsFor[map[string]any]) (*mcp.CallToolResultFor[any], error) {
+		func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
 			keys, err := client.Keys().List(ctx, true)
 			if err != nil {
-				return toolError("Failed to list API keys", err), nil
+				return toolError("Failed to list API keys", err), nil, nil
 			}
 
 			output, err := json.MarshalIndent(keys, "", "  ")
 			if err != nil {
-				return toolError("Failed to serialize API keys", err), nil
+				return toolError("Failed to serialize API keys", err), nil, nil
+			}
+
+			return toolSuccess(string(output)), nil, nil
+		},
+	)
+
+	// Create auth key tool
+	mcp.AddTool(
+		server,
+		&mcp.Tool{
+			Name: "create_auth_key",
+			Description: "Create an auth key for adding devices to the tailnet. The key secret is returned only in this " +
+				"response and cannot be retrieved again. Tags must be declared in the policy's tagOwners. A template " +
+				"provides defaults (ci-runner: ephemeral, preauthorized, single use, 1 hour; server: preauthorized, " +
+				"single use, 1 day; fleet: reusable, preauthorized, 7 days) that explicit arguments override.",
+			InputSchema: &jsonschema.Schema{
+				Type: "object",
+				Properties: map[string]*jsonschema.Schema{
+					"template": {
+						Type:        "string",
+						Description: "Start from a predefined set of capabilities and expiry",
+						Enum:        authKeyTemplateNames,
+					},
+					"reusable": {
+						Type:        "boolean",
+						Description: "Allow the key to add more than one device",
+					},
+					"ephemeral": {
+						Type:        "boolean",
+						Description: "Remove devices added with the key once they go offline",
+					},
+					"preauthorized": {
+						Type:        "boolean",
+						Description: "Approve devices added with the key without manual authorization",
+					},
+					"tags": {
+						Type:        "array",
+						Description: "Tags applied to devices added with the key (e.g. tag:ci)",
+						Items:       &jsonschema.Schema{Type: "string"},
+					},
+					"expiry_seconds": {
+						Type:        "integer",
+						Description: "Seconds until the key expires, at most 90 days (default: the template's expiry, or 90 days)",
+						Minimum:     jsonschema.Ptr(1.0),
+						Maximum:     jsonschema.Ptr(maxAuthKeyExpiry.Seconds()),
+					},
+					"description": {
+						Type:        "string",
+						Description: "Short description of what the key is for, up to 50 characters",
+						MaxLength:   jsonschema.Ptr(maxKeyDescriptionLength),
+					},
+				},
+				AdditionalProperties: &jsonschema.Schema{Not: &jsonschema.Schema{}},
+			},
+		},
+		func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
+			request, err := parseAuthKeyRequest(args)
+			if err != nil {
+				return toolError("Invalid auth key parameters", err), nil, nil
+			}
+
+			if tags := request.Capabilities.Devices.Create.Tags; len(tags) > 0 {
+				acl, err := client.PolicyFile().Get(ctx)
+				if err != nil {
+					return toolError("Failed to get ACL policy", err), nil, nil
+				}
+				if err := checkTagsDeclared(acl, tags); err != nil {
+					return toolError("Tag validation failed", err), nil, nil
+				}
+			}
+
+			key, err := client.Keys().CreateAuthKey(ctx, request)
+			if err != nil {
+				return toolError("Failed to create auth key", err), nil, nil
+			}
+
+			create := key.Capabilities.Devices.Create
+			result := struct {
+				ID            string   `json:"id"`
+				Key           string   `json:"key"`
+				Description   string   `json:"description,omitempty"`
+				Reusable      bool     `json:"reusable"`
+				Ephemeral     bool     `json:"ephemeral"`
+				Preauthorized bool     `json:"preauthorized"`
+				Tags          []string `json:"tags,omitempty"`
+				Created       string   `json:"created,omitempty"`
+				Expires       string   `json:"expires,omitempty"`
+				Notice        string   `json:"notice"`
+			}{
+				ID:            key.ID,
+				Key:           key.Key,
+				Description:   key.Description,
+				Reusable:      create.Reusable,
+				Ephemeral:     create.Ephemeral,
+				Preauthorized: create.Preauthorized,
+				Tags:          create.Tags,
+				Notice:        "This is the only time the key secret is shown; store it securely now.",
+			}
+			if !key.Created.IsZero() {
+				result.Created = key.Created.String()
+			}
+			if !key.Expires.IsZero() {
+				result.Expires = key.Expires.String()
+			}
+
+			output, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				return toolError("Failed to serialize auth key", err), nil, nil
 			}
 
-			return toolSuccess(string(output)), nil
+			// The secret is the point of this tool, so it is the one field let through redaction
+			return toolSecretSuccess(string(output), redact.Path{"key"}), nil, nil
 		},
 	)
+
+	// Get key tool
+	mcp.AddTool(
+		server,
+		&mcp.Tool{
+			Name:        "get_key",
+			Description: "Get a key's type, description, creator, capabilities, and expiry. The key secret is never included.",
+			InputSchema: &jsonschema.Schema{
+				Type: "object",
+				Properties: map[string]*jsonschema.Schema{
+					"keyID": {
+						Type:        "string",
+						Description: "The ID of the key",
+					},
+				},
+				Required:             []string{"keyID"},
+				AdditionalProperties: &jsonschema.Schema{Not: &jsonschema.Schema{}},
+			},
+		},
+		func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
+			keyID, err := getIDParam(args, "keyID")
+			if err != nil {
+				return toolError("Invalid key ID parameter", err), nil, nil
+			}
+
+			key, err := client.Keys().Get(ctx, keyID)
+			if err != nil {
+				return toolError("Failed to get key", err), nil, nil
+			}
+
+			creators, warning := keyCreators(ctx, client)
+
+			result := struct {
+				KeyReportEntry
+				Capabilities *tailscale.KeyCapabilities `json:"capabilities,omitempty"`
+				Scopes       []string                   `json:"scopes,omitempty"`
+				Warning      string                     `json:"warning,omitempty"`
+			}{
+				KeyReportEntry: summarizeKey(*key, creators, time.Now(), defaultKeyExpiryDays*24*time.Hour),
+				Scopes:         key.Scopes,
+				Warning:        warning,
+			}
+			if key.KeyType == "auth" {
+				result.Capabilities = &key.Capabilities
+			}
+
+			output, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				return toolError("Failed to serialize key", err), nil, nil
+			}
+
+			return toolSuccess(string(output)), nil, nil
+		},
+	)
+
+	// Revoke key tool
+	mcp.AddTool(
+		server,
+		&mcp.Tool{
+			Name: "revoke_key",
+			Description: "Revoke a key so it can no longer be used. This cannot be undone. Devices already added with " +
+				"an auth key stay in the tailnet.",
+			InputSchema: &jsonschema.Schema{
+				Type: "object",
+				Properties: map[string]*jsonschema.Schema{
+					"keyID": {
+						Type:        "string",
+						Description: "The ID of the key to revoke",
+					},
+				},
+				Required:             []string{"keyID"},
+				AdditionalProperties: &jsonschema.Schema{Not: &jsonschema.Schema{}},
+			},
+		},
+		func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
+			keyID, err := getIDParam(args, "keyID")
+			if err != nil {
+				return toolError("Invalid key ID parameter", err), nil, nil
+			}
+
+			// Look the key up first so that the result describes what was revoked
+			key, err := client.Keys().Get(ctx, keyID)
+			if err != nil {
+				return toolError("Failed to get key", err), nil, nil
+			}
+
+			if err := client.Keys().Delete(ctx, keyID); err != nil {
+				return toolError("Failed to revoke key", err), nil, nil
+			}
+
+			result := struct {
+				ID          string `json:"id"`
+				KeyType     string `json:"keyType"`
+				Description string `json:"description,omitempty"`
+				Revoked     bool   `json:"revoked"`
+			}{
+				ID:          key.ID,
+				KeyType:     key.KeyType,
+				Description: key.Description,
+				Revoked:     true,
+			}
+
+			output, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				return toolError("Failed to serialize result", err), nil, nil
+			}
+
+			return toolSuccess(string(output)), nil, nil
+		},
+	)
+
+	// Key expiry report tool
+	mcp.AddTool(
+		server,
+		&mcp.Tool{
+			Name: "key_expiry_report",
+			Description: "Audit every key in the tailnet, classifying each as expired (including revoked and invalid keys), " +
+				"expiring within a number of days, or healthy, with its creator and description. Key secrets are never included.",
+			InputSchema: &jsonschema.Schema{
+				Type: "object",
+				Properties: map[string]*jsonschema.Schema{
+					"days": {
+						Type:        "integer",
+						Description: fmt.Sprintf("Report keys expiring within this many days as expiring (default: %d, max: %d)", defaultKeyExpiryDays, maxDays),
+						Minimum:     jsonschema.Ptr(0.0),
+						Maximum:     jsonschema.Ptr(float64(maxDays)),
+					},
+					"status": {
+						Type:        "string",
+						Description: "Only report keys with this status",
+						Enum:        keyStatuses,
+					},
+					"sort": {
+						Type: "string",
+						Description: "Order of the report: expires (soonest first, default), created (oldest first), " +
+							"status (expired first), or creator",
+						Enum: keyReportSorts,
+					},
+				},
+				AdditionalProperties: &jsonschema.Schema{Not: &jsonschema.Schema{}},
+			},
+		},
+		func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
+			days, err := getOptionalIntParam(args, "days", defaultKeyExpiryDays)
+			if err != nil {
+				return toolError("Invalid days parameter", err), nil, nil
+			}
+			if days < 0 || days > maxDays {
+				return toolError("Invalid days parameter", fmt.Errorf("days must be between 0 and %d", maxDays)), nil, nil
+			}
+
+			status, err := getOptionalStringParam(args, "status")
+			if err != nil {
+				return toolError("Invalid status parameter", err), nil, nil
+			}
+
+			sortBy, err := getOptionalStringParam(args, "sort")
+			if err != nil {
+				return toolError("Invalid sort parameter", err), nil, nil
+			}
+			if sortBy == "" {
+				sortBy = keyReportSorts[0].(string)
+			}
+
+			keys, err := client.Keys().List(ctx, true)
+			if err != nil {
+				return toolError("Failed to list keys", err), nil, nil
+			}
+
+			creators, warning := keyCreators(ctx, client)
+			report := keyExpiryReport(keys, creators, time.Now(), time.Duration(days)*24*time.Hour, sortBy)
+
+			summary := map[string]int{keyStatusExpired: 0, keyStatusExpiring: 0, keyStatusHealthy: 0}
+			entries := make([]KeyReportEntry, 0, len(report))
+			for _, entry := range report {
+				summary[entry.Status]++
+				if status == "" || entry.Status == status {
+					entries = append(entries, entry)
+				}
+			}
+
+			result := struct {
+				Days    int              `json:"days"`
+				Sort    string           `json:"sort"`
+				Summary map[string]int   `json:"summary"`
+				Keys    []KeyReportEntry `json:"keys"`
+				Warning string           `json:"warning,omitempty"`
+			}{
+				Days:    days,
+				Sort:    sortBy,
+				Summary: summary,
+				Keys:    entries,
+				Warning: warning,
+			}
+
+			output, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				return toolError("Failed to serialize key expiry report", err), nil, nil
+			}
+
+			return toolSuccess(string(output)), nil, nil
+		},
+	)
+}
+
+// keyCreators maps user IDs to login names so that keys can name their creator. Listing users
+// needs the users scope, so a failure only produces a warning and creators are left as IDs.
+func keyCreators(ctx context.Context, client internal.TailscaleClient) (map[string]string, string) {
+	users, err := client.Users().List(ctx, nil, nil)
+	if err != nil {
+		return nil, fmt.Sprintf("creators are shown as user IDs because users could not be listed: %v", err)
+	}
+
+	creators := make(map[string]string, len(users))
+	for _, user := range users {
+		creators[user.ID] = user.LoginName
+	}
+	return creators, ""
+}
+
+// parseAuthKeyRequest builds the create request for create_auth_key, starting from the
+// template if one is given and applying the explicit arguments on top
+func parseAuthKeyRequest(params map[string]any) (tailscale.CreateKeyRequest, error) {
+	var request tailscale.CreateKeyRequest
+
+	templateName, err := getOptionalStringParam(params, "template")
+	if err != nil {
+		return request, err
+	}
+	var template authKeyTemplate
+	if templateName != "" {
+		var ok bool
+		if template, ok = authKeyTemplates[templateName]; !ok {
+			return request, fmt.Errorf("unknown template %q", templateName)
+		}
+	}
+
+	create := &request.Capabilities.Devices.Create
+	create.Reusable, create.Ephemeral, create.Preauthorized = template.reusable, template.ephemeral, template.preauthorized
+	request.ExpirySeconds = int64(template.expiry.Seconds())
+
+	for key, field := range map[string]*bool{
+		"reusable":      &create.Reusable,
+		"ephemeral":     &create.Ephemeral,
+		"preauthorized": &create.Preauthorized,
+	} {
+		value, err := getOptionalBoolParam(params, key)
+		if err != nil {
+			return request, err
+		}
+		if value != nil {
+			*field = *value
+		}
+	}
+
+	tags, err := getOptionalStringSliceParam(params, "tags")
+	if err != nil {
+		return request, err
+	}
+	create.Tags = tags
+
+	// Without a template or explicit expiry, ExpirySeconds stays 0 and the API default applies
+	if params["expiry_seconds"] != nil {
+		expiry, err := getOptionalIntParam(params, "expiry_seconds", 0)
+		if err != nil {
+			return request, err
+		}
+		if expiry < 1 || time.Duration(expiry)*time.Second > maxAuthKeyExpiry {
+			return request, fmt.Errorf("expiry_seconds must be between 1 and %d", int(maxAuthKeyExpiry.Seconds()))
+		}
+		request.ExpirySeconds = int64(expiry)
+	}
+
+	description, err := getOptionalStringParam(params, "description")
+	if err != nil {
+		return request, err
+	}
+	if len(description) > maxKeyDescriptionLength {
+		return request, fmt.Errorf("description must be at most %d characters", maxKeyDescriptionLength)
+	}
+	request.Description = description
+
+	return request, nil
 }
@@ -0,0 +1,242 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// workflowStep is one tool call in a prompt's workflow
+type workflowStep struct {
+	tool string
+	// args describes the arguments to call the tool with, if any
+	args string
+	// purpose tells the model what to look for in the result
+	purpose string
+}
+
+// workflowPrompt renders a prompt that states the goal, lists the tool calls to make in order,
+// and closes with how to report the result
+func workflowPrompt(description, goal string, steps []workflowStep, report string) *mcp.GetPromptResult {
+	var text strings.Builder
+	text.WriteString(goal)
+	text.WriteString("\n\nCall these tools in order:\n")
+	for i, step := range steps {
+		fmt.Fprintf(&text, "%d. `%s`", i+1, step.tool)
+		if step.args != "" {
+			fmt.Fprintf(&text, " with %s", step.args)
+		}
+		fmt.Fprintf(&text, ": %s\n", step.purpose)
+	}
+	text.WriteString("\n")
+	text.WriteString(report)
+
+	return &mcp.GetPromptResult{
+		Description: description,
+		Messages: []*mcp.PromptMessage{
+			{
+				Role:    "user",
+				Content: &mcp.TextContent{Text: text.String()},
+			},
+		},
+	}
+}
+
+// getPromptArg returns a trimmed prompt argument, which must be present if required
+func getPromptArg(args map[string]string, name string, required bool) (string, error) {
+	value := strings.TrimSpace(args[name])
+	if value == "" && required {
+		return "", fmt.Errorf("%s argument is required", name)
+	}
+	return value, nil
+}
+
+// splitTagsArg splits a comma-separated tags argument, adding the tag: prefix where it is missing
+func splitTagsArg(value string) []string {
+	var tags []string
+	for _, tag := range strings.Split(value, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+		if !strings.HasPrefix(tag, "tag:") {
+			tag = "tag:" + tag
+		}
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+// RegisterPrompts adds prompts that walk through common tailnet workflows using the registered
+// tools. Their device and tag arguments are completed by Completer.
+func RegisterPrompts(server *mcp.Server) {
+	server.AddPrompt(
+		&mcp.Prompt{
+			Name:        "audit_tailnet",
+			Title:       "Audit the tailnet",
+			Description: "Review the policy file, devices, keys, users, and webhooks for problems, without changing anything",
+			Arguments: []*mcp.PromptArgument{
+				{
+					Name:        "days",
+					Description: fmt.Sprintf("How many days without being seen makes a device stale, and how soon a key must expire to be reported (default %d)", defaultStaleDays),
+				},
+			},
+		},
+		func(ctx context.Context, req *mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+			days := defaultStaleDays
+			if value, _ := getPromptArg(req.Params.Arguments, "days", false); value != "" {
+				parsed, err := strconv.Atoi(value)
+				if err != nil || parsed < 1 {
+					return nil, fmt.Errorf("days must be a positive number of days, got %q", value)
+				}
+				days = parsed
+			}
+
+			return workflowPrompt(
+				"Audit the tailnet",
+				"Audit this Tailscale tailnet for security and hygiene problems. Only read: do not call any tool that "+
+					"changes the tailnet.",
+				[]workflowStep{
+					{tool: "lint_acl", purpose: "problems in the policy file, such as empty groups, unused tags, shadowed rules, and `*:*` destinations."},
+					{tool: "stale_devices", args: fmt.Sprintf("`days` = %d", days), purpose: "devices that are candidates for removal."},
+					{tool: "list_devices", args: "`update_available` = true", purpose: "devices running an outdated Tailscale client."},
+					{tool: "key_expiry_report", args: fmt.Sprintf("`days` = %d", days), purpose: "expired keys that should be cleaned up and keys that expire soon, with who created them."},
+					{tool: "list_users", purpose: "admins and suspended users, and users who own no devices."},
+					{tool: "list_webhooks", purpose: "whether anyone is notified of tailnet events such as expiring node keys."},
+				},
+				"Report the findings as a prioritized list, most severe first. For each finding give the evidence from the "+
+					"tool output and the tool call that would fix it, but do not make the change.",
+			), nil
+		},
+	)
+
+	server.AddPrompt(
+		&mcp.Prompt{
+			Name:        "troubleshoot_connectivity",
+			Title:       "Troubleshoot connectivity",
+			Description: "Work out why one device cannot reach another, checking both devices, the policy file, routes, and DNS",
+			Arguments: []*mcp.PromptArgument{
+				{Name: "source", Description: "The device, user, or tag that cannot connect", Required: true},
+				{Name: "destination", Description: "The device or subnet IP address it cannot reach", Required: true},
+				{Name: "port", Description: "The destination port; omit to troubleshoot ping"},
+			},
+		},
+		func(ctx context.Context, req *mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+			source, err := getPromptArg(req.Params.Arguments, "source", true)
+			if err != nil {
+				return nil, err
+			}
+			destination, err := getPromptArg(req.Params.Arguments, "destination", true)
+			if err != nil {
+				return nil, err
+			}
+
+			access := fmt.Sprintf("`source` = %q, `destination` = %q, and `proto` = \"icmp\"", source, destination)
+			connection := fmt.Sprintf("%s cannot ping %s", source, destination)
+			if port, _ := getPromptArg(req.Params.Arguments, "port", false); port != "" {
+				number, err := strconv.Atoi(port)
+				if err != nil || number < 1 || number > 65535 {
+					return nil, fmt.Errorf("port must be between 1 and 65535, got %q", port)
+				}
+				access = fmt.Sprintf("`source` = %q, `destination` = %q, and `port` = %d", source, destination, number)
+				connection = fmt.Sprintf("%s cannot connect to %s on port %d", source, destination, number)
+			}
+
+			return workflowPrompt(
+				"Troubleshoot "+connection,
+				fmt.Sprintf("Find out why %s in this Tailscale tailnet.", connection),
+				[]workflowStep{
+					{tool: "get_device_details", args: fmt.Sprintf("`deviceID` = %q", source), purpose: "check that the source device exists, is authorized, is online, and that its key has not expired. Skip this if the source is a user or tag."},
+					{tool: "get_device_details", args: fmt.Sprintf("`deviceID` = %q", destination), purpose: "the same checks for the destination, plus whether it blocks incoming connections. If the destination is an IP address behind a subnet router, find the router with `list_devices` and its `address` filter instead."},
+					{tool: "get_device_routes", purpose: "only if the destination is behind a subnet router: check that the router advertises a route covering it and that the route is enabled."},
+					{tool: "check_access", args: access, purpose: "whether the policy file allows the connection, and which rule allows it."},
+					{tool: "effective_access_to", args: fmt.Sprintf("`destination` = %q", destination), purpose: "only if access is denied: who can reach the destination, to find the rule closest to what is needed."},
+					{tool: "get_dns_config", purpose: "only if the connection is made by name: whether MagicDNS and the nameservers can resolve it."},
+				},
+				"Stop at the first check that explains the failure. Report the most likely cause with the evidence, and the "+
+					"change that would fix it. Ask before calling any tool that changes the tailnet.",
+			), nil
+		},
+	)
+
+	server.AddPrompt(
+		&mcp.Prompt{
+			Name:        "onboard_device",
+			Title:       "Onboard a device",
+			Description: "Check that a new device's tags are declared, create an auth key for it, and confirm its access once it joins",
+			Arguments: []*mcp.PromptArgument{
+				{Name: "hostname", Description: "The hostname the new device will join with", Required: true},
+				{Name: "tags", Description: "Comma-separated tags to apply to the device, such as tag:server,tag:prod"},
+			},
+		},
+		func(ctx context.Context, req *mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+			hostname, err := getPromptArg(req.Params.Arguments, "hostname", true)
+			if err != nil {
+				return nil, err
+			}
+			tagsArg, _ := getPromptArg(req.Params.Arguments, "tags", false)
+			tags := splitTagsArg(tagsArg)
+
+			keyArgs := "`template` = \"server\""
+			declared := "check the policy's `tagOwners`. Untagged devices are owned by the user who authenticates them, so confirm that is intended."
+			if len(tags) > 0 {
+				quoted := make([]string, 0, len(tags))
+				for _, tag := range tags {
+					quoted = append(quoted, strconv.Quote(tag))
+				}
+				keyArgs += fmt.Sprintf(" and `tags` = [%s]", strings.Join(quoted, ", "))
+				declared = fmt.Sprintf("check that %s are declared in the policy's `tagOwners`. If any is missing, stop and propose the policy change with the `review_acl_change` prompt first.", strings.Join(tags, ", "))
+			}
+
+			return workflowPrompt(
+				"Onboard "+hostname,
+				fmt.Sprintf("Onboard a new device named %q to this Tailscale tailnet.", hostname),
+				[]workflowStep{
+					{tool: "get_acl", purpose: declared},
+					{tool: "list_devices", args: fmt.Sprintf("`name` = %q", hostname), purpose: "check that no device already uses this name, since the new device would then join with a numbered suffix."},
+					{tool: "create_auth_key", args: keyArgs, purpose: "a single-use, preauthorized key for the device. Show the key only once, together with the command to run on the device: `tailscale up --auth-key=<key> --hostname=" + hostname + "`."},
+					{tool: "get_device_details", args: fmt.Sprintf("`deviceID` = %q", hostname), purpose: "after the device has joined: confirm it is authorized, online, and has the expected tags. Use `authorize_device` if it is waiting for approval."},
+					{tool: "effective_access", args: fmt.Sprintf("`source` = %q", hostname), purpose: "what the new device can reach."},
+					{tool: "effective_access_to", args: fmt.Sprintf("`destination` = %q", hostname), purpose: "who can reach the new device."},
+				},
+				"Wait for confirmation that the device has joined before the last three steps. Finish with a summary of "+
+					"the device and its access, and point out anything broader than the tags suggest.",
+			), nil
+		},
+	)
+
+	server.AddPrompt(
+		&mcp.Prompt{
+			Name:        "review_acl_change",
+			Title:       "Review a policy change",
+			Description: "Draft a policy file change, validate it and its effect on access, and apply it only after approval",
+			Arguments: []*mcp.PromptArgument{
+				{Name: "change", Description: "The change to make, such as \"let group:eng reach tag:prod on port 22\"", Required: true},
+			},
+		},
+		func(ctx context.Context, req *mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+			change, err := getPromptArg(req.Params.Arguments, "change", true)
+			if err != nil {
+				return nil, err
+			}
+
+			return workflowPrompt(
+				"Review a policy change",
+				fmt.Sprintf("Make this change to the Tailscale policy file: %s", change),
+				[]workflowStep{
+					{tool: "get_acl_raw", purpose: "the current policy as HuJSON and its ETag. Draft the smallest edit that makes the change, keeping existing comments and formatting, and add `tests` entries that cover it."},
+					{tool: "validate_acl", args: "the edited policy", purpose: "syntax errors, API errors, and failing tests. Fix the draft and validate again until it passes."},
+					{tool: "update_acl", args: "the edited policy and `dry_run` = true", purpose: "the unified diff against the current policy."},
+					{tool: "check_access", purpose: "for the connections the change should allow or deny, confirm the result. The check runs against the current policy, so compare it with what the draft changes."},
+					{tool: "effective_access_to", purpose: "for devices the change affects, who can reach them now, to spot access the change would widen unexpectedly."},
+					{tool: "update_acl", args: "the edited policy and the ETag from `get_acl_raw`", purpose: "only after the diff has been approved. If the ETag no longer matches, start again from `get_acl_raw`."},
+					{tool: "lint_acl", purpose: "after applying: any new warnings the change introduced."},
+				},
+				"Present the diff, the validation result, and the effect on access, and ask for approval before applying.",
+			), nil
+		},
+	)
+}
@@ -0,0 +1,130 @@
+package tools
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/R167/tailscale-mcp/internal"
+)
+
+func promptTestServer() *mcp.Server {
+	client := &internal.MockTailscaleClient{}
+	server := mcp.NewServer(&mcp.Implementation{}, &mcp.ServerOptions{})
+	RegisterDeviceTools(server, client, NewDeviceResolver(client))
+	RegisterACLTools(server, client)
+	RegisterKeyTools(server, client)
+	RegisterAccessTools(server, client)
+	RegisterDNSTools(server, client)
+	RegisterUserTools(server, client)
+	RegisterWebhookTools(server, client)
+	RegisterPrompts(server)
+	return server
+}
+
+func getPrompt(t *testing.T, session *mcp.ClientSession, name string, args map[string]string) string {
+	t.Helper()
+
+	result, err := session.GetPrompt(context.Background(), &mcp.GetPromptParams{Name: name, Arguments: args})
+	if err != nil {
+		t.Fatalf("Failed to get prompt %s: %v", name, err)
+	}
+	if len(result.Messages) != 1 {
+		t.Fatalf("Expected one message from %s, got %d", name, len(result.Messages))
+	}
+	content, ok := result.Messages[0].Content.(*mcp.TextContent)
+	if !ok {
+		t.Fatalf("Expected text content from %s", name)
+	}
+	return content.Text
+}
+
+func TestPromptsReferenceRegisteredTools(t *testing.T) {
+	session := connectClient(t, promptTestServer())
+	ctx := context.Background()
+
+	tools, err := session.ListTools(ctx, nil)
+	if err != nil {
+		t.Fatalf("Failed to list tools: %v", err)
+	}
+	registered := map[string]bool{}
+	for _, tool := range tools.Tools {
+		registered[tool.Name] = true
+	}
+
+	args := map[string]map[string]string{
+		"troubleshoot_connectivity": {"source": "laptop", "destination": "db-1", "port": "5432"},
+		"onboard_device":            {"hostname": "db-3", "tags": "tag:db"},
+		"review_acl_change":         {"change": "let group:eng reach tag:db on port 5432"},
+	}
+
+	prompts, err := session.ListPrompts(ctx, nil)
+	if err != nil {
+		t.Fatalf("Failed to list prompts: %v", err)
+	}
+	if len(prompts.Prompts) != 4 {
+		t.Errorf("Expected four prompts, got %d", len(prompts.Prompts))
+	}
+
+	step := regexp.MustCompile("(?m)^\\d+\\. `([a-z_]+)`")
+	mentioned := regexp.MustCompile("`([a-z]+_[a-z_]+)`")
+	for _, prompt := range prompts.Prompts {
+		text := getPrompt(t, session, prompt.Name, args[prompt.Name])
+
+		steps := step.FindAllStringSubmatch(text, -1)
+		if len(steps) == 0 {
+			t.Errorf("Expected %s to list tool calls: %s", prompt.Name, text)
+		}
+		for _, match := range mentioned.FindAllStringSubmatch(text, -1) {
+			name := match[1]
+			if !registered[name] && name != "review_acl_change" && name != "dry_run" && name != "update_available" {
+				t.Errorf("Prompt %s refers to unregistered tool %s", prompt.Name, name)
+			}
+		}
+	}
+}
+
+func TestTroubleshootConnectivityPrompt(t *testing.T) {
+	session := connectClient(t, promptTestServer())
+
+	text := getPrompt(t, session, "troubleshoot_connectivity", map[string]string{"source": "laptop", "destination": "db-1", "port": "5432"})
+	if !strings.Contains(text, "`port` = 5432") || !strings.Contains(text, "laptop cannot connect to db-1 on port 5432") {
+		t.Errorf("Expected the port check: %s", text)
+	}
+
+	text = getPrompt(t, session, "troubleshoot_connectivity", map[string]string{"source": "laptop", "destination": "db-1"})
+	if !strings.Contains(text, "`proto` = \"icmp\"") {
+		t.Errorf("Expected a ping check without a port: %s", text)
+	}
+
+	testCases := map[string]map[string]string{
+		"source argument is required": {"destination": "db-1"},
+		"port must be between":        {"source": "laptop", "destination": "db-1", "port": "http"},
+	}
+	for expected, args := range testCases {
+		_, err := session.GetPrompt(context.Background(), &mcp.GetPromptParams{Name: "troubleshoot_connectivity", Arguments: args})
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected error containing %q, got %v", expected, err)
+		}
+	}
+}
+
+func TestOnboardDevicePrompt(t *testing.T) {
+	session := connectClient(t, promptTestServer())
+
+	text := getPrompt(t, session, "onboard_device", map[string]string{"hostname": "db-3", "tags": "db, tag:prod,"})
+	if !strings.Contains(text, "`tags` = [\"tag:db\", \"tag:prod\"]") {
+		t.Errorf("Expected the tags to be normalized for create_auth_key: %s", text)
+	}
+	if !strings.Contains(text, "--hostname=db-3") {
+		t.Errorf("Expected the join command for the hostname: %s", text)
+	}
+
+	text = getPrompt(t, session, "onboard_device", map[string]string{"hostname": "db-3"})
+	if strings.Contains(text, "`tags` =") || !strings.Contains(text, "Untagged devices") {
+		t.Errorf("Expected an untagged key: %s", text)
+	}
+}
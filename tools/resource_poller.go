@@ -0,0 +1,278 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	tailscale "tailscale.com/client/tailscale/v2"
+
+	"github.com/R167/tailscale-mcp/internal"
+)
+
+// defaultResourcePollInterval is how often subscribed resources are checked for changes when
+// no interval is configured
+const defaultResourcePollInterval = 30 * time.Second
+
+// ResourcePoller sends notifications/resources/updated to sessions subscribed to tailnet
+// resources. The Tailscale API has no change feed, so while any session is subscribed the
+// poller periodically snapshots the devices and the policy file ETag and diffs each snapshot
+// against the previous one. It stops polling once no session is subscribed.
+type ResourcePoller struct {
+	client   internal.TailscaleClient
+	interval time.Duration
+
+	mu            sync.Mutex
+	server        *mcp.Server
+	subscriptions map[*mcp.ServerSession]map[string]bool
+	stop          context.CancelFunc
+}
+
+// NewResourcePoller creates a poller that checks for changes every interval, or every
+// defaultResourcePollInterval if interval is not positive. Its Subscribe and Unsubscribe
+// methods are the server's subscription handlers, and Attach must be called with the server
+// before it starts serving.
+func NewResourcePoller(client internal.TailscaleClient, interval time.Duration) *ResourcePoller {
+	if interval <= 0 {
+		interval = defaultResourcePollInterval
+	}
+	return &ResourcePoller{
+		client:        client,
+		interval:      interval,
+		subscriptions: map[*mcp.ServerSession]map[string]bool{},
+	}
+}
+
+// Attach sets the server that notifications are sent through
+func (p *ResourcePoller) Attach(server *mcp.Server) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.server = server
+}
+
+// Subscribe records a session's subscription, starting the poller if it is not running
+func (p *ResourcePoller) Subscribe(ctx context.Context, req *mcp.SubscribeRequest) error {
+	uri := req.Params.URI
+	if !subscribableResource(uri) {
+		return fmt.Errorf("%s does not support subscriptions; only devices and the policy file are watched for changes", uri)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.subscriptions[req.Session] == nil {
+		p.subscriptions[req.Session] = map[string]bool{}
+	}
+	p.subscriptions[req.Session][uri] = true
+
+	if p.stop == nil {
+		pollCtx, stop := context.WithCancel(context.Background())
+		p.stop = stop
+		go p.run(pollCtx)
+	}
+	return nil
+}
+
+// Unsubscribe removes a session's subscription, stopping the poller if it was the last one
+func (p *ResourcePoller) Unsubscribe(ctx context.Context, req *mcp.UnsubscribeRequest) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if uris := p.subscriptions[req.Session]; uris != nil {
+		delete(uris, req.Params.URI)
+		if len(uris) == 0 {
+			delete(p.subscriptions, req.Session)
+		}
+	}
+	p.stopIfIdle()
+	return nil
+}
+
+// running reports whether the poller is currently polling
+func (p *ResourcePoller) running() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.stop != nil
+}
+
+// stopIfIdle stops polling when no session is subscribed. p.mu must be held.
+func (p *ResourcePoller) stopIfIdle() {
+	if len(p.subscriptions) == 0 && p.stop != nil {
+		p.stop()
+		p.stop = nil
+	}
+}
+
+// subscribed drops the subscriptions of sessions that have disconnected, stopping the poller
+// if none remain, and returns the URIs that are still subscribed
+func (p *ResourcePoller) subscribed() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.server != nil {
+		connected := map[*mcp.ServerSession]bool{}
+		for session := range p.server.Sessions() {
+			connected[session] = true
+		}
+		for session := range p.subscriptions {
+			if !connected[session] {
+				delete(p.subscriptions, session)
+			}
+		}
+	}
+	p.stopIfIdle()
+
+	var uris []string
+	for _, sessionURIs := range p.subscriptions {
+		for uri := range sessionURIs {
+			if !slices.Contains(uris, uri) {
+				uris = append(uris, uri)
+			}
+		}
+	}
+	return uris
+}
+
+// run polls until ctx is cancelled, notifying subscribers of every resource that changed
+// between consecutive snapshots
+func (p *ResourcePoller) run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	previous := p.snapshot(ctx, nil)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		uris := p.subscribed()
+		if len(uris) == 0 {
+			return
+		}
+
+		current := p.snapshot(ctx, previous)
+		for _, uri := range resourceChanges(previous, current, uris) {
+			if ctx.Err() != nil {
+				return
+			}
+			if err := p.server.ResourceUpdated(ctx, &mcp.ResourceUpdatedNotificationParams{URI: uri}); err != nil {
+				slog.Warn("Failed to notify resource subscribers", "uri", uri, "error", err)
+			}
+		}
+		previous = current
+	}
+}
+
+// resourceSnapshot is the state of the watched resources at one poll. A nil device list or
+// empty ETag means that part could not be fetched yet and is not compared.
+type resourceSnapshot struct {
+	devices    []tailscale.Device
+	states     map[string]deviceState
+	policyETag string
+}
+
+// deviceState is the part of a device whose change subscribers are notified of. It replaces
+// lastSeen, which advances continually while a device is connected, with the online status.
+type deviceState struct {
+	device tailscale.Device
+	status string
+}
+
+// snapshot fetches the watched resources, keeping the previous state of any that fail
+func (p *ResourcePoller) snapshot(ctx context.Context, previous *resourceSnapshot) *resourceSnapshot {
+	current := &resourceSnapshot{}
+	if previous != nil {
+		*current = *previous
+	}
+
+	if devices, err := p.client.Devices().List(ctx); err != nil {
+		slog.Warn("Failed to poll devices", "error", outputRedactor.Text(err.Error()))
+	} else {
+		now := time.Now()
+		current.devices = devices
+		current.states = make(map[string]deviceState, len(devices))
+		for _, device := range devices {
+			status := deviceStatus(device, now, defaultOnlineThreshold)
+			device.LastSeen = nil
+			current.states[device.ID] = deviceState{device: device, status: status}
+		}
+	}
+
+	if raw, err := p.client.PolicyFile().Raw(ctx); err != nil {
+		slog.Warn("Failed to poll policy file", "error", outputRedactor.Text(err.Error()))
+	} else {
+		current.policyETag = raw.ETag
+	}
+
+	return current
+}
+
+// subscribableResource reports whether uri is a resource the poller watches
+func subscribableResource(uri string) bool {
+	switch uri {
+	case devicesResourceURI, aclResourceURI, aclRawResourceURI:
+		return true
+	}
+	return deviceResourceRef(uri) != ""
+}
+
+// deviceResourceRef returns the device reference in a tailscale://devices/{id} URI, or "" if
+// uri is not a device URI
+func deviceResourceRef(uri string) string {
+	return templateValue(deviceResourceTemplate, uri, idTemplateVar)
+}
+
+// resourceChanges returns the subscribed URIs whose contents differ between two snapshots
+func resourceChanges(previous, current *resourceSnapshot, subscribed []string) []string {
+	changedDevices := map[string]bool{}
+	if previous.states != nil && current.states != nil {
+		for id, state := range current.states {
+			if old, ok := previous.states[id]; !ok || !reflect.DeepEqual(old, state) {
+				changedDevices[id] = true
+			}
+		}
+		for id := range previous.states {
+			if _, ok := current.states[id]; !ok {
+				changedDevices[id] = true
+			}
+		}
+	}
+
+	policyChanged := previous.policyETag != "" && current.policyETag != "" && previous.policyETag != current.policyETag
+
+	var changes []string
+	for _, uri := range subscribed {
+		switch uri {
+		case devicesResourceURI:
+			if len(changedDevices) > 0 {
+				changes = append(changes, uri)
+			}
+		case aclResourceURI, aclRawResourceURI:
+			if policyChanged {
+				changes = append(changes, uri)
+			}
+		default:
+			ref := deviceResourceRef(uri)
+			if ref == "" || len(changedDevices) == 0 {
+				continue
+			}
+			// Match against both snapshots so that removed and newly added devices are found
+			matches := append(matchDevices(previous.devices, ref), matchDevices(current.devices, ref)...)
+			if slices.ContainsFunc(matches, func(device tailscale.Device) bool { return changedDevices[device.ID] }) {
+				changes = append(changes, uri)
+			}
+		}
+	}
+
+	slices.Sort(changes)
+	return changes
+}
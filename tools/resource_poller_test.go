@@ -0,0 +1,203 @@
+package tools
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	tailscale "tailscale.com/client/tailscale/v2"
+
+	"github.com/R167/tailscale-mcp/internal"
+)
+
+// tailnetState is a mutable tailnet served by a mock client, for tests that change it while
+// the poller runs
+type tailnetState struct {
+	mu         sync.Mutex
+	devices    []tailscale.Device
+	policyETag string
+}
+
+func (s *tailnetState) set(devices []tailscale.Device, policyETag string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.devices = devices
+	s.policyETag = policyETag
+}
+
+func (s *tailnetState) client() internal.TailscaleClient {
+	return &internal.MockTailscaleClient{
+		DevicesFunc: func() internal.DevicesResource {
+			return &internal.MockDevicesResource{
+				ListFunc: func(ctx context.Context) ([]tailscale.Device, error) {
+					s.mu.Lock()
+					defer s.mu.Unlock()
+					return s.devices, nil
+				},
+			}
+		},
+		PolicyFileFunc: func() internal.PolicyFileResource {
+			return &internal.MockPolicyFileResource{
+				RawFunc: func(ctx context.Context) (*tailscale.RawACL, error) {
+					s.mu.Lock()
+					defer s.mu.Unlock()
+					return &tailscale.RawACL{HuJSON: internal.MockPolicyHuJSON, ETag: s.policyETag}, nil
+				},
+			}
+		},
+	}
+}
+
+func pollSnapshot(devices []tailscale.Device, policyETag string) *resourceSnapshot {
+	state := &tailnetState{devices: devices, policyETag: policyETag}
+	return NewResourcePoller(state.client(), 0).snapshot(context.Background(), nil)
+}
+
+func TestResourceChanges(t *testing.T) {
+	subscribed := []string{
+		"tailscale://devices",
+		"tailscale://devices/1",
+		"tailscale://devices/db-1.example.ts.net",
+		"tailscale://devices/3",
+		"tailscale://acl",
+		"tailscale://acl/raw",
+	}
+	previous := pollSnapshot(testDevices(), `"etag-1"`)
+
+	offline := testDevices()
+	offline[0].ConnectedToControl = false
+	offline[0].LastSeen = &tailscale.Time{Time: testNow}
+
+	lastSeen := testDevices()
+	lastSeen[2].LastSeen = &tailscale.Time{Time: testNow.Add(time.Minute)}
+
+	testCases := []struct {
+		name     string
+		current  *resourceSnapshot
+		expected []string
+	}{
+		{name: "Unchanged", current: pollSnapshot(testDevices(), `"etag-1"`), expected: nil},
+		{
+			name:     "DeviceOffline",
+			current:  pollSnapshot(offline, `"etag-1"`),
+			expected: []string{"tailscale://devices", "tailscale://devices/1", "tailscale://devices/db-1.example.ts.net"},
+		},
+		{name: "LastSeenOnly", current: pollSnapshot(lastSeen, `"etag-1"`), expected: nil},
+		{
+			name:     "DeviceRemoved",
+			current:  pollSnapshot(testDevices()[:2], `"etag-1"`),
+			expected: []string{"tailscale://devices", "tailscale://devices/3"},
+		},
+		{
+			name:     "PolicyChanged",
+			current:  pollSnapshot(testDevices(), `"etag-2"`),
+			expected: []string{"tailscale://acl", "tailscale://acl/raw"},
+		},
+		{name: "PolicyUnknown", current: pollSnapshot(testDevices(), ""), expected: nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			changes := resourceChanges(previous, tc.current, subscribed)
+			if !slices.Equal(changes, tc.expected) {
+				t.Errorf("Expected %v, got %v", tc.expected, changes)
+			}
+		})
+	}
+}
+
+func TestResourcePollerNotifiesSubscribers(t *testing.T) {
+	state := &tailnetState{devices: testDevices(), policyETag: `"etag-1"`}
+	client := state.client()
+	poller := NewResourcePoller(client, 10*time.Millisecond)
+	server := mcp.NewServer(&mcp.Implementation{}, &mcp.ServerOptions{
+		SubscribeHandler:   poller.Subscribe,
+		UnsubscribeHandler: poller.Unsubscribe,
+	})
+	poller.Attach(server)
+	RegisterResources(server, client, NewDeviceResolver(client))
+
+	updated := make(chan string, 10)
+	session := connectClientWithOptions(t, server, &mcp.ClientOptions{
+		ResourceUpdatedHandler: func(ctx context.Context, req *mcp.ResourceUpdatedNotificationRequest) {
+			updated <- req.Params.URI
+		},
+	})
+
+	ctx := context.Background()
+	if err := session.Subscribe(ctx, &mcp.SubscribeParams{URI: "tailscale://keys"}); err == nil || !strings.Contains(err.Error(), "does not support subscriptions") {
+		t.Errorf("Expected keys not to support subscriptions, got %v", err)
+	}
+	if poller.running() {
+		t.Fatal("Expected the poller not to run without subscriptions")
+	}
+
+	for _, uri := range []string{"tailscale://devices/db-1", "tailscale://acl"} {
+		if err := session.Subscribe(ctx, &mcp.SubscribeParams{URI: uri}); err != nil {
+			t.Fatalf("Failed to subscribe to %s: %v", uri, err)
+		}
+	}
+	if !poller.running() {
+		t.Fatal("Expected the poller to start on the first subscription")
+	}
+
+	// Let the poller take its baseline snapshot before the tailnet changes
+	time.Sleep(50 * time.Millisecond)
+	offline := testDevices()
+	offline[0].ConnectedToControl = false
+	state.set(offline, `"etag-2"`)
+
+	received := map[string]bool{}
+	timeout := time.After(5 * time.Second)
+	for len(received) < 2 {
+		select {
+		case uri := <-updated:
+			received[uri] = true
+		case <-timeout:
+			t.Fatalf("Expected notifications for the device and policy, got %v", received)
+		}
+	}
+	if !received["tailscale://devices/db-1"] || !received["tailscale://acl"] {
+		t.Errorf("Expected notifications for the subscribed URIs, got %v", received)
+	}
+
+	for _, uri := range []string{"tailscale://devices/db-1", "tailscale://acl"} {
+		if err := session.Unsubscribe(ctx, &mcp.UnsubscribeParams{URI: uri}); err != nil {
+			t.Fatalf("Failed to unsubscribe from %s: %v", uri, err)
+		}
+	}
+	if poller.running() {
+		t.Error("Expected the poller to stop when the last subscription is removed")
+	}
+}
+
+func TestResourcePollerStopsWhenSessionsClose(t *testing.T) {
+	state := &tailnetState{devices: testDevices(), policyETag: `"etag-1"`}
+	client := state.client()
+	poller := NewResourcePoller(client, 10*time.Millisecond)
+	server := mcp.NewServer(&mcp.Implementation{}, &mcp.ServerOptions{
+		SubscribeHandler:   poller.Subscribe,
+		UnsubscribeHandler: poller.Unsubscribe,
+	})
+	poller.Attach(server)
+	RegisterResources(server, client, NewDeviceResolver(client))
+
+	session := connectClient(t, server)
+	if err := session.Subscribe(context.Background(), &mcp.SubscribeParams{URI: "tailscale://devices"}); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+	session.Close()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for poller.running() {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the poller to stop once the subscribed session disconnected")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
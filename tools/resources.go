@@ -0,0 +1,243 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/yosida95/uritemplate/v3"
+	tailscale "tailscale.com/client/tailscale/v2"
+
+	"github.com/R167/tailscale-mcp/internal"
+)
+
+// MIME types of resource contents
+const (
+	mimeJSON   = "application/json"
+	mimeHuJSON = "application/hujson"
+)
+
+// URIs of the tailnet resources
+const (
+	devicesResourceURI = "tailscale://devices"
+	deviceResourceURI  = "tailscale://devices/{id}"
+	aclResourceURI     = "tailscale://acl"
+	aclRawResourceURI  = "tailscale://acl/raw"
+	keysResourceURI    = "tailscale://keys"
+	keyResourceURI     = "tailscale://keys/{id}"
+	userResourceURI    = "tailscale://users/{+user}"
+	idTemplateVar      = "id"
+	userTemplateVar    = "user"
+)
+
+// Templates that extract the reference from a resource URI. The user template allows reserved
+// characters so that login names can contain an unescaped @.
+var (
+	deviceResourceTemplate = uritemplate.MustNew(deviceResourceURI)
+	keyResourceTemplate    = uritemplate.MustNew(keyResourceURI)
+	userResourceTemplate   = uritemplate.MustNew(userResourceURI)
+)
+
+// RegisterResources exposes tailnet state as MCP resources so that clients can attach it as
+// context. Contents are redacted the same way as tool output. Device references are resolved
+// with resolver.
+func RegisterResources(server *mcp.Server, client internal.TailscaleClient, resolver *DeviceResolver) {
+	server.AddResource(
+		&mcp.Resource{
+			URI:         devicesResourceURI,
+			Name:        "devices",
+			Title:       "Devices",
+			Description: "Every device in the tailnet with its addresses, owner, tags, and connection status",
+			MIMEType:    mimeJSON,
+		},
+		jsonResource(func(ctx context.Context, uri string) (any, error) {
+			devices, err := client.Devices().List(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list devices: %w", err)
+			}
+
+			now := time.Now()
+			summaries := make([]DeviceSummary, 0, len(devices))
+			for _, device := range devices {
+				summaries = append(summaries, summarizeDevice(device, now, defaultOnlineThreshold))
+			}
+			return summaries, nil
+		}),
+	)
+
+	server.AddResourceTemplate(
+		&mcp.ResourceTemplate{
+			URITemplate: deviceResourceURI,
+			Name:        "device",
+			Title:       "Device",
+			Description: "All details of one device: " + deviceRefDescription,
+			MIMEType:    mimeJSON,
+		},
+		jsonResource(func(ctx context.Context, uri string) (any, error) {
+			ref := templateValue(deviceResourceTemplate, uri, idTemplateVar)
+			if ref == "" {
+				return nil, mcp.ResourceNotFoundError(uri)
+			}
+
+			deviceID, err := resolver.resolve(ctx, ref)
+			if err != nil {
+				return nil, err
+			}
+
+			device, err := client.Devices().GetWithAllFields(ctx, deviceID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get device: %w", err)
+			}
+			return device, nil
+		}),
+	)
+
+	server.AddResource(
+		&mcp.Resource{
+			URI:         aclResourceURI,
+			Name:        "acl",
+			Title:       "Policy file",
+			Description: "The tailnet policy file parsed as JSON",
+			MIMEType:    mimeJSON,
+		},
+		jsonResource(func(ctx context.Context, uri string) (any, error) {
+			acl, err := client.PolicyFile().Get(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get ACL policy: %w", err)
+			}
+			return acl, nil
+		}),
+	)
+
+	server.AddResource(
+		&mcp.Resource{
+			URI:         aclRawResourceURI,
+			Name:        "acl-raw",
+			Title:       "Policy file (HuJSON)",
+			Description: "The tailnet policy file as HuJSON text exactly as stored, including comments and ordering",
+			MIMEType:    mimeHuJSON,
+		},
+		func(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+			raw, err := client.PolicyFile().Raw(ctx)
+			if err != nil {
+				return nil, resourceError(req.Params.URI, fmt.Errorf("failed to get raw ACL policy: %w", err))
+			}
+
+			return &mcp.ReadResourceResult{
+				Contents: []*mcp.ResourceContents{{
+					URI:      req.Params.URI,
+					MIMEType: mimeHuJSON,
+					Text:     outputRedactor.Text(raw.HuJSON),
+				}},
+			}, nil
+		},
+	)
+
+	server.AddResource(
+		&mcp.Resource{
+			URI:         keysResourceURI,
+			Name:        "keys",
+			Title:       "Keys",
+			Description: "The tailnet's API, auth, and OAuth client keys with their capabilities and expiry, without secrets",
+			MIMEType:    mimeJSON,
+		},
+		jsonResource(func(ctx context.Context, uri string) (any, error) {
+			keys, err := client.Keys().List(ctx, true)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list API keys: %w", err)
+			}
+			return keys, nil
+		}),
+	)
+
+	server.AddResourceTemplate(
+		&mcp.ResourceTemplate{
+			URITemplate: keyResourceURI,
+			Name:        "key",
+			Title:       "Key",
+			Description: "One API, auth, or OAuth client key with its capabilities and expiry, without its secret",
+			MIMEType:    mimeJSON,
+		},
+		jsonResource(func(ctx context.Context, uri string) (any, error) {
+			keyID := templateValue(keyResourceTemplate, uri, idTemplateVar)
+			if keyID == "" {
+				return nil, mcp.ResourceNotFoundError(uri)
+			}
+
+			key, err := client.Keys().Get(ctx, keyID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get key: %w", err)
+			}
+			return key, nil
+		}),
+	)
+
+	server.AddResourceTemplate(
+		&mcp.ResourceTemplate{
+			URITemplate: userResourceURI,
+			Name:        "user",
+			Title:       "User",
+			Description: "One user's role and status, by user ID or login name",
+			MIMEType:    mimeJSON,
+		},
+		jsonResource(func(ctx context.Context, uri string) (any, error) {
+			ref := templateValue(userResourceTemplate, uri, userTemplateVar)
+			if ref == "" {
+				return nil, mcp.ResourceNotFoundError(uri)
+			}
+
+			user, err := resolveUser(ctx, client, ref)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get user: %w", err)
+			}
+			return user, nil
+		}),
+	)
+}
+
+// templateValue returns the value of the named variable in uri, or "" if uri does not match
+// the template
+func templateValue(template *uritemplate.Template, uri, name string) string {
+	match := template.Match(uri)
+	if match == nil {
+		return ""
+	}
+	return match.Get(name).String()
+}
+
+// jsonResource creates a resource handler serving the indented JSON encoding of the value
+// fetch returns, redacted like tool output
+func jsonResource(fetch func(ctx context.Context, uri string) (any, error)) mcp.ResourceHandler {
+	return func(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+		value, err := fetch(ctx, req.Params.URI)
+		if err != nil {
+			return nil, resourceError(req.Params.URI, err)
+		}
+
+		output, err := json.MarshalIndent(value, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize %s: %w", req.Params.URI, err)
+		}
+
+		return &mcp.ReadResourceResult{
+			Contents: []*mcp.ResourceContents{{
+				URI:      req.Params.URI,
+				MIMEType: mimeJSON,
+				Text:     outputRedactor.JSON(string(output)),
+			}},
+		}, nil
+	}
+}
+
+// resourceError reports an API 404 as the protocol's resource not found error, and redacts
+// the message of any other error
+func resourceError(uri string, err error) error {
+	notFound := mcp.ResourceNotFoundError(uri)
+	if errors.Is(err, notFound) || tailscale.IsNotFound(err) {
+		return notFound
+	}
+	return errors.New(outputRedactor.Text(err.Error()))
+}
@@ -0,0 +1,228 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	tailscale "tailscale.com/client/tailscale/v2"
+
+	"github.com/R167/tailscale-mcp/internal"
+)
+
+func resourceTestServer() *mcp.Server {
+	client := &internal.MockTailscaleClient{
+		DevicesFunc: func() internal.DevicesResource {
+			return &internal.MockDevicesResource{
+				ListFunc: func(ctx context.Context) ([]tailscale.Device, error) {
+					return testDevices(), nil
+				},
+				GetWithAllFieldsFunc: func(ctx context.Context, deviceID string) (*tailscale.Device, error) {
+					for _, device := range testDevices() {
+						if device.ID == deviceID {
+							device.NodeKey = "nodekey:0123456789abcdef"
+							return &device, nil
+						}
+					}
+					return nil, tailscale.APIError{Message: "device not found", Status: 404}
+				},
+			}
+		},
+		KeysFunc: func() internal.KeysResource {
+			return &internal.MockKeysResource{
+				ListFunc: func(ctx context.Context, all bool) ([]tailscale.Key, error) {
+					return testKeys(), nil
+				},
+				GetFunc: func(ctx context.Context, id string) (*tailscale.Key, error) {
+					for _, key := range testKeys() {
+						if key.ID == id {
+							return &key, nil
+						}
+					}
+					return nil, tailscale.APIError{Message: "key not found", Status: 404}
+				},
+			}
+		},
+		UsersFunc: func() internal.UsersResource {
+			return &internal.MockUsersResource{
+				ListFunc: func(ctx context.Context, userType *tailscale.UserType, role *tailscale.UserRole) ([]tailscale.User, error) {
+					return testUsers(), nil
+				},
+			}
+		},
+	}
+
+	server := mcp.NewServer(&mcp.Implementation{}, &mcp.ServerOptions{})
+	RegisterResources(server, client, NewDeviceResolver(client))
+	return server
+}
+
+func readResource(t *testing.T, session *mcp.ClientSession, uri string) *mcp.ResourceContents {
+	t.Helper()
+
+	result, err := session.ReadResource(context.Background(), &mcp.ReadResourceParams{URI: uri})
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", uri, err)
+	}
+	if len(result.Contents) != 1 {
+		t.Fatalf("Expected one content for %s, got %d", uri, len(result.Contents))
+	}
+	return result.Contents[0]
+}
+
+func TestListResources(t *testing.T) {
+	session := connectClient(t, resourceTestServer())
+
+	resources, err := session.ListResources(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("Failed to list resources: %v", err)
+	}
+	mimeTypes := map[string]string{}
+	for _, resource := range resources.Resources {
+		mimeTypes[resource.URI] = resource.MIMEType
+	}
+	expected := map[string]string{
+		"tailscale://devices": mimeJSON,
+		"tailscale://acl":     mimeJSON,
+		"tailscale://acl/raw": mimeHuJSON,
+		"tailscale://keys":    mimeJSON,
+	}
+	for uri, mimeType := range expected {
+		if mimeTypes[uri] != mimeType {
+			t.Errorf("Expected %s to be listed as %s, got %q", uri, mimeType, mimeTypes[uri])
+		}
+	}
+
+	templates, err := session.ListResourceTemplates(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("Failed to list resource templates: %v", err)
+	}
+	var uriTemplates []string
+	for _, template := range templates.ResourceTemplates {
+		uriTemplates = append(uriTemplates, template.URITemplate)
+	}
+	slices.Sort(uriTemplates)
+	expectedTemplates := []string{"tailscale://devices/{id}", "tailscale://keys/{id}", "tailscale://users/{+user}"}
+	if !slices.Equal(uriTemplates, expectedTemplates) {
+		t.Errorf("Expected the templates %v, got %v", expectedTemplates, uriTemplates)
+	}
+}
+
+func TestReadResources(t *testing.T) {
+	session := connectClient(t, resourceTestServer())
+
+	devices := readResource(t, session, "tailscale://devices")
+	var summaries []DeviceSummary
+	if err := json.Unmarshal([]byte(devices.Text), &summaries); err != nil {
+		t.Fatalf("Expected devices as JSON: %v", err)
+	}
+	if devices.MIMEType != mimeJSON || len(summaries) != len(testDevices()) {
+		t.Errorf("Expected every device as JSON, got %s: %s", devices.MIMEType, devices.Text)
+	}
+
+	raw := readResource(t, session, "tailscale://acl/raw")
+	if raw.MIMEType != mimeHuJSON || raw.Text != internal.MockPolicyHuJSON {
+		t.Errorf("Expected the policy as stored, got %s: %s", raw.MIMEType, raw.Text)
+	}
+
+	acl := readResource(t, session, "tailscale://acl")
+	if acl.MIMEType != mimeJSON || !json.Valid([]byte(acl.Text)) {
+		t.Errorf("Expected the policy as JSON, got %s: %s", acl.MIMEType, acl.Text)
+	}
+
+	keys := readResource(t, session, "tailscale://keys")
+	if keys.MIMEType != mimeJSON || !strings.Contains(keys.Text, "automation") || strings.Contains(keys.Text, "-secret") {
+		t.Errorf("Expected keys without secrets, got %s", keys.Text)
+	}
+}
+
+func TestReadDeviceResource(t *testing.T) {
+	session := connectClient(t, resourceTestServer())
+
+	for _, uri := range []string{"tailscale://devices/1", "tailscale://devices/db-1"} {
+		device := readResource(t, session, uri)
+		if device.URI != uri || device.MIMEType != mimeJSON {
+			t.Errorf("Expected %s as JSON, got %s %s", uri, device.URI, device.MIMEType)
+		}
+		if !strings.Contains(device.Text, `"name": "db-1.example.ts.net"`) {
+			t.Errorf("Expected %s to resolve to db-1: %s", uri, device.Text)
+		}
+		if strings.Contains(device.Text, "0123456789abcdef") {
+			t.Errorf("Expected the node key to be redacted: %s", device.Text)
+		}
+	}
+
+	_, err := session.ReadResource(context.Background(), &mcp.ReadResourceParams{URI: "tailscale://devices/nodeXYZ123"})
+	if err == nil || !strings.Contains(err.Error(), "Resource not found") {
+		t.Errorf("Expected an unknown device to be a resource not found error, got %v", err)
+	}
+}
+
+func TestDeviceResourceAfterRename(t *testing.T) {
+	devices := testDevices()
+	client := &internal.MockTailscaleClient{
+		DevicesFunc: func() internal.DevicesResource {
+			return &internal.MockDevicesResource{
+				ListFunc: func(ctx context.Context) ([]tailscale.Device, error) {
+					return slices.Clone(devices), nil
+				},
+				GetWithAllFieldsFunc: func(ctx context.Context, deviceID string) (*tailscale.Device, error) {
+					for _, device := range devices {
+						if device.ID == deviceID {
+							return &device, nil
+						}
+					}
+					return nil, tailscale.APIError{Message: "device not found", Status: 404}
+				},
+				SetNameFunc: func(ctx context.Context, deviceID, newName string) error {
+					devices[0].Name = newName + ".example.ts.net"
+					return nil
+				},
+			}
+		},
+	}
+
+	// The tools and resources share a resolver, as they do in the server
+	resolver := NewDeviceResolver(client)
+	server := mcp.NewServer(&mcp.Implementation{}, &mcp.ServerOptions{})
+	RegisterDeviceTools(server, client, resolver)
+	RegisterResources(server, client, resolver)
+	session := connectClient(t, server)
+	ctx := context.Background()
+
+	readResource(t, session, "tailscale://devices/db-1.example.ts.net")
+
+	result, err := session.CallTool(ctx, &mcp.CallToolParams{Name: "rename_device", Arguments: map[string]any{"deviceID": "1", "name": "db-9"}})
+	if err != nil || result.IsError {
+		t.Fatalf("Failed to rename device: %v %+v", err, result)
+	}
+
+	if _, err := session.ReadResource(ctx, &mcp.ReadResourceParams{URI: "tailscale://devices/db-1.example.ts.net"}); err == nil {
+		t.Error("Expected the old name to stop resolving right after the rename")
+	}
+	if device := readResource(t, session, "tailscale://devices/db-9"); !strings.Contains(device.Text, `"id": "1"`) {
+		t.Errorf("Expected the new name to resolve right after the rename: %s", device.Text)
+	}
+}
+
+func TestReadKeyAndUserResources(t *testing.T) {
+	session := connectClient(t, resourceTestServer())
+
+	key := readResource(t, session, "tailscale://keys/expiring")
+	if !strings.Contains(key.Text, `"description": "automation"`) || strings.Contains(key.Text, "-secret") {
+		t.Errorf("Expected the key without its secret, got %s", key.Text)
+	}
+
+	user := readResource(t, session, "tailscale://users/alice@example.com")
+	if !strings.Contains(user.Text, `"id": "u1"`) {
+		t.Errorf("Expected the user by login name, got %s", user.Text)
+	}
+
+	_, err := session.ReadResource(context.Background(), &mcp.ReadResourceParams{URI: "tailscale://keys/missing"})
+	if err == nil || !strings.Contains(err.Error(), "Resource not found") {
+		t.Errorf("Expected an unknown key to be a resource not found error, got %v", err)
+	}
+}
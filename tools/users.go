@@ -0,0 +1,469 @@
+package tools
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	tailscale "tailscale.com/client/tailscale/v2"
+
+	"github.com/R167/tailscale-mcp/internal"
+)
+
+const userRefDescription = "a user ID or login name (e.g. alice@example.com)"
+
+var (
+	userRoles    = []any{"owner", "admin", "it-admin", "network-admin", "billing-admin", "auditor", "member"}
+	userStatuses = []any{"active", "idle", "suspended", "needs-approval", "over-billing-limit"}
+	userTypes    = []any{"member", "shared"}
+
+	// assignableUserRoles are the roles set_user_role can grant. The API cannot make a user the
+	// owner; ownership is transferred in the admin console.
+	assignableUserRoles = []any{"admin", "it-admin", "network-admin", "billing-admin", "auditor", "member"}
+)
+
+// UserSummary is a user joined with the devices they own, returned by the user tools
+type UserSummary struct {
+	ID          string `json:"id"`
+	LoginName   string `json:"loginName"`
+	DisplayName string `json:"displayName"`
+	Role        string `json:"role"`
+	Status      string `json:"status"`
+	Type        string `json:"type"`
+	Created     string `json:"created,omitempty"`
+	// DeviceCount is the number of devices in the device list that the user owns
+	DeviceCount int `json:"deviceCount"`
+	// Connected is set when any of the user's devices is connected to the control plane
+	Connected bool `json:"connected"`
+	// LastSeen is the most recent time any of the user's devices was seen
+	LastSeen string `json:"lastSeen,omitempty"`
+}
+
+// summarizeUser joins a user with the devices in the device list that they own
+func summarizeUser(user tailscale.User, devices []tailscale.Device) UserSummary {
+	summary := UserSummary{
+		ID:          user.ID,
+		LoginName:   user.LoginName,
+		DisplayName: user.DisplayName,
+		Role:        string(user.Role),
+		Status:      string(user.Status),
+		Type:        string(user.Type),
+	}
+	if !user.Created.IsZero() {
+		summary.Created = user.Created.String()
+	}
+
+	var lastSeen time.Time
+	for _, device := range userDevices(user, devices) {
+		summary.DeviceCount++
+		summary.Connected = summary.Connected || device.ConnectedToControl
+		if seen := deviceLastSeen(device); seen.After(lastSeen) {
+			lastSeen = seen
+		}
+	}
+	if !lastSeen.IsZero() {
+		summary.LastSeen = lastSeen.String()
+	}
+
+	return summary
+}
+
+// userDevices returns the devices owned by user
+func userDevices(user tailscale.User, devices []tailscale.Device) []tailscale.Device {
+	var owned []tailscale.Device
+	for _, device := range devices {
+		if strings.EqualFold(device.User, user.LoginName) {
+			owned = append(owned, device)
+		}
+	}
+	return owned
+}
+
+// resolveUser looks a user up by ID or, when ref contains @, by login name
+func resolveUser(ctx context.Context, client internal.TailscaleClient, ref string) (*tailscale.User, error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return nil, fmt.Errorf("user cannot be empty")
+	}
+
+	if !strings.Contains(ref, "@") {
+		if err := validateID("user ID", ref); err != nil {
+			return nil, err
+		}
+		return client.Users().Get(ctx, ref)
+	}
+
+	users, err := client.Users().List(ctx, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, user := range users {
+		if strings.EqualFold(user.LoginName, ref) {
+			return &user, nil
+		}
+	}
+	return nil, fmt.Errorf("no user has login name %q", ref)
+}
+
+func RegisterUserTools(server *mcp.Server, client internal.TailscaleClient) {
+	// List users tool
+	mcp.AddTool(
+		server,
+		&mcp.Tool{
+			Name: "list_users",
+			Description: "List users in the tailnet with their role and status, joined with how many devices each owns " +
+				"and when those devices were last seen",
+			InputSchema: &jsonschema.Schema{
+				Type: "object",
+				Properties: map[string]*jsonschema.Schema{
+					"role": {
+						Type:        "string",
+						Description: "Only list users with this role",
+						Enum:        userRoles,
+					},
+					"status": {
+						Type:        "string",
+						Description: "Only list users with this status",
+						Enum:        userStatuses,
+					},
+					"type": {
+						Type:        "string",
+						Description: "Only list members of the tailnet, or users the tailnet shares devices with",
+						Enum:        userTypes,
+					},
+				},
+				AdditionalProperties: &jsonschema.Schema{Not: &jsonschema.Schema{}},
+			},
+		},
+		func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
+			role, err := getOptionalStringParam(args, "role")
+			if err != nil {
+				return toolError("Invalid role parameter", err), nil, nil
+			}
+
+			status, err := getOptionalStringParam(args, "status")
+			if err != nil {
+				return toolError("Invalid status parameter", err), nil, nil
+			}
+
+			userType, err := getOptionalStringParam(args, "type")
+			if err != nil {
+				return toolError("Invalid type parameter", err), nil, nil
+			}
+
+			var roleFilter *tailscale.UserRole
+			if role != "" {
+				roleFilter = (*tailscale.UserRole)(&role)
+			}
+			var typeFilter *tailscale.UserType
+			if userType != "" {
+				typeFilter = (*tailscale.UserType)(&userType)
+			}
+
+			users, err := client.Users().List(ctx, typeFilter, roleFilter)
+			if err != nil {
+				return toolError("Failed to list users", err), nil, nil
+			}
+
+			devices, err := client.Devices().List(ctx)
+			if err != nil {
+				return toolError("Failed to list devices", err), nil, nil
+			}
+
+			summaries := make([]UserSummary, 0, len(users))
+			for _, user := range users {
+				if status != "" && string(user.Status) != status {
+					continue
+				}
+				summaries = append(summaries, summarizeUser(user, devices))
+			}
+			slices.SortFunc(summaries, func(a, b UserSummary) int {
+				return cmp.Compare(strings.ToLower(a.LoginName), strings.ToLower(b.LoginName))
+			})
+
+			result := struct {
+				Total int           `json:"total"`
+				Users []UserSummary `json:"users"`
+			}{
+				Total: len(summaries),
+				Users: summaries,
+			}
+
+			output, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				return toolError("Failed to serialize users", err), nil, nil
+			}
+
+			return toolSuccess(string(output)), nil, nil
+		},
+	)
+
+	// Get user tool
+	mcp.AddTool(
+		server,
+		&mcp.Tool{
+			Name:        "get_user",
+			Description: "Get a user's details together with the devices they own",
+			InputSchema: userSchema("The user to get"),
+		},
+		func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
+			ref, err := getStringParam(args, "user")
+			if err != nil {
+				return toolError("Invalid user parameter", err), nil, nil
+			}
+
+			user, err := resolveUser(ctx, client, ref)
+			if err != nil {
+				return toolError("Failed to get user", err), nil, nil
+			}
+
+			devices, err := client.Devices().List(ctx)
+			if err != nil {
+				return toolError("Failed to list devices", err), nil, nil
+			}
+
+			now := time.Now()
+			owned := []DeviceSummary{}
+			for _, device := range userDevices(*user, devices) {
+				owned = append(owned, summarizeDevice(device, now, defaultOnlineThreshold))
+			}
+
+			result := struct {
+				UserSummary
+				Devices []DeviceSummary `json:"devices"`
+			}{
+				UserSummary: summarizeUser(*user, devices),
+				Devices:     owned,
+			}
+
+			output, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				return toolError("Failed to serialize user", err), nil, nil
+			}
+
+			return toolSuccess(string(output)), nil, nil
+		},
+	)
+
+	// Set user role tool
+	roleSchema := userSchema("The user whose role to change")
+	roleSchema.Properties["role"] = &jsonschema.Schema{
+		Type:        "string",
+		Description: "The new role. The owner role cannot be assigned.",
+		Enum:        assignableUserRoles,
+	}
+	roleSchema.Required = append(roleSchema.Required, "role")
+
+	mcp.AddTool(
+		server,
+		&mcp.Tool{
+			Name:        "set_user_role",
+			Description: "Change a user's role in the tailnet, e.g. to grant or remove admin access",
+			InputSchema: roleSchema,
+		},
+		func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
+			ref, err := getStringParam(args, "user")
+			if err != nil {
+				return toolError("Invalid user parameter", err), nil, nil
+			}
+
+			role, err := getStringParam(args, "role")
+			if err != nil {
+				return toolError("Invalid role parameter", err), nil, nil
+			}
+
+			user, err := resolveUser(ctx, client, ref)
+			if err != nil {
+				return toolError("Failed to get user", err), nil, nil
+			}
+
+			if string(user.Role) != role {
+				if err := client.Users().SetRole(ctx, user.ID, tailscale.UserRole(role)); err != nil {
+					return toolError("Failed to set user role", err), nil, nil
+				}
+			}
+
+			result := struct {
+				UserID    string `json:"userId"`
+				LoginName string `json:"loginName"`
+				Changed   bool   `json:"changed"`
+				Previous  string `json:"previous"`
+				Role      string `json:"role"`
+			}{
+				UserID:    user.ID,
+				LoginName: user.LoginName,
+				Changed:   string(user.Role) != role,
+				Previous:  string(user.Role),
+				Role:      role,
+			}
+
+			output, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				return toolError("Failed to serialize user role", err), nil, nil
+			}
+
+			return toolSuccess(string(output)), nil, nil
+		},
+	)
+
+	// Suspend user tool
+	mcp.AddTool(
+		server,
+		&mcp.Tool{
+			Name:        "suspend_user",
+			Description: "Suspend a user, blocking them and their devices from the tailnet until they are restored",
+			InputSchema: userSchema("The user to suspend"),
+		},
+		userSuspensionHandler(client, true),
+	)
+
+	// Restore user tool
+	mcp.AddTool(
+		server,
+		&mcp.Tool{
+			Name:        "restore_user",
+			Description: "Restore a suspended user",
+			InputSchema: userSchema("The user to restore"),
+		},
+		userSuspensionHandler(client, false),
+	)
+
+	// Delete user tool
+	deleteSchema := userSchema("The user to delete")
+	deleteSchema.Properties["confirm"] = &jsonschema.Schema{
+		Type:        "string",
+		Description: "The login name of the user being deleted, to confirm the right user is targeted",
+	}
+	deleteSchema.Required = append(deleteSchema.Required, "confirm")
+
+	mcp.AddTool(
+		server,
+		&mcp.Tool{
+			Name: "delete_user",
+			Description: "Permanently delete a user and the devices they own from the tailnet. The confirm argument " +
+				"must repeat the user's login name exactly, as shown by get_user.",
+			InputSchema: deleteSchema,
+		},
+		func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
+			ref, err := getStringParam(args, "user")
+			if err != nil {
+				return toolError("Invalid user parameter", err), nil, nil
+			}
+
+			confirm, err := getStringParam(args, "confirm")
+			if err != nil {
+				return toolError("Invalid confirm parameter", err), nil, nil
+			}
+
+			user, err := resolveUser(ctx, client, ref)
+			if err != nil {
+				return toolError("Failed to get user", err), nil, nil
+			}
+
+			if confirm != user.LoginName {
+				return toolError("Deletion not confirmed",
+					fmt.Errorf("confirm %q does not match the login name of user %s (%q)", confirm, user.ID, user.LoginName)), nil, nil
+			}
+
+			devices, err := client.Devices().List(ctx)
+			if err != nil {
+				return toolError("Failed to list devices", err), nil, nil
+			}
+
+			if err := client.Users().Delete(ctx, user.ID); err != nil {
+				return toolError("Failed to delete user", err), nil, nil
+			}
+
+			result := struct {
+				UserSummary
+				Deleted bool `json:"deleted"`
+			}{
+				UserSummary: summarizeUser(*user, devices),
+				Deleted:     true,
+			}
+
+			output, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				return toolError("Failed to serialize deleted user", err), nil, nil
+			}
+
+			return toolSuccess(string(output)), nil, nil
+		},
+	)
+}
+
+// userSchema returns the input schema for tools that take only a user reference
+func userSchema(description string) *jsonschema.Schema {
+	return &jsonschema.Schema{
+		Type: "object",
+		Properties: map[string]*jsonschema.Schema{
+			"user": {
+				Type:        "string",
+				Description: description + ": " + userRefDescription,
+			},
+		},
+		Required:             []string{"user"},
+		AdditionalProperties: &jsonschema.Schema{Not: &jsonschema.Schema{}},
+	}
+}
+
+// userSuspensionHandler returns a tool handler that suspends or restores a user and reports
+// their status before and after the change
+func userSuspensionHandler(client internal.TailscaleClient, suspend bool) mcp.ToolHandlerFor[map[string]any, any] {
+	return func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
+		ref, err := getStringParam(args, "user")
+		if err != nil {
+			return toolError("Invalid user parameter", err), nil, nil
+		}
+
+		before, err := resolveUser(ctx, client, ref)
+		if err != nil {
+			return toolError("Failed to get user", err), nil, nil
+		}
+
+		suspended := before.Status == tailscale.UserStatusSuspended
+		after := before
+		if suspended != suspend {
+			if suspend {
+				err = client.Users().Suspend(ctx, before.ID)
+			} else {
+				err = client.Users().Restore(ctx, before.ID)
+			}
+			if err != nil {
+				return toolError("Failed to update user status", err), nil, nil
+			}
+
+			after, err = client.Users().Get(ctx, before.ID)
+			if err != nil {
+				return toolError("User status was updated but the user could not be re-read", err), nil, nil
+			}
+		}
+
+		result := struct {
+			UserID    string `json:"userId"`
+			LoginName string `json:"loginName"`
+			Changed   bool   `json:"changed"`
+			Previous  string `json:"previous"`
+			Status    string `json:"status"`
+		}{
+			UserID:    before.ID,
+			LoginName: before.LoginName,
+			Changed:   before.Status != after.Status,
+			Previous:  string(before.Status),
+			Status:    string(after.Status),
+		}
+
+		output, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return toolError("Failed to serialize user status", err), nil, nil
+		}
+
+		return toolSuccess(string(output)), nil, nil
+	}
+}
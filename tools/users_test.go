@@ -0,0 +1,262 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	tailscale "tailscale.com/client/tailscale/v2"
+
+	"github.com/R167/tailscale-mcp/internal"
+)
+
+func testUsers() []tailscale.User {
+	return []tailscale.User{
+		{ID: "u2", LoginName: "bob@example.com", Role: tailscale.UserRoleMember, Status: tailscale.UserStatusIdle, Type: tailscale.UserTypeMember},
+		{ID: "u1", LoginName: "alice@example.com", Role: tailscale.UserRoleAdmin, Status: tailscale.UserStatusActive, Type: tailscale.UserTypeMember},
+		{ID: "u3", LoginName: "carol@example.com", Role: tailscale.UserRoleMember, Status: tailscale.UserStatusSuspended, Type: tailscale.UserTypeMember},
+	}
+}
+
+// userStore is an in-memory user list behind a mock client, recording the actions taken
+type userStore struct {
+	users   []tailscale.User
+	actions []string
+}
+
+func (s *userStore) client() *internal.MockTailscaleClient {
+	find := func(userID string) *tailscale.User {
+		for i := range s.users {
+			if s.users[i].ID == userID {
+				return &s.users[i]
+			}
+		}
+		return nil
+	}
+
+	return &internal.MockTailscaleClient{
+		DevicesFunc: func() internal.DevicesResource {
+			return &internal.MockDevicesResource{
+				ListFunc: func(ctx context.Context) ([]tailscale.Device, error) {
+					return testDevices(), nil
+				},
+			}
+		},
+		UsersFunc: func() internal.UsersResource {
+			return &internal.MockUsersResource{
+				ListFunc: func(ctx context.Context, userType *tailscale.UserType, role *tailscale.UserRole) ([]tailscale.User, error) {
+					var users []tailscale.User
+					for _, user := range s.users {
+						if (userType == nil || user.Type == *userType) && (role == nil || user.Role == *role) {
+							users = append(users, user)
+						}
+					}
+					return users, nil
+				},
+				GetFunc: func(ctx context.Context, userID string) (*tailscale.User, error) {
+					user := find(userID)
+					if user == nil {
+						return nil, tailscale.APIError{Message: "user not found", Status: 404}
+					}
+					copied := *user
+					return &copied, nil
+				},
+				SetRoleFunc: func(ctx context.Context, userID string, role tailscale.UserRole) error {
+					s.actions = append(s.actions, "role "+userID+" "+string(role))
+					find(userID).Role = role
+					return nil
+				},
+				SuspendFunc: func(ctx context.Context, userID string) error {
+					s.actions = append(s.actions, "suspend "+userID)
+					find(userID).Status = tailscale.UserStatusSuspended
+					return nil
+				},
+				RestoreFunc: func(ctx context.Context, userID string) error {
+					s.actions = append(s.actions, "restore "+userID)
+					find(userID).Status = tailscale.UserStatusActive
+					return nil
+				},
+				DeleteFunc: func(ctx context.Context, userID string) error {
+					s.actions = append(s.actions, "delete "+userID)
+					return nil
+				},
+			}
+		},
+	}
+}
+
+func (s *userStore) server() *mcp.Server {
+	server := mcp.NewServer(&mcp.Implementation{}, &mcp.ServerOptions{})
+	RegisterUserTools(server, s.client())
+	return server
+}
+
+func TestSummarizeUser(t *testing.T) {
+	users := testUsers()
+
+	alice := summarizeUser(users[1], testDevices())
+	if alice.DeviceCount != 2 || !alice.Connected {
+		t.Errorf("Expected alice to own two devices, one connected, got %+v", alice)
+	}
+	if want := testNow.Add(-time.Hour).String(); alice.LastSeen != want {
+		t.Errorf("Expected alice's last seen to be %s, got %s", want, alice.LastSeen)
+	}
+
+	bob := summarizeUser(users[0], testDevices())
+	if bob.DeviceCount != 1 || bob.Connected || bob.LastSeen != "" {
+		t.Errorf("Expected bob's never-seen device to be counted without a last seen time, got %+v", bob)
+	}
+
+	carol := summarizeUser(users[2], testDevices())
+	if carol.DeviceCount != 0 {
+		t.Errorf("Expected carol to own no devices, got %+v", carol)
+	}
+}
+
+func TestListUsers(t *testing.T) {
+	store := &userStore{users: testUsers()}
+
+	testCases := []struct {
+		name     string
+		args     map[string]any
+		expected []string
+	}{
+		{name: "All", args: nil, expected: []string{"alice@example.com", "bob@example.com", "carol@example.com"}},
+		{name: "Role", args: map[string]any{"role": "member"}, expected: []string{"bob@example.com", "carol@example.com"}},
+		{name: "Status", args: map[string]any{"status": "suspended"}, expected: []string{"carol@example.com"}},
+		{name: "Type", args: map[string]any{"type": "shared"}, expected: []string{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, text := callTool(t, store.server(), "list_users", tc.args)
+			if result.IsError {
+				t.Fatalf("Expected success, got error: %s", text)
+			}
+
+			var output struct {
+				Total int           `json:"total"`
+				Users []UserSummary `json:"users"`
+			}
+			if err := json.Unmarshal([]byte(text), &output); err != nil {
+				t.Fatalf("Failed to unmarshal output: %v", err)
+			}
+
+			var logins []string
+			for _, user := range output.Users {
+				logins = append(logins, user.LoginName)
+			}
+			if output.Total != len(tc.expected) || strings.Join(logins, ",") != strings.Join(tc.expected, ",") {
+				t.Errorf("Expected %v, got %v", tc.expected, logins)
+			}
+		})
+	}
+}
+
+func TestGetUser(t *testing.T) {
+	store := &userStore{users: testUsers()}
+
+	result, text := callTool(t, store.server(), "get_user", map[string]any{"user": "ALICE@example.com"})
+	if result.IsError {
+		t.Fatalf("Expected success, got error: %s", text)
+	}
+
+	var output struct {
+		ID          string          `json:"id"`
+		DeviceCount int             `json:"deviceCount"`
+		Devices     []DeviceSummary `json:"devices"`
+	}
+	if err := json.Unmarshal([]byte(text), &output); err != nil {
+		t.Fatalf("Failed to unmarshal output: %v", err)
+	}
+	if output.ID != "u1" || output.DeviceCount != 2 || len(output.Devices) != 2 {
+		t.Errorf("Expected alice with her two devices: %s", text)
+	}
+
+	result, text = callTool(t, store.server(), "get_user", map[string]any{"user": "dave@example.com"})
+	if !result.IsError || !strings.Contains(text, "no user has login name") {
+		t.Errorf("Expected an unknown user error, got %s", text)
+	}
+
+	// IDs end up in the API path, so path separators and query characters are rejected
+	result, text = callTool(t, store.server(), "get_user", map[string]any{"user": "u1/../keys"})
+	if !result.IsError || !strings.Contains(text, "user ID contains invalid characters") {
+		t.Errorf("Expected an invalid user ID error, got %s", text)
+	}
+}
+
+func TestSetUserRole(t *testing.T) {
+	store := &userStore{users: testUsers()}
+
+	result, text := callTool(t, store.server(), "set_user_role", map[string]any{"user": "u2", "role": "network-admin"})
+	if result.IsError {
+		t.Fatalf("Expected success, got error: %s", text)
+	}
+	if !strings.Contains(text, `"previous": "member"`) || !strings.Contains(text, `"changed": true`) {
+		t.Errorf("Expected the role change in the output: %s", text)
+	}
+
+	result, text = callTool(t, store.server(), "set_user_role", map[string]any{"user": "u2", "role": "network-admin"})
+	if result.IsError || !strings.Contains(text, `"changed": false`) {
+		t.Errorf("Expected an unchanged role, got %s", text)
+	}
+	if strings.Join(store.actions, ",") != "role u2 network-admin" {
+		t.Errorf("Expected a single role change, got %v", store.actions)
+	}
+
+	// The API cannot assign the owner role, so the schema does not offer it
+	session := connectClient(t, store.server())
+	params := &mcp.CallToolParams{Name: "set_user_role", Arguments: map[string]any{"user": "u2", "role": "owner"}}
+	if result, err := session.CallTool(context.Background(), params); err == nil && !result.IsError {
+		t.Error("Expected the owner role to be rejected")
+	}
+	if len(store.actions) != 1 {
+		t.Errorf("Expected no further role changes, got %v", store.actions)
+	}
+}
+
+func TestSuspendAndRestoreUser(t *testing.T) {
+	store := &userStore{users: testUsers()}
+
+	result, text := callTool(t, store.server(), "suspend_user", map[string]any{"user": "bob@example.com"})
+	if result.IsError || !strings.Contains(text, `"status": "suspended"`) {
+		t.Errorf("Expected bob to be suspended, got %s", text)
+	}
+
+	result, text = callTool(t, store.server(), "restore_user", map[string]any{"user": "u3"})
+	if result.IsError || !strings.Contains(text, `"previous": "suspended"`) || !strings.Contains(text, `"status": "active"`) {
+		t.Errorf("Expected carol to be restored, got %s", text)
+	}
+
+	result, text = callTool(t, store.server(), "restore_user", map[string]any{"user": "u1"})
+	if result.IsError || !strings.Contains(text, `"changed": false`) {
+		t.Errorf("Expected restoring an active user to change nothing, got %s", text)
+	}
+
+	if strings.Join(store.actions, ",") != "suspend u2,restore u3" {
+		t.Errorf("Unexpected actions %v", store.actions)
+	}
+}
+
+func TestDeleteUser(t *testing.T) {
+	store := &userStore{users: testUsers()}
+
+	result, text := callTool(t, store.server(), "delete_user", map[string]any{"user": "u2", "confirm": "alice@example.com"})
+	if !result.IsError || !strings.Contains(text, "does not match") {
+		t.Errorf("Expected a confirmation mismatch, got %s", text)
+	}
+	if len(store.actions) != 0 {
+		t.Fatalf("Expected nothing to be deleted, got %v", store.actions)
+	}
+
+	result, text = callTool(t, store.server(), "delete_user", map[string]any{"user": "u2", "confirm": "bob@example.com"})
+	if result.IsError || !strings.Contains(text, `"deleted": true`) || !strings.Contains(text, `"deviceCount": 1`) {
+		t.Errorf("Expected bob to be deleted, got %s", text)
+	}
+	if strings.Join(store.actions, ",") != "delete u2" {
+		t.Errorf("Unexpected actions %v", store.actions)
+	}
+}
@@ -0,0 +1,542 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	tailscale "tailscale.com/client/tailscale/v2"
+
+	"github.com/R167/tailscale-mcp/internal"
+	"github.com/R167/tailscale-mcp/redact"
+)
+
+// genericWebhookProvider names the provider type the API leaves empty: a plain JSON payload
+const genericWebhookProvider = "generic"
+
+var webhookProviders = []any{
+	genericWebhookProvider,
+	string(tailscale.WebhookSlackProviderType),
+	string(tailscale.WebhookMattermostProviderType),
+	string(tailscale.WebhookGoogleChatProviderType),
+	string(tailscale.WebhookDiscordProviderType),
+}
+
+// webhookEventCategories maps each category subscription to the events it implies
+var webhookEventCategories = map[tailscale.WebhookSubscriptionType][]tailscale.WebhookSubscriptionType{
+	tailscale.WebhookCategoryTailnetManagement: {
+		tailscale.WebhookNodeCreated,
+		tailscale.WebhookNodeNeedsApproval,
+		tailscale.WebhookNodeApproved,
+		tailscale.WebhookNodeKeyExpiringInOneDay,
+		tailscale.WebhookNodeKeyExpired,
+		tailscale.WebhookNodeDeleted,
+		tailscale.WebhookPolicyUpdate,
+		tailscale.WebhookUserCreated,
+		tailscale.WebhookUserNeedsApproval,
+		tailscale.WebhookUserSuspended,
+		tailscale.WebhookUserRestored,
+		tailscale.WebhookUserDeleted,
+		tailscale.WebhookUserApproved,
+		tailscale.WebhookUserRoleUpdated,
+	},
+	tailscale.WebhookCategoryDeviceMisconfigurations: {
+		tailscale.WebhookSubnetIPForwardingNotEnabled,
+		tailscale.WebhookExitNodeIPForwardingNotEnabled,
+	},
+}
+
+// webhookEvents lists every subscription type, each category followed by its events
+var webhookEvents = func() []any {
+	var events []any
+	for _, category := range []tailscale.WebhookSubscriptionType{
+		tailscale.WebhookCategoryTailnetManagement,
+		tailscale.WebhookCategoryDeviceMisconfigurations,
+	} {
+		events = append(events, string(category))
+		for _, event := range webhookEventCategories[category] {
+			events = append(events, string(event))
+		}
+	}
+	return events
+}()
+
+const webhookSubscriptionsDescription = "Events to send to the endpoint. A category " +
+	"(categoryTailnetManagement, categoryDeviceMisconfigurations) includes all of its events, including future ones."
+
+// WebhookSummary describes a webhook endpoint without its secret
+type WebhookSummary struct {
+	ID            string   `json:"id"`
+	URL           string   `json:"url"`
+	Provider      string   `json:"provider"`
+	Creator       string   `json:"creator,omitempty"`
+	Created       string   `json:"created,omitempty"`
+	LastModified  string   `json:"lastModified,omitempty"`
+	Subscriptions []string `json:"subscriptions"`
+}
+
+func summarizeWebhook(webhook tailscale.Webhook) WebhookSummary {
+	summary := WebhookSummary{
+		ID:            webhook.EndpointID,
+		URL:           webhook.EndpointURL,
+		Provider:      webhookProviderName(webhook.ProviderType),
+		Creator:       webhook.CreatorLoginName,
+		Subscriptions: make([]string, 0, len(webhook.Subscriptions)),
+	}
+	for _, subscription := range webhook.Subscriptions {
+		summary.Subscriptions = append(summary.Subscriptions, string(subscription))
+	}
+	if !webhook.Created.IsZero() {
+		summary.Created = webhook.Created.Format(time.RFC3339)
+	}
+	if !webhook.LastModified.IsZero() {
+		summary.LastModified = webhook.LastModified.Format(time.RFC3339)
+	}
+	return summary
+}
+
+// webhookProviderName names a provider type, calling the empty one generic
+func webhookProviderName(provider tailscale.WebhookProviderType) string {
+	if provider == tailscale.WebhookEmptyProviderType {
+		return genericWebhookProvider
+	}
+	return string(provider)
+}
+
+// webhookReceives reports whether a webhook is sent event, directly or through its category
+func webhookReceives(webhook tailscale.Webhook, event tailscale.WebhookSubscriptionType) bool {
+	for _, subscription := range webhook.Subscriptions {
+		if subscription == event || slices.Contains(webhookEventCategories[subscription], event) {
+			return true
+		}
+	}
+	return false
+}
+
+// getWebhookSubscriptionsParam extracts the required, non-empty list of subscriptions,
+// dropping duplicates
+func getWebhookSubscriptionsParam(params map[string]any) ([]tailscale.WebhookSubscriptionType, error) {
+	events, err := getOptionalStringSliceParam(params, "subscriptions")
+	if err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, fmt.Errorf("at least one subscription is required")
+	}
+
+	var subscriptions []tailscale.WebhookSubscriptionType
+	for _, event := range events {
+		if !slices.Contains(webhookEvents, any(event)) {
+			return nil, fmt.Errorf("unknown subscription %q", event)
+		}
+		if subscription := tailscale.WebhookSubscriptionType(event); !slices.Contains(subscriptions, subscription) {
+			subscriptions = append(subscriptions, subscription)
+		}
+	}
+	return subscriptions, nil
+}
+
+// validateWebhookURL checks that an endpoint URL is an absolute HTTPS URL
+func validateWebhookURL(endpoint string) error {
+	parsed, err := url.Parse(endpoint)
+	if err != nil {
+		return fmt.Errorf("invalid URL: %w", err)
+	}
+	if parsed.Scheme != "https" || parsed.Host == "" {
+		return fmt.Errorf("endpoint URL must be an absolute https:// URL, got %q", endpoint)
+	}
+	return nil
+}
+
+// webhookSecretResult describes a webhook along with a secret that is shown only once
+type webhookSecretResult struct {
+	WebhookSummary
+	Secret string `json:"secret,omitempty"`
+	Notice string `json:"notice"`
+}
+
+func newWebhookSecretResult(webhook tailscale.Webhook, notice string) webhookSecretResult {
+	result := webhookSecretResult{WebhookSummary: summarizeWebhook(webhook), Notice: notice}
+	if webhook.Secret != nil {
+		result.Secret = *webhook.Secret
+	}
+	return result
+}
+
+func webhookIDSchema(description string) *jsonschema.Schema {
+	return &jsonschema.Schema{
+		Type: "object",
+		Properties: map[string]*jsonschema.Schema{
+			"webhookID": {
+				Type:        "string",
+				Description: description,
+			},
+		},
+		Required:             []string{"webhookID"},
+		AdditionalProperties: &jsonschema.Schema{Not: &jsonschema.Schema{}},
+	}
+}
+
+func RegisterWebhookTools(server *mcp.Server, client internal.TailscaleClient) {
+	// List webhooks tool
+	mcp.AddTool(
+		server,
+		&mcp.Tool{
+			Name: "list_webhooks",
+			Description: "List the tailnet's webhook endpoints with where they send events, their provider, " +
+				"and their event subscriptions. Webhook secrets are never included.",
+			InputSchema: &jsonschema.Schema{
+				Type: "object",
+				Properties: map[string]*jsonschema.Schema{
+					"event": {
+						Type:        "string",
+						Description: "Only list webhooks that are sent this event, directly or through its category",
+						Enum:        webhookEvents,
+					},
+				},
+				AdditionalProperties: &jsonschema.Schema{Not: &jsonschema.Schema{}},
+			},
+		},
+		func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
+			event, err := getOptionalStringParam(args, "event")
+			if err != nil {
+				return toolError("Invalid event parameter", err), nil, nil
+			}
+
+			webhooks, err := client.Webhooks().List(ctx)
+			if err != nil {
+				return toolError("Failed to list webhooks", err), nil, nil
+			}
+
+			summaries := make([]WebhookSummary, 0, len(webhooks))
+			bySubscription := map[string][]string{}
+			for _, webhook := range webhooks {
+				if event != "" && !webhookReceives(webhook, tailscale.WebhookSubscriptionType(event)) {
+					continue
+				}
+				summary := summarizeWebhook(webhook)
+				summaries = append(summaries, summary)
+				for _, subscription := range summary.Subscriptions {
+					bySubscription[subscription] = append(bySubscription[subscription], summary.URL)
+				}
+			}
+			slices.SortStableFunc(summaries, func(a, b WebhookSummary) int {
+				return strings.Compare(a.URL, b.URL)
+			})
+			for _, urls := range bySubscription {
+				slices.Sort(urls)
+			}
+
+			result := struct {
+				Count          int                 `json:"count"`
+				Webhooks       []WebhookSummary    `json:"webhooks"`
+				BySubscription map[string][]string `json:"bySubscription"`
+			}{
+				Count:          len(summaries),
+				Webhooks:       summaries,
+				BySubscription: bySubscription,
+			}
+
+			output, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				return toolError("Failed to serialize webhooks", err), nil, nil
+			}
+
+			return toolSuccess(string(output)), nil, nil
+		},
+	)
+
+	// Create webhook tool
+	mcp.AddTool(
+		server,
+		&mcp.Tool{
+			Name: "create_webhook",
+			Description: "Create a webhook endpoint that is sent the subscribed tailnet events. The signing secret is " +
+				"returned only in this response and cannot be retrieved again.",
+			InputSchema: &jsonschema.Schema{
+				Type: "object",
+				Properties: map[string]*jsonschema.Schema{
+					"url": {
+						Type:        "string",
+						Description: "The https:// URL events are sent to",
+					},
+					"provider": {
+						Type:        "string",
+						Description: "Format events for this provider (default: generic JSON)",
+						Enum:        webhookProviders,
+					},
+					"subscriptions": {
+						Type:        "array",
+						Description: webhookSubscriptionsDescription,
+						Items:       &jsonschema.Schema{Type: "string", Enum: webhookEvents},
+						MinItems:    jsonschema.Ptr(1),
+					},
+				},
+				Required:             []string{"url", "subscriptions"},
+				AdditionalProperties: &jsonschema.Schema{Not: &jsonschema.Schema{}},
+			},
+		},
+		func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
+			endpoint, err := getStringParam(args, "url")
+			if err != nil {
+				return toolError("Invalid url parameter", err), nil, nil
+			}
+			endpoint = strings.TrimSpace(endpoint)
+			if err := validateWebhookURL(endpoint); err != nil {
+				return toolError("Invalid url parameter", err), nil, nil
+			}
+
+			provider, err := getOptionalStringParam(args, "provider")
+			if err != nil {
+				return toolError("Invalid provider parameter", err), nil, nil
+			}
+			if provider == genericWebhookProvider {
+				provider = ""
+			}
+
+			subscriptions, err := getWebhookSubscriptionsParam(args)
+			if err != nil {
+				return toolError("Invalid subscriptions parameter", err), nil, nil
+			}
+
+			webhook, err := client.Webhooks().Create(ctx, tailscale.CreateWebhookRequest{
+				EndpointURL:   endpoint,
+				ProviderType:  tailscale.WebhookProviderType(provider),
+				Subscriptions: subscriptions,
+			})
+			if err != nil {
+				return toolError("Failed to create webhook", err), nil, nil
+			}
+
+			result := newWebhookSecretResult(*webhook,
+				"This is the only time the webhook secret is shown; store it where the receiver can verify event signatures.")
+
+			output, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				return toolError("Failed to serialize webhook", err), nil, nil
+			}
+
+			return toolSecretSuccess(string(output), redact.Path{"secret"}), nil, nil
+		},
+	)
+
+	// Update webhook subscriptions tool
+	mcp.AddTool(
+		server,
+		&mcp.Tool{
+			Name:        "update_webhook_subscriptions",
+			Description: "Replace the events a webhook endpoint is subscribed to",
+			InputSchema: &jsonschema.Schema{
+				Type: "object",
+				Properties: map[string]*jsonschema.Schema{
+					"webhookID": {
+						Type:        "string",
+						Description: "The ID of the webhook",
+					},
+					"subscriptions": {
+						Type:        "array",
+						Description: webhookSubscriptionsDescription,
+						Items:       &jsonschema.Schema{Type: "string", Enum: webhookEvents},
+						MinItems:    jsonschema.Ptr(1),
+					},
+				},
+				Required:             []string{"webhookID", "subscriptions"},
+				AdditionalProperties: &jsonschema.Schema{Not: &jsonschema.Schema{}},
+			},
+		},
+		func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
+			webhookID, err := getIDParam(args, "webhookID")
+			if err != nil {
+				return toolError("Invalid webhook ID parameter", err), nil, nil
+			}
+
+			subscriptions, err := getWebhookSubscriptionsParam(args)
+			if err != nil {
+				return toolError("Invalid subscriptions parameter", err), nil, nil
+			}
+
+			current, err := client.Webhooks().Get(ctx, webhookID)
+			if err != nil {
+				return toolError("Failed to get webhook", err), nil, nil
+			}
+
+			updated, err := client.Webhooks().Update(ctx, webhookID, subscriptions)
+			if err != nil {
+				return toolError("Failed to update webhook subscriptions", err), nil, nil
+			}
+
+			previous := summarizeWebhook(*current).Subscriptions
+			webhook := summarizeWebhook(*updated)
+			result := struct {
+				WebhookSummary
+				Previous []string `json:"previous"`
+				Added    []string `json:"added,omitempty"`
+				Removed  []string `json:"removed,omitempty"`
+			}{
+				WebhookSummary: webhook,
+				Previous:       previous,
+			}
+			for _, subscription := range webhook.Subscriptions {
+				if !slices.Contains(previous, subscription) {
+					result.Added = append(result.Added, subscription)
+				}
+			}
+			for _, subscription := range previous {
+				if !slices.Contains(webhook.Subscriptions, subscription) {
+					result.Removed = append(result.Removed, subscription)
+				}
+			}
+
+			output, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				return toolError("Failed to serialize webhook", err), nil, nil
+			}
+
+			return toolSuccess(string(output)), nil, nil
+		},
+	)
+
+	// Rotate webhook secret tool
+	mcp.AddTool(
+		server,
+		&mcp.Tool{
+			Name: "rotate_webhook_secret",
+			Description: "Generate a new signing secret for a webhook endpoint. The new secret is returned only in this " +
+				"response; the receiver must be updated to verify events with it.",
+			InputSchema: webhookIDSchema("The ID of the webhook"),
+		},
+		func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
+			webhookID, err := getIDParam(args, "webhookID")
+			if err != nil {
+				return toolError("Invalid webhook ID parameter", err), nil, nil
+			}
+
+			webhook, err := client.Webhooks().RotateSecret(ctx, webhookID)
+			if err != nil {
+				return toolError("Failed to rotate webhook secret", err), nil, nil
+			}
+
+			result := newWebhookSecretResult(*webhook,
+				"This is the only time the new webhook secret is shown; update the receiver to verify event signatures with it.")
+
+			output, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				return toolError("Failed to serialize webhook", err), nil, nil
+			}
+
+			return toolSecretSuccess(string(output), redact.Path{"secret"}), nil, nil
+		},
+	)
+
+	// Test webhook tool
+	mcp.AddTool(
+		server,
+		&mcp.Tool{
+			Name: "test_webhook",
+			Description: "Send a test event to a webhook endpoint. Tailscale delivers the event asynchronously, so the " +
+				"result reports whether the test was accepted for delivery; check the receiver to confirm it arrived.",
+			InputSchema: webhookIDSchema("The ID of the webhook to test"),
+		},
+		func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
+			webhookID, err := getIDParam(args, "webhookID")
+			if err != nil {
+				return toolError("Invalid webhook ID parameter", err), nil, nil
+			}
+
+			webhook, err := client.Webhooks().Get(ctx, webhookID)
+			if err != nil {
+				return toolError("Failed to get webhook", err), nil, nil
+			}
+
+			if err := client.Webhooks().Test(ctx, webhookID); err != nil {
+				return toolError("Test event was not accepted for delivery", err), nil, nil
+			}
+
+			result := struct {
+				ID      string `json:"id"`
+				URL     string `json:"url"`
+				Queued  bool   `json:"queued"`
+				Message string `json:"message"`
+			}{
+				ID:     webhook.EndpointID,
+				URL:    webhook.EndpointURL,
+				Queued: true,
+				Message: "Tailscale accepted the test event and sends it to the endpoint within a few seconds. " +
+					"Check the receiver to confirm it was delivered.",
+			}
+
+			output, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				return toolError("Failed to serialize result", err), nil, nil
+			}
+
+			return toolSuccess(string(output)), nil, nil
+		},
+	)
+
+	// Delete webhook tool
+	deleteSchema := webhookIDSchema("The ID of the webhook to delete")
+	deleteSchema.Properties["confirm"] = &jsonschema.Schema{
+		Type:        "string",
+		Description: "The endpoint URL of the webhook being deleted, to confirm the right webhook is targeted",
+	}
+	deleteSchema.Required = append(deleteSchema.Required, "confirm")
+
+	mcp.AddTool(
+		server,
+		&mcp.Tool{
+			Name: "delete_webhook",
+			Description: "Delete a webhook endpoint so it is no longer sent events. This cannot be undone. The confirm " +
+				"argument must repeat the webhook's endpoint URL exactly, as shown by list_webhooks.",
+			InputSchema: deleteSchema,
+		},
+		func(ctx context.Context, req *mcp.CallToolRequest, args map[string]any) (*mcp.CallToolResult, any, error) {
+			webhookID, err := getIDParam(args, "webhookID")
+			if err != nil {
+				return toolError("Invalid webhook ID parameter", err), nil, nil
+			}
+
+			confirm, err := getStringParam(args, "confirm")
+			if err != nil {
+				return toolError("Invalid confirm parameter", err), nil, nil
+			}
+
+			// Look the webhook up first to check the confirmation and so that the result describes
+			// what was deleted
+			webhook, err := client.Webhooks().Get(ctx, webhookID)
+			if err != nil {
+				return toolError("Failed to get webhook", err), nil, nil
+			}
+
+			if confirm != webhook.EndpointURL {
+				return toolError("Deletion not confirmed",
+					fmt.Errorf("confirm %q does not match the endpoint URL of webhook %s (%q)", confirm, webhookID, webhook.EndpointURL)), nil, nil
+			}
+
+			if err := client.Webhooks().Delete(ctx, webhookID); err != nil {
+				return toolError("Failed to delete webhook", err), nil, nil
+			}
+
+			result := struct {
+				WebhookSummary
+				Deleted bool `json:"deleted"`
+			}{
+				WebhookSummary: summarizeWebhook(*webhook),
+				Deleted:        true,
+			}
+
+			output, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				return toolError("Failed to serialize result", err), nil, nil
+			}
+
+			return toolSuccess(string(output)), nil, nil
+		},
+	)
+}
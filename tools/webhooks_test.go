@@ -0,0 +1,261 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	tailscale "tailscale.com/client/tailscale/v2"
+
+	"github.com/R167/tailscale-mcp/internal"
+)
+
+func testWebhooks() []tailscale.Webhook {
+	return []tailscale.Webhook{
+		{
+			EndpointID:    "w2",
+			EndpointURL:   "https://hooks.slack.com/services/prod",
+			ProviderType:  tailscale.WebhookSlackProviderType,
+			Subscriptions: []tailscale.WebhookSubscriptionType{tailscale.WebhookCategoryTailnetManagement},
+		},
+		{
+			EndpointID:       "w1",
+			EndpointURL:      "https://alerts.example.com/tailscale",
+			CreatorLoginName: "alice@example.com",
+			Subscriptions:    []tailscale.WebhookSubscriptionType{tailscale.WebhookNodeKeyExpired, tailscale.WebhookSubnetIPForwardingNotEnabled},
+		},
+	}
+}
+
+func webhookTestServer(webhooks *internal.MockWebhooksResource) *mcp.Server {
+	client := &internal.MockTailscaleClient{
+		WebhooksFunc: func() internal.WebhooksResource { return webhooks },
+	}
+
+	server := mcp.NewServer(&mcp.Implementation{}, &mcp.ServerOptions{})
+	RegisterWebhookTools(server, client)
+	return server
+}
+
+func TestWebhookReceives(t *testing.T) {
+	webhooks := testWebhooks()
+
+	if !webhookReceives(webhooks[0], tailscale.WebhookUserSuspended) {
+		t.Error("Expected a category subscription to include its events")
+	}
+	if webhookReceives(webhooks[0], tailscale.WebhookSubnetIPForwardingNotEnabled) {
+		t.Error("Expected a category subscription not to include other categories' events")
+	}
+	if !webhookReceives(webhooks[1], tailscale.WebhookNodeKeyExpired) || webhookReceives(webhooks[1], tailscale.WebhookNodeCreated) {
+		t.Error("Expected direct subscriptions to match only their own event")
+	}
+}
+
+func TestListWebhooks(t *testing.T) {
+	server := webhookTestServer(&internal.MockWebhooksResource{
+		ListFunc: func(ctx context.Context) ([]tailscale.Webhook, error) {
+			return testWebhooks(), nil
+		},
+	})
+
+	testCases := []struct {
+		name     string
+		args     map[string]any
+		expected []string
+	}{
+		{name: "All", args: nil, expected: []string{"w1", "w2"}},
+		{name: "Category", args: map[string]any{"event": "nodeKeyExpired"}, expected: []string{"w1", "w2"}},
+		{name: "Event", args: map[string]any{"event": "userCreated"}, expected: []string{"w2"}},
+		{name: "None", args: map[string]any{"event": "exitNodeIPForwardingNotEnabled"}, expected: []string{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, text := callTool(t, server, "list_webhooks", tc.args)
+			if result.IsError {
+				t.Fatalf("Expected success, got error: %s", text)
+			}
+
+			var output struct {
+				Count          int                 `json:"count"`
+				Webhooks       []WebhookSummary    `json:"webhooks"`
+				BySubscription map[string][]string `json:"bySubscription"`
+			}
+			if err := json.Unmarshal([]byte(text), &output); err != nil {
+				t.Fatalf("Failed to unmarshal output: %v", err)
+			}
+
+			ids := []string{}
+			for _, webhook := range output.Webhooks {
+				ids = append(ids, webhook.ID)
+			}
+			if output.Count != len(tc.expected) || !slices.Equal(ids, tc.expected) {
+				t.Errorf("Expected %v, got %v", tc.expected, ids)
+			}
+		})
+	}
+
+	_, text := callTool(t, server, "list_webhooks", nil)
+	if !strings.Contains(text, `"provider": "generic"`) || !strings.Contains(text, `"provider": "slack"`) {
+		t.Errorf("Expected provider names: %s", text)
+	}
+	if !strings.Contains(text, `"categoryTailnetManagement": [`) {
+		t.Errorf("Expected webhooks grouped by subscription: %s", text)
+	}
+}
+
+func TestCreateWebhook(t *testing.T) {
+	var created []tailscale.CreateWebhookRequest
+	server := webhookTestServer(&internal.MockWebhooksResource{
+		CreateFunc: func(ctx context.Context, request tailscale.CreateWebhookRequest) (*tailscale.Webhook, error) {
+			created = append(created, request)
+			secret := "whsec-created-once"
+			return &tailscale.Webhook{
+				EndpointID:    "w3",
+				EndpointURL:   request.EndpointURL,
+				ProviderType:  request.ProviderType,
+				Subscriptions: request.Subscriptions,
+				Secret:        &secret,
+			}, nil
+		},
+	})
+
+	result, text := callTool(t, server, "create_webhook", map[string]any{
+		"url":           "https://alerts.example.com/staging",
+		"provider":      "generic",
+		"subscriptions": []any{"nodeCreated", "policyUpdate", "nodeCreated"},
+	})
+	if result.IsError {
+		t.Fatalf("Expected success, got error: %s", text)
+	}
+	if !strings.Contains(text, `"secret": "whsec-created-once"`) || !strings.Contains(text, "only time") {
+		t.Errorf("Expected the secret to be returned once with a notice: %s", text)
+	}
+	if len(created) != 1 || created[0].ProviderType != tailscale.WebhookEmptyProviderType || len(created[0].Subscriptions) != 2 {
+		t.Errorf("Expected a generic webhook with two subscriptions, got %+v", created)
+	}
+
+	result, text = callTool(t, server, "create_webhook", map[string]any{
+		"url":           "http://alerts.example.com/staging",
+		"subscriptions": []any{"nodeCreated"},
+	})
+	if !result.IsError || !strings.Contains(text, "https://") {
+		t.Errorf("Expected a non-https URL to be refused, got %s", text)
+	}
+	if len(created) != 1 {
+		t.Errorf("Expected no webhook to be created for an invalid URL, got %d", len(created))
+	}
+}
+
+func TestUpdateWebhookSubscriptions(t *testing.T) {
+	var updated []tailscale.WebhookSubscriptionType
+	server := webhookTestServer(&internal.MockWebhooksResource{
+		GetFunc: func(ctx context.Context, endpointID string) (*tailscale.Webhook, error) {
+			return &testWebhooks()[1], nil
+		},
+		UpdateFunc: func(ctx context.Context, endpointID string, subscriptions []tailscale.WebhookSubscriptionType) (*tailscale.Webhook, error) {
+			updated = subscriptions
+			webhook := testWebhooks()[1]
+			webhook.Subscriptions = subscriptions
+			return &webhook, nil
+		},
+	})
+
+	result, text := callTool(t, server, "update_webhook_subscriptions", map[string]any{
+		"webhookID":     "w1",
+		"subscriptions": []any{"nodeKeyExpired", "nodeKeyExpiringInOneDay"},
+	})
+	if result.IsError {
+		t.Fatalf("Expected success, got error: %s", text)
+	}
+	if len(updated) != 2 {
+		t.Errorf("Expected the subscriptions to be replaced, got %v", updated)
+	}
+
+	var output struct {
+		Added   []string `json:"added"`
+		Removed []string `json:"removed"`
+	}
+	if err := json.Unmarshal([]byte(text), &output); err != nil {
+		t.Fatalf("Failed to unmarshal output: %v", err)
+	}
+	if !slices.Equal(output.Added, []string{"nodeKeyExpiringInOneDay"}) || !slices.Equal(output.Removed, []string{"subnetIPForwardingNotEnabled"}) {
+		t.Errorf("Unexpected changes: %s", text)
+	}
+}
+
+func TestRotateWebhookSecret(t *testing.T) {
+	server := webhookTestServer(&internal.MockWebhooksResource{})
+
+	result, text := callTool(t, server, "rotate_webhook_secret", map[string]any{"webhookID": "w1"})
+	if result.IsError {
+		t.Fatalf("Expected success, got error: %s", text)
+	}
+	if !strings.Contains(text, `"secret": "mock-rotated-secret"`) {
+		t.Errorf("Expected the new secret to be returned: %s", text)
+	}
+}
+
+func TestTestWebhook(t *testing.T) {
+	tested := 0
+	server := webhookTestServer(&internal.MockWebhooksResource{
+		TestFunc: func(ctx context.Context, endpointID string) error {
+			tested++
+			if endpointID == "broken" {
+				return fmt.Errorf("endpoint is disabled")
+			}
+			return nil
+		},
+	})
+
+	result, text := callTool(t, server, "test_webhook", map[string]any{"webhookID": "w1"})
+	if result.IsError || !strings.Contains(text, `"queued": true`) {
+		t.Errorf("Expected the test event to be queued, got %s", text)
+	}
+
+	result, text = callTool(t, server, "test_webhook", map[string]any{"webhookID": "broken"})
+	if !result.IsError || !strings.Contains(text, "endpoint is disabled") {
+		t.Errorf("Expected the delivery failure to be reported, got %s", text)
+	}
+	if tested != 2 {
+		t.Errorf("Expected two test events, got %d", tested)
+	}
+}
+
+func TestDeleteWebhook(t *testing.T) {
+	var deleted []string
+	server := webhookTestServer(&internal.MockWebhooksResource{
+		GetFunc: func(ctx context.Context, endpointID string) (*tailscale.Webhook, error) {
+			return nil, tailscale.APIError{Message: "webhook not found", Status: 404}
+		},
+		DeleteFunc: func(ctx context.Context, endpointID string) error {
+			deleted = append(deleted, endpointID)
+			return nil
+		},
+	})
+
+	result, text := callTool(t, server, "delete_webhook", map[string]any{"webhookID": "missing", "confirm": "https://alerts.example.com/tailscale"})
+	if !result.IsError || len(deleted) != 0 {
+		t.Errorf("Expected an unknown webhook not to be deleted, got %s", text)
+	}
+
+	server = webhookTestServer(&internal.MockWebhooksResource{
+		DeleteFunc: func(ctx context.Context, endpointID string) error {
+			deleted = append(deleted, endpointID)
+			return nil
+		},
+	})
+	result, text = callTool(t, server, "delete_webhook", map[string]any{"webhookID": "w1", "confirm": "https://alerts.example.com/other"})
+	if !result.IsError || !strings.Contains(text, "Deletion not confirmed") || len(deleted) != 0 {
+		t.Errorf("Expected a mismatched confirmation to be rejected, got %s", text)
+	}
+
+	result, text = callTool(t, server, "delete_webhook", map[string]any{"webhookID": "w1", "confirm": "https://alerts.example.com/tailscale"})
+	if result.IsError || !strings.Contains(text, `"deleted": true`) || !slices.Equal(deleted, []string{"w1"}) {
+		t.Errorf("Expected the webhook to be deleted, got %s", text)
+	}
+}